@@ -6,10 +6,12 @@ package command
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
 	"math"
+	"os"
 	"strconv"
 	"strings"
 	"sync"
@@ -17,9 +19,10 @@ import (
 
 	"github.com/hashicorp/hcl/v2"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer/hcl2template"
 	"github.com/hashicorp/packer/internal/hcp/registry"
 	"github.com/hashicorp/packer/packer"
-	"golang.org/x/sync/semaphore"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
 
 	"github.com/hako/durafmt"
 	"github.com/posener/complete"
@@ -70,6 +73,7 @@ func (c *BuildCommand) ParseArgs(args []string) (*BuildArgs, int) {
 func writeDiags(ui packersdk.Ui, files map[string]*hcl.File, diags hcl.Diagnostics) int {
 	// write HCL errors/diagnostics if any.
 	b := bytes.NewBuffer(nil)
+	diags = hcl2template.RedactSensitiveDiagnostics(diags)
 	err := hcl.NewDiagnosticTextWriter(b, files, 80, false).WriteDiagnostics(diags)
 	if err != nil {
 		ui.Error("could not write diagnostic: " + err.Error())
@@ -154,6 +158,13 @@ func (c *BuildCommand) RunContext(buildCtx context.Context, cla *BuildArgs) int
 		c.Ui.Say("Debug mode enabled. Builds will not be parallelized.")
 	}
 
+	if cla.DebugConsole {
+		console := &debugConsole{evaluator: packerStarter}
+		for _, b := range builds {
+			b.DebugConsole = console
+		}
+	}
+
 	// Compile all the UIs for the builds
 	colors := [5]packer.UiColor{
 		packer.UiColorGreen,
@@ -218,24 +229,57 @@ func (c *BuildCommand) RunContext(buildCtx context.Context, cla *BuildArgs) int
 		sync.RWMutex
 		m map[string]error
 	}{m: make(map[string]error)}
-	limitParallel := semaphore.NewWeighted(cla.ParallelBuilds)
+	// Errors of post-processors that were allowed to fail
+	var warnings = struct {
+		sync.RWMutex
+		m map[string][]error
+	}{m: make(map[string][]error)}
+	scheduler := newBuildScheduler(cla.ParallelBuilds, cla.ConcurrencyLimits)
+	for _, b := range builds {
+		scheduler.add(b)
+	}
 
-	for i := range builds {
-		if err := buildCtx.Err(); err != nil {
-			log.Println("Interrupted, not going to start any more builds.")
+	// runCtx is cancelled when the -timeout is reached, buildCtx is only
+	// cancelled on interruption.
+	runCtx := buildCtx
+	if cla.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(buildCtx, cla.Timeout)
+		defer cancel()
+	}
+	timedOut := func() bool {
+		return buildCtx.Err() == nil && errors.Is(runCtx.Err(), context.DeadlineExceeded)
+	}
+
+	for {
+		b, err := scheduler.next(runCtx)
+		if err == nil {
+			err = runCtx.Err()
+		}
+		if err != nil {
+			notStarted := scheduler.pending()
+			if b != nil {
+				notStarted = append(notStarted, b)
+				scheduler.release(b)
+			}
+			if timedOut() {
+				log.Println("Timed out, not going to start any more builds.")
+				errs.Lock()
+				for _, b := range notStarted {
+					errs.m[b.Name()] = &packer.TimeoutError{Timeout: cla.Timeout}
+				}
+				errs.Unlock()
+			} else {
+				log.Println("Interrupted, not going to start any more builds.")
+			}
+			break
+		}
+		if b == nil {
 			break
 		}
 
-		b := builds[i]
 		name := b.Name()
 		ui := buildUis[b]
-		if err := limitParallel.Acquire(buildCtx, 1); err != nil {
-			ui.Error(fmt.Sprintf("Build '%s' failed to acquire semaphore: %s", name, err))
-			errs.Lock()
-			errs.m[name] = err
-			errs.Unlock()
-			break
-		}
 		// Increment the waitgroup so we wait for this item to finish properly
 		wg.Add(1)
 
@@ -246,7 +290,7 @@ func (c *BuildCommand) RunContext(buildCtx context.Context, cla *BuildArgs) int
 
 			defer wg.Done()
 
-			defer limitParallel.Release(1)
+			defer scheduler.release(b)
 
 			err := hcpRegistry.StartBuild(buildCtx, b)
 			// Seems odd to require this error check here. Now that it is an error we can just exit with diag
@@ -269,7 +313,10 @@ func (c *BuildCommand) RunContext(buildCtx context.Context, cla *BuildArgs) int
 			}
 
 			log.Printf("Starting build run: %s", name)
-			runArtifacts, err := b.Run(buildCtx, ui)
+			runArtifacts, err := b.Run(runCtx, ui)
+			if err != nil && timedOut() && !errors.As(err, new(*packer.TimeoutError)) {
+				err = &packer.TimeoutError{Timeout: cla.Timeout, Err: err}
+			}
 
 			// Get the duration of the build and parse it
 			buildEnd := time.Now()
@@ -307,13 +354,28 @@ Check that you are using an HCP Ready integration before trying again:
 				}
 			}
 
+			if buildWarnings := b.Warnings(); len(buildWarnings) > 0 {
+				warnings.Lock()
+				warnings.m[name] = buildWarnings
+				warnings.Unlock()
+			}
+
+			attempts := ""
+			if b.Retry.Attempts > 1 {
+				(&packer.TargetedUI{
+					Target: name,
+					Ui:     c.Ui,
+				}).Machine("attempts", strconv.Itoa(b.Attempts()))
+				attempts = fmt.Sprintf(" (%d/%d attempts)", b.Attempts(), b.Retry.Attempts)
+			}
+
 			if err != nil {
-				ui.Error(fmt.Sprintf("Build '%s' errored after %s: %s", name, fmtBuildDuration, err))
+				ui.Error(fmt.Sprintf("Build '%s' errored after %s%s: %s", name, fmtBuildDuration, attempts, err))
 				errs.Lock()
 				errs.m[name] = err
 				errs.Unlock()
 			} else {
-				ui.Say(fmt.Sprintf("Build '%s' finished after %s.", name, fmtBuildDuration))
+				ui.Say(fmt.Sprintf("Build '%s' finished after %s%s.", name, fmtBuildDuration, attempts))
 				if runArtifacts != nil {
 					artifacts.Lock()
 					artifacts.m[name] = runArtifacts
@@ -375,6 +437,24 @@ Check that you are using an HCP Ready integration before trying again:
 		}
 	}
 
+	if len(warnings.m) > 0 {
+		c.Ui.Machine("warning-count", strconv.FormatInt(int64(len(warnings.m)), 10))
+
+		c.Ui.Error("\n==> Some builds completed with warnings:")
+		for name, buildWarnings := range warnings.m {
+			ui := &packer.TargetedUI{
+				Target: name,
+				Ui:     c.Ui,
+			}
+
+			for _, warning := range buildWarnings {
+				ui.Machine("warning", warning.Error())
+
+				c.Ui.Error(fmt.Sprintf("--> %s: %s", name, warning))
+			}
+		}
+	}
+
 	if len(artifacts.m) > 0 {
 		c.Ui.Say("\n==> Builds finished. The artifacts of successful builds are:")
 		for name, buildArtifacts := range artifacts.m {
@@ -425,6 +505,30 @@ Check that you are using an HCP Ready integration before trying again:
 		c.Ui.Say("\n==> Builds finished but no artifacts were created.")
 	}
 
+	outputs, diags := packerStarter.EvaluateOutputs(artifacts.m)
+	// The outputs that were evaluated are still reported when others failed.
+	if writeDiags(c.Ui, nil, diags) != 0 {
+		ret = 1
+	}
+	if len(outputs) > 0 {
+		c.Ui.Say("\n==> Outputs:")
+		for _, output := range outputs {
+			if output.Sensitive {
+				c.Ui.Say(fmt.Sprintf("--> %s: <sensitive>", output.Name))
+				continue
+			}
+			value := hcl2template.PrintableCtyValue(output.Value)
+			c.Ui.Machine("output", output.Name, value)
+			c.Ui.Say(fmt.Sprintf("--> %s: %s", output.Name, value))
+		}
+	}
+	if cla.OutputFile != "" {
+		if err := writeOutputsFile(cla.OutputFile, outputs); err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to write outputs to %s: %s", cla.OutputFile, err))
+			return 1
+		}
+	}
+
 	if len(errs.m) > 0 {
 		// If any errors occurred, exit with a non-zero exit status
 		ret = 1
@@ -433,6 +537,41 @@ Check that you are using an HCP Ready integration before trying again:
 	return ret
 }
 
+// writeOutputsFile writes outputs to path as a JSON object of the outputs
+// by name, with their value, type and sensitivity.
+func writeOutputsFile(path string, outputs []packer.Output) error {
+	type jsonOutput struct {
+		Sensitive   bool            `json:"sensitive"`
+		Description string          `json:"description,omitempty"`
+		Type        json.RawMessage `json:"type"`
+		Value       json.RawMessage `json:"value"`
+	}
+
+	res := map[string]jsonOutput{}
+	for _, output := range outputs {
+		value, err := ctyjson.Marshal(output.Value, output.Value.Type())
+		if err != nil {
+			return fmt.Errorf("output %q: %s", output.Name, err)
+		}
+		valueType, err := ctyjson.MarshalType(output.Value.Type())
+		if err != nil {
+			return fmt.Errorf("output %q: %s", output.Name, err)
+		}
+		res[output.Name] = jsonOutput{
+			Sensitive:   output.Sensitive,
+			Description: output.Description,
+			Type:        valueType,
+			Value:       value,
+		}
+	}
+
+	b, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0644)
+}
+
 func (*BuildCommand) Help() string {
 	helpText := `
 Usage: packer build [options] TEMPLATE
@@ -444,12 +583,16 @@ Options:
 
   -color=false                  Disable color output. (Default: color)
   -debug                        Debug mode enabled for builds.
+  -debug-console                Start a debug console at breakpoint provisioners, and when a provisioner fails with -on-error=ask.
   -except=foo,bar,baz           Run all builds and post-processors other than these.
   -only=foo,bar,baz             Build only the specified builds.
   -force                        Force a build to continue if artifacts exist, deletes existing artifacts.
   -machine-readable             Produce machine-readable output.
   -on-error=[cleanup|abort|ask|run-cleanup-provisioner] If the build fails do: clean up (default), abort, ask, or run-cleanup-provisioner.
+  -output-file=path             Write the values of the output blocks to a JSON file.
   -parallel-builds=1            Number of builds to run in parallel. 1 disables parallelization. 0 means no limit (Default: 0)
+  -concurrency=group=N          Number of builds of a concurrency group or builder type to run in parallel, can be used multiple times.
+  -timeout=0                    Cancel all builds that did not complete after this duration, e.g. 2h. 0 means no timeout (Default: 0)
   -timestamp-ui                 Enable prefixing of each ui output with an RFC3339 timestamp.
   -var 'key=value'              Variable for templates, can be used multiple times.
   -var-file=path                JSON or HCL2 file containing user variables, can be used multiple times.
@@ -472,13 +615,17 @@ func (*BuildCommand) AutocompleteArgs() complete.Predictor {
 func (*BuildCommand) AutocompleteFlags() complete.Flags {
 	return complete.Flags{
 		"-color":            complete.PredictNothing,
+		"-concurrency":      complete.PredictNothing,
 		"-debug":            complete.PredictNothing,
+		"-debug-console":    complete.PredictNothing,
 		"-except":           complete.PredictNothing,
 		"-only":             complete.PredictNothing,
 		"-force":            complete.PredictNothing,
 		"-machine-readable": complete.PredictNothing,
 		"-on-error":         complete.PredictNothing,
+		"-output-file":      complete.PredictNothing,
 		"-parallel":         complete.PredictNothing,
+		"-timeout":          complete.PredictNothing,
 		"-timestamp-ui":     complete.PredictNothing,
 		"-var":              complete.PredictNothing,
 		"-var-file":         complete.PredictNothing,
@@ -0,0 +1,146 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"context"
+	"sync"
+
+	"github.com/hashicorp/packer/packer"
+)
+
+// defaultConcurrencyGroup holds the builds that are only bound by the global
+// -parallel-builds limit.
+const defaultConcurrencyGroup = ""
+
+// buildScheduler hands out the builds to run while respecting the global limit
+// of concurrent builds, as well as the limits set per concurrency group.
+//
+// Builds of a group are started in order. Groups are served in a round-robin
+// fashion so that a group with a low limit is not starved by bigger groups,
+// and does not hold back builds of other groups when it is full.
+type buildScheduler struct {
+	mu sync.Mutex
+	// wake is signaled each time a build is released.
+	wake chan struct{}
+
+	limit   int64
+	running int64
+
+	groupLimits  map[string]int64
+	groupRunning map[string]int64
+	queues       map[string][]*packer.CoreBuild
+	groups       []string
+	nextGroup    int
+}
+
+func newBuildScheduler(limit int64, groupLimits map[string]int64) *buildScheduler {
+	return &buildScheduler{
+		wake:         make(chan struct{}, 1),
+		limit:        limit,
+		groupLimits:  groupLimits,
+		groupRunning: map[string]int64{},
+		queues:       map[string][]*packer.CoreBuild{},
+	}
+}
+
+// concurrencyGroup returns the group a build belongs to: its
+// concurrency_group if a limit is set for it, otherwise its builder type if
+// a limit is set for it, otherwise the default group.
+func (s *buildScheduler) concurrencyGroup(b *packer.CoreBuild) string {
+	for _, group := range []string{b.ConcurrencyGroup, b.BuilderType} {
+		if group == "" {
+			continue
+		}
+		if _, ok := s.groupLimits[group]; ok {
+			return group
+		}
+	}
+	return defaultConcurrencyGroup
+}
+
+// add queues a build to be run.
+func (s *buildScheduler) add(b *packer.CoreBuild) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	group := s.concurrencyGroup(b)
+	if _, ok := s.queues[group]; !ok {
+		s.groups = append(s.groups, group)
+	}
+	s.queues[group] = append(s.queues[group], b)
+}
+
+// next blocks until a queued build can be started and returns it. It returns
+// nil when no build is left to run, or the context error if ctx is done first.
+func (s *buildScheduler) next(ctx context.Context) (*packer.CoreBuild, error) {
+	for {
+		b, left := s.pick()
+		if b != nil || !left {
+			return b, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-s.wake:
+		}
+	}
+}
+
+// pick returns the next build that can be started, if any, and whether builds
+// are still queued.
+func (s *buildScheduler) pick() (*packer.CoreBuild, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	left := false
+	for i := range s.groups {
+		group := s.groups[(s.nextGroup+i)%len(s.groups)]
+		queue := s.queues[group]
+		if len(queue) == 0 {
+			continue
+		}
+		left = true
+		if s.running >= s.limit {
+			break
+		}
+		if limit, ok := s.groupLimits[group]; ok && s.groupRunning[group] >= limit {
+			continue
+		}
+
+		b := queue[0]
+		s.queues[group] = queue[1:]
+		s.running++
+		s.groupRunning[group]++
+		s.nextGroup = (s.nextGroup + i + 1) % len(s.groups)
+		return b, true
+	}
+	return nil, left
+}
+
+// release frees the slots taken by a build returned by next.
+func (s *buildScheduler) release(b *packer.CoreBuild) {
+	s.mu.Lock()
+	s.running--
+	s.groupRunning[s.concurrencyGroup(b)]--
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// pending returns the builds that were not started yet.
+func (s *buildScheduler) pending() []*packer.CoreBuild {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var res []*packer.CoreBuild
+	for _, group := range s.groups {
+		res = append(res, s.queues[group]...)
+	}
+	return res
+}
@@ -0,0 +1,97 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/packer/packer"
+)
+
+func TestBuildScheduler(t *testing.T) {
+	builds := []*packer.CoreBuild{
+		{Type: "qemu.a", BuilderType: "qemu"},
+		{Type: "qemu.b", BuilderType: "qemu"},
+		{Type: "qemu.c", BuilderType: "qemu"},
+		{Type: "docker.a", BuilderType: "docker"},
+		{Type: "docker.b", BuilderType: "docker"},
+		{Type: "docker.c", BuilderType: "docker", ConcurrencyGroup: "slow"},
+	}
+
+	s := newBuildScheduler(4, map[string]int64{"qemu": 1, "slow": 1})
+	for _, b := range builds {
+		s.add(b)
+	}
+
+	var started []string
+	next := func() *packer.CoreBuild {
+		t.Helper()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		b, err := s.next(ctx)
+		if err != nil {
+			return nil
+		}
+		started = append(started, b.Name())
+		return b
+	}
+
+	// Only one qemu build can run, groups are served in turns.
+	for i := 0; i < 3; i++ {
+		next()
+	}
+	if diff := cmp.Diff([]string{"qemu.a", "docker.a", "docker.c"}, started); diff != "" {
+		t.Fatalf("unexpected started builds: %s", diff)
+	}
+
+	// The default group still has room.
+	next()
+	// The global limit is reached.
+	if b := next(); b != nil {
+		t.Fatalf("expected no build to start, got %s", b.Name())
+	}
+
+	// Releasing the qemu build lets the next qemu build start.
+	s.release(builds[0])
+	next()
+	if diff := cmp.Diff([]string{"qemu.a", "docker.a", "docker.c", "docker.b", "qemu.b"}, started); diff != "" {
+		t.Fatalf("unexpected started builds: %s", diff)
+	}
+
+	if pending := s.pending(); len(pending) != 1 || pending[0] != builds[2] {
+		t.Fatalf("expected qemu.c to be pending, got %v", pending)
+	}
+}
+
+func TestBuildScheduler_noLimits(t *testing.T) {
+	builds := []*packer.CoreBuild{
+		{Type: "qemu.a", BuilderType: "qemu"},
+		{Type: "docker.a", BuilderType: "docker"},
+		{Type: "qemu.b", BuilderType: "qemu"},
+	}
+
+	s := newBuildScheduler(1, nil)
+	for _, b := range builds {
+		s.add(b)
+	}
+
+	// Without limits builds are run in order.
+	for _, want := range builds {
+		b, err := s.next(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b != want {
+			t.Fatalf("expected %s, got %s", want.Name(), b.Name())
+		}
+		s.release(b)
+	}
+
+	if b, err := s.next(context.Background()); b != nil || err != nil {
+		t.Fatalf("expected no more builds, got %v, %v", b, err)
+	}
+}
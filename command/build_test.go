@@ -4,10 +4,13 @@
 package command
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"math"
 	"os"
 	"path/filepath"
+	"reflect"
 	"regexp"
 	"runtime"
 	"strings"
@@ -15,6 +18,7 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/hashicorp/go-uuid"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 )
 
 var (
@@ -343,6 +347,59 @@ func TestBuild(t *testing.T) {
 				},
 			},
 		},
+		// for_each and count
+		{
+			name: "hcl - for_each and count",
+			args: []string{
+				testFixture("hcl", "for_each"),
+			},
+			fileCheck: fileCheck{
+				expected: []string{"apple.txt", "banana.txt", "cake-0.txt", "cake-1.txt"},
+			},
+		},
+		{
+			name: "hcl - for_each - only one instance",
+			args: []string{
+				`-only=file.fruit["banana"]`,
+				testFixture("hcl", "for_each"),
+			},
+			fileCheck: fileCheck{
+				notExpected: []string{"apple.txt", "cake-0.txt", "cake-1.txt"},
+				expected:    []string{"banana.txt"},
+			},
+		},
+		{
+			name: "hcl - count - only all instances",
+			args: []string{
+				"-only=file.cake",
+				testFixture("hcl", "for_each"),
+			},
+			fileCheck: fileCheck{
+				notExpected: []string{"apple.txt", "banana.txt"},
+				expected:    []string{"cake-0.txt", "cake-1.txt"},
+			},
+		},
+		// check blocks
+		{
+			name: "hcl - failed check fails the build",
+			args: []string{
+				filepath.Join(testFixture("hcl", "check"), "error.pkr.hcl"),
+			},
+			fileCheck: fileCheck{
+				expected: []string{"chocolate.txt"},
+			},
+			expectedCode: 1,
+		},
+		{
+			name: "hcl - failed check with on_failure = warn",
+			args: []string{
+				filepath.Join(testFixture("hcl", "check"), "warn.pkr.hcl"),
+			},
+			fileCheck: fileCheck{
+				expected: []string{"chocolate.txt"},
+			},
+			expectedCode: 0,
+		},
 		{
 			name: "hcl - build.name accessible",
 			args: []string{
@@ -583,6 +640,100 @@ func Test_build_output(t *testing.T) {
 	}
 }
 
+func TestBuildOutputs(t *testing.T) {
+	c := &BuildCommand{
+		Meta: TestMetaFile(t),
+	}
+
+	outputFile := filepath.Join(t.TempDir(), "outputs.json")
+	args := []string{
+		"-output-file=" + outputFile,
+		testFixture("hcl", "outputs"),
+	}
+
+	defer cleanup()
+
+	if code := c.Run(args); code != 0 {
+		fatalCommand(t, c.Meta)
+	}
+
+	out := c.Meta.Ui.(*packersdk.BasicUi).Writer.(*bytes.Buffer).String()
+	for _, expected := range []string{
+		"==> Outputs:",
+		"--> file_count: 1",
+		"--> secret: <sensitive>",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("output should contain %q:\n%s", expected, out)
+		}
+	}
+	if strings.Contains(out, "s3cr3t") {
+		t.Errorf("sensitive output should not be displayed:\n%s", out)
+	}
+
+	b, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("failed to read outputs: %s", err)
+	}
+	var outputs map[string]struct {
+		Sensitive   bool        `json:"sensitive"`
+		Description string      `json:"description"`
+		Type        interface{} `json:"type"`
+		Value       interface{} `json:"value"`
+	}
+	if err := json.Unmarshal(b, &outputs); err != nil {
+		t.Fatalf("failed to decode outputs: %s", err)
+	}
+
+	files := outputs["files"]
+	if files.Description != "The files written by the build." ||
+		!reflect.DeepEqual(files.Type, []interface{}{"list", "string"}) ||
+		!reflect.DeepEqual(files.Value, []interface{}{"chocolate.txt"}) {
+		t.Errorf("unexpected files output: %#v", files)
+	}
+	if count := outputs["file_count"]; count.Value != float64(1) {
+		t.Errorf("unexpected file_count output: %#v", count)
+	}
+	if secret := outputs["secret"]; !secret.Sensitive || secret.Value != "s3cr3t" {
+		t.Errorf("unexpected secret output: %#v", secret)
+	}
+}
+
+func TestBuildOutputs_evaluationError(t *testing.T) {
+	c := &BuildCommand{
+		Meta: TestMetaFile(t),
+	}
+
+	outputFile := filepath.Join(t.TempDir(), "outputs.json")
+	args := []string{
+		"-output-file=" + outputFile,
+		testFixture("hcl", "outputs-error"),
+	}
+
+	defer cleanup()
+
+	if code := c.Run(args); code != 1 {
+		t.Fatalf("expected exit status 1, got %d", code)
+	}
+
+	out := c.Meta.Ui.(*packersdk.BasicUi).Writer.(*bytes.Buffer).String()
+	if !strings.Contains(out, "--> file_count: 1") {
+		t.Errorf("the valid output should be displayed:\n%s", out)
+	}
+
+	b, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("failed to read outputs: %s", err)
+	}
+	var outputs map[string]interface{}
+	if err := json.Unmarshal(b, &outputs); err != nil {
+		t.Fatalf("failed to decode outputs: %s", err)
+	}
+	if _, ok := outputs["file_count"]; !ok || len(outputs) != 1 {
+		t.Errorf("only the valid output should be written, got %v", outputs)
+	}
+}
+
 func TestBuildOnlyFileCommaFlags(t *testing.T) {
 	c := &BuildCommand{
 		Meta: TestMetaFile(t),
@@ -1131,6 +1282,23 @@ func TestBuildCommand_ParseArgs(t *testing.T) {
 			},
 			0,
 		},
+		{fields{defaultMeta},
+			args{[]string{"-parallel-builds=10", "-concurrency=qemu=2", "-concurrency=docker=8", "file.json"}},
+			&BuildArgs{
+				MetaArgs:          MetaArgs{Path: "file.json"},
+				ParallelBuilds:    10,
+				ConcurrencyLimits: map[string]int64{"qemu": 2, "docker": 8},
+				Color:             true,
+			},
+			0,
+		},
+		{fields{defaultMeta},
+			args{[]string{"-concurrency=qemu=0", "file.json"}},
+			&BuildArgs{
+				Color: true,
+			},
+			1,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(fmt.Sprintf("%s", tt.args.args), func(t *testing.T) {
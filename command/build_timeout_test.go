@@ -6,6 +6,7 @@ package command
 import (
 	"bytes"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
@@ -75,3 +76,29 @@ func TestBuildSleepTimeout(t *testing.T) {
 		}
 	}
 }
+
+func TestBuildCommandTimeout(t *testing.T) {
+	defer cleanup()
+
+	c := &BuildCommand{
+		Meta: testMetaSleepFile(t),
+	}
+
+	args := []string{
+		"-timeout=100ms",
+		filepath.Join(testFixture("timeout"), "sleep.pkr.hcl"),
+	}
+
+	if code := c.Run(args); code == 0 {
+		fatalCommand(t, c.Meta)
+	}
+
+	if fileExists("campanules.txt") {
+		t.Errorf("Expected to not find campanules.txt")
+	}
+
+	errOut := c.Meta.Ui.(*packersdk.BasicUi).ErrorWriter.(*bytes.Buffer).String()
+	if !strings.Contains(errOut, "file.roses: timed out after 100ms") {
+		t.Errorf("Expected a timeout error in the build summary, got:\n%s", errOut)
+	}
+}
@@ -5,7 +5,10 @@ package command
 
 import (
 	"flag"
+	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/packer/command/enumflag"
 	kvflag "github.com/hashicorp/packer/command/flag-kv"
@@ -76,6 +79,9 @@ type MetaArgs struct {
 	// WarnOnUndeclared does not have a common default, as the default varies per sub-command usage.
 	// Refer to individual command FlagSets for usage.
 	WarnOnUndeclaredVar bool
+	// WarnOnUnusedVar reports the variables and locals that are declared
+	// but never referenced. With StrictUnusedVar, they are reported as errors.
+	WarnOnUnusedVar, StrictUnusedVar bool
 	// UseSequential specifies to use a sequential/phased approach for
 	// evaluating datasources/locals instead of a DAG.
 	//
@@ -87,11 +93,15 @@ type MetaArgs struct {
 func (ba *BuildArgs) AddFlagSets(flags *flag.FlagSet) {
 	flags.BoolVar(&ba.Color, "color", true, "")
 	flags.BoolVar(&ba.Debug, "debug", false, "")
+	flags.BoolVar(&ba.DebugConsole, "debug-console", false, "")
 	flags.BoolVar(&ba.Force, "force", false, "")
 	flags.BoolVar(&ba.TimestampUi, "timestamp-ui", false, "")
 	flags.BoolVar(&ba.MachineReadable, "machine-readable", false, "")
 
 	flags.Int64Var(&ba.ParallelBuilds, "parallel-builds", 0, "")
+	flags.DurationVar(&ba.Timeout, "timeout", 0, "")
+	flags.Var((*concurrencyFlag)(&ba.ConcurrencyLimits), "concurrency", "")
+	flags.StringVar(&ba.OutputFile, "output-file", "", "")
 
 	flagOnError := enumflag.New(&ba.OnError, "cleanup", "abort", "ask", "run-cleanup-provisioner")
 	flags.Var(flagOnError, "on-error", "")
@@ -136,6 +146,45 @@ type BuildArgs struct {
 	ParallelBuilds                      int64
 	OnError                             string
 	ReleaseOnly                         bool
+	// DebugConsole starts a debug console at breakpoint provisioners and,
+	// with -on-error=ask, when a provisioner fails.
+	DebugConsole bool
+	// Timeout bounds the time all the builds can take. Zero means no
+	// timeout.
+	Timeout time.Duration
+	// ConcurrencyLimits is the maximum number of builds that can run at
+	// the same time per concurrency group or builder type.
+	ConcurrencyLimits map[string]int64
+	// OutputFile is the path of the JSON file the outputs of the config are
+	// written to.
+	OutputFile string
+}
+
+// concurrencyFlag is a flag.Value implementation for parsing concurrency
+// limits from the command-line in the format of '-concurrency group=N'.
+type concurrencyFlag map[string]int64
+
+func (v *concurrencyFlag) String() string {
+	return ""
+}
+
+func (v *concurrencyFlag) Set(raw string) error {
+	idx := strings.Index(raw, "=")
+	if idx == -1 {
+		return fmt.Errorf("No '=' value in arg: %s", raw)
+	}
+
+	key, value := raw[0:idx], raw[idx+1:]
+	limit, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || limit < 1 {
+		return fmt.Errorf("Invalid concurrency limit %q for %q, must be a positive integer", value, key)
+	}
+
+	if *v == nil {
+		*v = make(map[string]int64)
+	}
+	(*v)[key] = limit
+	return nil
 }
 
 func (ia *InitArgs) AddFlagSets(flags *flag.FlagSet) {
@@ -159,11 +208,15 @@ type PluginsRequiredArgs struct {
 
 func (ca *ConsoleArgs) AddFlagSets(flags *flag.FlagSet) {
 	flags.BoolVar(&ca.MetaArgs.UseSequential, "use-sequential-evaluation", false, "Fallback to using a sequential approach for local/datasource evaluation.")
+	flags.StringVar(&ca.Build, "build", "", "Evaluate expressions in the context of this build.")
 }
 
 // ConsoleArgs represents a parsed cli line for a `packer console`
 type ConsoleArgs struct {
 	MetaArgs
+	// Build is the name of the build in the context of which expressions
+	// are evaluated, like its provisioners see them.
+	Build string
 }
 
 func (fa *FixArgs) AddFlagSets(flags *flag.FlagSet) {
@@ -181,6 +234,8 @@ type FixArgs struct {
 func (va *ValidateArgs) AddFlagSets(flags *flag.FlagSet) {
 	flags.BoolVar(&va.SyntaxOnly, "syntax-only", false, "check syntax only")
 	flags.BoolVar(&va.NoWarnUndeclaredVar, "no-warn-undeclared-var", false, "Ignore warnings for variable files containing undeclared variables.")
+	flags.BoolVar(&va.MetaArgs.WarnOnUnusedVar, "unused", false, "Warn about declared variables and locals that are never referenced.")
+	flags.BoolVar(&va.MetaArgs.StrictUnusedVar, "strict", false, "Report unused variables and locals as errors, implies -unused.")
 	flags.BoolVar(&va.EvaluateDatasources, "evaluate-datasources", false, "evaluate datasources for validation (HCL2 only, may incur costs)")
 	flags.BoolVar(&va.ReleaseOnly, "ignore-prerelease-plugins", false, "Disable the loading of prerelease plugin binaries (x.y.z-dev).")
 	flags.BoolVar(&va.MetaArgs.UseSequential, "use-sequential-evaluation", false, "Fallback to using a sequential approach for local/datasource evaluation.")
@@ -197,6 +252,8 @@ type ValidateArgs struct {
 }
 
 func (va *InspectArgs) AddFlagSets(flags *flag.FlagSet) {
+	flags.BoolVar(&va.VarSources, "var-sources", false, "List the sources of the value of each variable.")
+	flags.BoolVar(&va.JSON, "json", false, "Output as JSON.")
 	flags.BoolVar(&va.MetaArgs.UseSequential, "use-sequential-evaluation", false, "Fallback to using a sequential approach for local/datasource evaluation.")
 	va.MetaArgs.AddFlagSets(flags)
 }
@@ -204,6 +261,22 @@ func (va *InspectArgs) AddFlagSets(flags *flag.FlagSet) {
 // InspectArgs represents a parsed cli line for a `packer inspect`
 type InspectArgs struct {
 	MetaArgs
+	VarSources, JSON bool
+}
+
+func (va *GraphArgs) AddFlagSets(flags *flag.FlagSet) {
+	va.Type = "full"
+	flagType := enumflag.New(&va.Type, "prereqs", "builds", "full")
+	flags.Var(flagType, "type", "Blocks in the graph: prereqs, builds or full.")
+	flags.BoolVar(&va.JSON, "json", false, "Output as JSON.")
+	va.MetaArgs.AddFlagSets(flags)
+}
+
+// GraphArgs represents a parsed cli line for a `packer graph`
+type GraphArgs struct {
+	MetaArgs
+	Type string
+	JSON bool
 }
 
 func (va *HCL2UpgradeArgs) AddFlagSets(flags *flag.FlagSet) {
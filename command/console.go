@@ -9,8 +9,10 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"unicode"
 
 	"github.com/chzyer/readline"
+	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/packer/helper/wrappedreadline"
 	"github.com/hashicorp/packer/helper/wrappedstreams"
 	"github.com/hashicorp/packer/packer"
@@ -64,16 +66,35 @@ func (c *ConsoleCommand) RunContext(ctx context.Context, cla *ConsoleArgs) int {
 		return ret
 	}
 
-	_ = packerStarter.Initialize(packer.InitializeOptions{
-		UseSequential: cla.UseSequential,
+	diags := packerStarter.Initialize(packer.InitializeOptions{
+		UseSequential:       cla.UseSequential,
+		AllowUnsetVariables: true,
 	})
+	if ret := writeDiags(c.Ui, nil, diags); ret != 0 {
+		return ret
+	}
+
+	var evaluator packer.Evaluator = packerStarter
+	if cla.Build != "" {
+		buildEvaluator, ok := packerStarter.(packer.BuildEvaluator)
+		if !ok {
+			return writeDiags(c.Ui, nil, hcl.Diagnostics{&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "The -build option is only supported with HCL2 templates",
+			}})
+		}
+		evaluator, diags = buildEvaluator.BuildEvaluator(cla.Build)
+		if ret := writeDiags(c.Ui, nil, diags); ret != 0 {
+			return ret
+		}
+	}
 
 	// Determine if stdin is a pipe. If so, we evaluate directly.
 	if c.StdinPiped() {
-		return c.modePiped(packerStarter)
+		return c.modePiped(evaluator)
 	}
 
-	return c.modeInteractive(packerStarter)
+	return c.modeInteractive(evaluator)
 }
 
 func (*ConsoleCommand) Help() string {
@@ -86,6 +107,7 @@ Usage: packer console [options] [TEMPLATE]
   interpolation.
 
 Options:
+  -build=NAME                   Evaluate expressions like the provisioners of the build NAME see them.
   -var 'key=value'              Variable for templates, can be used multiple times.
   -var-file=path                JSON or HCL2 file containing user variables.
   -config-type                  Set to 'hcl2' to run in HCL2 mode when no file is passed. Defaults to json.
@@ -105,6 +127,7 @@ func (*ConsoleCommand) AutocompleteArgs() complete.Predictor {
 
 func (*ConsoleCommand) AutocompleteFlags() complete.Flags {
 	return complete.Flags{
+		"-build":    complete.PredictNothing,
 		"-var":      complete.PredictNothing,
 		"-var-file": complete.PredictNothing,
 	}
@@ -130,12 +153,16 @@ func (c *ConsoleCommand) modePiped(cfg packer.Evaluator) int {
 
 func (c *ConsoleCommand) modeInteractive(cfg packer.Evaluator) int {
 	// Setup the UI so we can output directly to stdout
-	l, err := readline.NewEx(wrappedreadline.Override(&readline.Config{
+	rlConfig := &readline.Config{
 		Prompt:            "> ",
 		InterruptPrompt:   "^C",
 		EOFPrompt:         "exit",
 		HistorySearchFold: true,
-	}))
+	}
+	if completer, ok := cfg.(packer.ConsoleCompleter); ok {
+		rlConfig.AutoComplete = &consoleAutoCompleter{candidates: completer.CompletionCandidates()}
+	}
+	l, err := readline.NewEx(wrappedreadline.Override(rlConfig))
 	if err != nil {
 		c.Ui.Error(fmt.Sprintf(
 			"Error initializing console: %s",
@@ -167,3 +194,35 @@ func (c *ConsoleCommand) modeInteractive(cfg packer.Evaluator) int {
 
 	return 0
 }
+
+// consoleAutoCompleter completes the name of the variable, attribute or
+// function under the cursor.
+type consoleAutoCompleter struct {
+	candidates []string
+}
+
+var _ readline.AutoCompleter = new(consoleAutoCompleter)
+
+func (c *consoleAutoCompleter) Do(line []rune, pos int) ([][]rune, int) {
+	start := pos
+	for start > 0 && isNameRune(line[start-1]) {
+		start--
+	}
+	prefix := string(line[start:pos])
+	if prefix == "" {
+		return nil, 0
+	}
+
+	var res [][]rune
+	for _, candidate := range c.candidates {
+		if candidate != prefix && strings.HasPrefix(candidate, prefix) {
+			res = append(res, []rune(candidate[len(prefix):]))
+		}
+	}
+	return res, pos - start
+}
+
+// isNameRune tells whether r can be part of a traversal like var.foo.
+func isNameRune(r rune) bool {
+	return r == '.' || r == '_' || r == '-' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -41,6 +41,10 @@ func Test_console(t *testing.T) {
 		{"var.untyped", []string{"console", `-var=untyped=just_a_string`, filepath.Join(testFixture("hcl", "variables", "untyped_var"))}, nil, "just_a_string\n"},
 		{"var.untyped", []string{"console", filepath.Join(testFixture("hcl", "variables", "untyped_var", "var.pkr.hcl"))}, nil, "<unknown>\n"},
 		{"var.untyped", []string{"console", filepath.Join(testFixture("hcl", "variables", "untyped_var", "var.pkr.hcl"))}, []string{"PKR_VAR_untyped=just_a_string"}, "just_a_string\n"},
+		{"source.name", []string{"console", "-build=app.null.example", testFixture("console-build")}, nil, "example\n"},
+		{"build.name", []string{"console", "-build=app.null.example", testFixture("console-build")}, nil, "app\n"},
+		{"build.ID", []string{"console", "-build=app.null.example", testFixture("console-build")}, nil, packer.BasicPlaceholderData()["ID"] + "\n"},
+		{`"${var.greeting} from ${source.type}.${source.name}"`, []string{"console", "-build=app.null.example", testFixture("console-build")}, nil, "hello from null.example\n"},
 	}
 
 	for _, tc := range tc {
@@ -56,3 +60,45 @@ func Test_console(t *testing.T) {
 		})
 	}
 }
+
+func Test_console_unknownBuild(t *testing.T) {
+	p := helperCommand(t, "console", "-build=nope", testFixture("console-build"))
+	p.Stdin = strings.NewReader("source.name")
+	bs, err := p.CombinedOutput()
+	if err == nil {
+		t.Fatalf("expected an error, got %s", bs)
+	}
+	if !strings.Contains(string(bs), `Unknown build "nope"`) || !strings.Contains(string(bs), "app.null.example") {
+		t.Fatalf("unexpected output: %s", bs)
+	}
+}
+
+func TestConsoleAutoCompleter(t *testing.T) {
+	c := &consoleAutoCompleter{candidates: []string{
+		"local.foo",
+		"local.foobar",
+		"lower(",
+		"var.images",
+		"var.images.key",
+	}}
+	tests := []struct {
+		line       string
+		want       []string
+		wantLength int
+	}{
+		{"", nil, 0},
+		{"lo", []string{"cal.foo", "cal.foobar", "wer("}, 2},
+		{"upper(local.foo", []string{"bar"}, 9},
+		{"var.images", []string{".key"}, 10},
+		{"1 + nope", nil, 4},
+	}
+	for _, tt := range tests {
+		got, length := c.Do([]rune(tt.line), len([]rune(tt.line)))
+		var gotStrings []string
+		for _, r := range got {
+			gotStrings = append(gotStrings, string(r))
+		}
+		assert.Equal(t, tt.want, gotStrings, tt.line)
+		assert.Equal(t, tt.wantLength, length, tt.line)
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/hcl/v2"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer/packer"
+)
+
+const debugConsoleHelp = `Packer debug console.
+The build is paused; type in an expression to evaluate it, the "build"
+variable describes the paused build:
+
+  build.name               the name of the build.
+  build.generated_data     the data generated by the builder.
+  build.provisioner_index  the index of the current provisioner.
+  build.provisioner_type   the type of the current provisioner.
+  build.error              the error of the failed provisioner, if any.
+
+Commands:
+
+  !exec <command>          run a command on the machine being built.
+  !upload <src> <dst>      upload a local file or directory to the machine.
+  !retry                   run the failed provisioner again.
+  !skip                    ignore the failure and run the next provisioner.
+  !continue                resume the build.
+  !help                    show this help.`
+
+// debugConsole is the packer.DebugConsole of the build command. It evaluates
+// expressions like the console command does.
+type debugConsole struct {
+	evaluator packer.Evaluator
+
+	// l makes sure only one paused build at a time reads the input.
+	l sync.Mutex
+}
+
+var _ packer.DebugConsole = new(debugConsole)
+
+func (c *debugConsole) Run(ctx context.Context, s *packer.DebugSession) packer.DebugAction {
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	if s.Err != nil {
+		s.Ui.Error(fmt.Sprintf("Provisioner %d (%s) failed: %s", s.ProvisionerIndex, s.ProvisionerType, s.Err))
+	}
+	s.Ui.Say(`Debug console started, type "!help" for the list of commands.`)
+
+	for {
+		line, err := askContext(ctx, s.Ui, "debug>")
+		if err != nil {
+			if ctx.Err() == nil {
+				s.Ui.Error(fmt.Sprintf("Error reading the debug console input: %s, continuing.", err))
+			}
+			return packer.DebugContinue
+		}
+		line = strings.TrimSpace(line)
+
+		if !strings.HasPrefix(line, "!") {
+			out, exit, diags := c.evaluate(line, s)
+			writeDiags(s.Ui, nil, diags)
+			if exit {
+				return packer.DebugContinue
+			}
+			if out != "" {
+				s.Ui.Say(out)
+			}
+			continue
+		}
+
+		command, args, _ := strings.Cut(line[1:], " ")
+		args = strings.TrimSpace(args)
+		switch command {
+		case "continue", "c":
+			return packer.DebugContinue
+		case "retry", "skip":
+			if s.Err == nil {
+				s.Ui.Error(fmt.Sprintf("Cannot %s: the build is paused at a breakpoint, not on a failure.", command))
+				continue
+			}
+			if command == "retry" {
+				return packer.DebugRetry
+			}
+			return packer.DebugSkip
+		case "exec":
+			if args == "" {
+				s.Ui.Error("Usage: !exec <command>")
+				continue
+			}
+			status, err := s.Exec(ctx, args)
+			if err != nil {
+				s.Ui.Error(fmt.Sprintf("Error running %q: %s", args, err))
+				continue
+			}
+			s.Ui.Say(fmt.Sprintf("Exit status: %d", status))
+		case "upload":
+			src, dst, _ := strings.Cut(args, " ")
+			dst = strings.TrimSpace(dst)
+			if src == "" || dst == "" {
+				s.Ui.Error("Usage: !upload <src> <dst>")
+				continue
+			}
+			if err := s.Upload(src, dst); err != nil {
+				s.Ui.Error(err.Error())
+				continue
+			}
+			s.Ui.Say(fmt.Sprintf("Uploaded %s to %s", src, dst))
+		case "help":
+			s.Ui.Say(debugConsoleHelp)
+		default:
+			s.Ui.Error(fmt.Sprintf("Unknown command %q, type \"!help\" for the list of commands.", line))
+		}
+	}
+}
+
+// evaluate evaluates an expression, with the `build` variable when the
+// evaluator supports it.
+func (c *debugConsole) evaluate(line string, s *packer.DebugSession) (string, bool, hcl.Diagnostics) {
+	if evaluator, ok := c.evaluator.(packer.DebugEvaluator); ok {
+		return evaluator.EvaluateDebugExpression(line, s)
+	}
+	return c.evaluator.EvaluateExpression(line)
+}
+
+// askContext asks query to the user, returning early when ctx is cancelled.
+func askContext(ctx context.Context, ui packersdk.Ui, query string) (string, error) {
+	type answer struct {
+		line string
+		err  error
+	}
+	result := make(chan answer, 1)
+	go func() {
+		line, err := ui.Ask(query)
+		result <- answer{line, err}
+	}()
+
+	select {
+	case res := <-result:
+		return res.line, res.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
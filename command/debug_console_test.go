@@ -0,0 +1,148 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer/packer"
+)
+
+// scriptedTTY answers the questions of a Ui with the given lines, in order.
+type scriptedTTY struct {
+	lines []string
+}
+
+func (tty *scriptedTTY) Close() error { return nil }
+func (tty *scriptedTTY) ReadString() (string, error) {
+	if len(tty.lines) == 0 {
+		return "", errors.New("no more input")
+	}
+	line := tty.lines[0]
+	tty.lines = tty.lines[1:]
+	return line + "\n", nil
+}
+
+func TestDebugConsole(t *testing.T) {
+	c := &BuildCommand{Meta: testMeta(t)}
+	cfg, ret := c.GetConfig(&MetaArgs{Path: filepath.Join(testFixture("var-arg"), "map.pkr.hcl")})
+	if ret != 0 {
+		t.Fatalf("GetConfig: %d", ret)
+	}
+	if diags := cfg.Initialize(packer.InitializeOptions{}); diags.HasErrors() {
+		t.Fatalf("Initialize: %s", diags)
+	}
+
+	upload := filepath.Join(t.TempDir(), "script.sh")
+	if err := os.WriteFile(upload, []byte("echo hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		err        error
+		input      []string
+		wantAction packer.DebugAction
+		wantOut    []string
+		wantErrOut []string
+	}{
+		{
+			name: "failure",
+			err:  errors.New("script exited with non-zero exit status: 1"),
+			input: []string{
+				"build.generated_data.Host",
+				"build.provisioner_index",
+				"build.error",
+				`var.images["key"]`,
+				"!exec systemctl status nginx",
+				"!upload " + upload + " /tmp/script.sh",
+				"!nope",
+				"!retry",
+			},
+			wantAction: packer.DebugRetry,
+			wantOut: []string{
+				"10.0.0.1\n",
+				"1\n",
+				"script exited with non-zero exit status: 1\n",
+				"value\n",
+				"Exit status: 0\n",
+				"Uploaded " + upload + " to /tmp/script.sh\n",
+			},
+			wantErrOut: []string{
+				"Provisioner 1 (shell) failed: script exited with non-zero exit status: 1",
+				`Unknown command "!nope"`,
+			},
+		},
+		{
+			name:       "skip",
+			err:        errors.New("failed"),
+			input:      []string{"!skip"},
+			wantAction: packer.DebugSkip,
+		},
+		{
+			name:       "breakpoint",
+			input:      []string{"!retry", "!skip", "build.name", "!continue"},
+			wantAction: packer.DebugContinue,
+			wantOut:    []string{"null.test\n"},
+			wantErrOut: []string{
+				"Cannot retry: the build is paused at a breakpoint",
+				"Cannot skip: the build is paused at a breakpoint",
+			},
+		},
+		{
+			name:       "exit",
+			input:      []string{"exit"},
+			wantAction: packer.DebugContinue,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			comm := new(packersdk.MockCommunicator)
+			session := &packer.DebugSession{
+				BuildName:        "null.test",
+				ProvisionerIndex: 1,
+				ProvisionerType:  "shell",
+				GeneratedData:    map[string]interface{}{"Host": "10.0.0.1"},
+				Err:              tt.err,
+				Ui: &packersdk.BasicUi{
+					Writer:      &out,
+					ErrorWriter: &errOut,
+					TTY:         &scriptedTTY{lines: tt.input},
+				},
+				Comm: comm,
+			}
+
+			console := &debugConsole{evaluator: cfg}
+			if action := console.Run(context.Background(), session); action != tt.wantAction {
+				t.Fatalf("Run() = %v, want %v", action, tt.wantAction)
+			}
+			for _, want := range tt.wantOut {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("output %q does not contain %q", out.String(), want)
+				}
+			}
+			for _, want := range tt.wantErrOut {
+				if !strings.Contains(errOut.String(), want) {
+					t.Errorf("error output %q does not contain %q", errOut.String(), want)
+				}
+			}
+			if tt.name == "failure" {
+				if comm.StartCmd == nil || comm.StartCmd.Command != "systemctl status nginx" {
+					t.Errorf("unexpected command run: %#v", comm.StartCmd)
+				}
+				if comm.UploadPath != "/tmp/script.sh" || comm.UploadData != "echo hello" {
+					t.Errorf("unexpected upload of %q to %q", comm.UploadData, comm.UploadPath)
+				}
+			}
+		})
+	}
+}
@@ -87,6 +87,8 @@ func TestHelperProcess(*testing.T) {
 		os.Exit((&ConsoleCommand{Meta: commandMeta()}).Run(args))
 	case "fmt":
 		os.Exit((&FormatCommand{Meta: commandMeta()}).Run(args))
+	case "graph":
+		os.Exit((&GraphCommand{Meta: commandMeta()}).Run(args))
 	case "inspect":
 		os.Exit((&InspectCommand{Meta: commandMeta()}).Run(args))
 	case "build":
@@ -0,0 +1,116 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/packer/packer"
+	"github.com/posener/complete"
+)
+
+type GraphCommand struct {
+	Meta
+}
+
+func (c *GraphCommand) Run(args []string) int {
+	ctx := context.Background()
+
+	cfg, ret := c.ParseArgs(args)
+	if ret != 0 {
+		return ret
+	}
+
+	return c.RunContext(ctx, cfg)
+}
+
+func (c *GraphCommand) ParseArgs(args []string) (*GraphArgs, int) {
+	var cfg GraphArgs
+	flags := c.Meta.FlagSet("graph")
+	flags.Usage = func() { c.Ui.Say(c.Help()) }
+	cfg.AddFlagSets(flags)
+	if err := flags.Parse(args); err != nil {
+		return &cfg, 1
+	}
+
+	args = flags.Args()
+	if len(args) != 1 {
+		flags.Usage()
+		return &cfg, 1
+	}
+	cfg.Path = args[0]
+	return &cfg, 0
+}
+
+func (c *GraphCommand) RunContext(ctx context.Context, cla *GraphArgs) int {
+	packerStarter, ret := c.GetConfig(&cla.MetaArgs)
+	if ret != 0 {
+		return ret
+	}
+
+	grapher, ok := packerStarter.(packer.ConfigGrapher)
+	if !ok {
+		c.Ui.Error("The graph command only supports HCL2 templates.")
+		return 1
+	}
+
+	// here we ignore init diags so that the graph of a template with
+	// dependency cycles or unset variables can still be output
+	_ = packerStarter.Initialize(packer.InitializeOptions{
+		SkipDatasourcesExecution: true,
+		AllowUnsetVariables:      true,
+	})
+
+	return grapher.GraphConfig(packer.GraphConfigOptions{
+		Ui:   c.Ui,
+		Type: cla.Type,
+		JSON: cla.JSON,
+	})
+}
+
+func (*GraphCommand) Help() string {
+	helpText := `
+Usage: packer graph [options] TEMPLATE
+
+  Outputs the graph of the blocks of a template, in the DOT language of
+  Graphviz or as JSON. Edges go from a block to the blocks that depend on it,
+  or that run after it. Dependency cycles are highlighted in red.
+
+  The DOT output can be rendered as an image with Graphviz:
+
+      $ packer graph . | dot -Tsvg > graph.svg
+
+  This command is only supported for HCL2 templates.
+
+Options:
+
+  -type=full                    Blocks in the graph:
+                                  prereqs: variables, locals and datasources.
+                                  builds: sources, builds, provisioners and
+                                  post-processors.
+                                  full: all of the above, with the references
+                                  of the build blocks (default).
+  -json                         Output as JSON.
+  -var 'key=value'              Variable for templates, can be used multiple times.
+  -var-file=path                JSON or HCL2 file containing user variables, can be used multiple times.
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *GraphCommand) Synopsis() string {
+	return "output the graph of a template"
+}
+
+func (c *GraphCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *GraphCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"-type": complete.PredictSet("prereqs", "builds", "full"),
+		"-json": complete.PredictNothing,
+	}
+}
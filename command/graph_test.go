@@ -0,0 +1,132 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_graph(t *testing.T) {
+	tc := []struct {
+		command  []string
+		expected string
+	}{
+		{[]string{"graph", testFixture("graph")}, `digraph {
+	rankdir = "LR"
+
+	"var.name" [shape = "ellipse"]
+	"local.image" [shape = "ellipse"]
+	"source.null.a" [shape = "component"]
+	"build.app" [shape = "box3d"]
+	"build.app.provisioner[0].shell-local" [shape = "box"]
+	"build.app.post-processors[0][0].manifest" [shape = "box"]
+
+	"build.app" -> "build.app.provisioner[0].shell-local"
+	"build.app.provisioner[0].shell-local" -> "build.app.post-processors[0][0].manifest"
+	"local.image" -> "build.app.provisioner[0].shell-local"
+	"source.null.a" -> "build.app"
+	"var.name" -> "local.image"
+}
+`},
+		{[]string{"graph", "-type=builds", testFixture("graph")}, `digraph {
+	rankdir = "LR"
+
+	"source.null.a" [shape = "component"]
+	"build.app" [shape = "box3d"]
+	"build.app.provisioner[0].shell-local" [shape = "box"]
+	"build.app.post-processors[0][0].manifest" [shape = "box"]
+
+	"build.app" -> "build.app.provisioner[0].shell-local"
+	"build.app.provisioner[0].shell-local" -> "build.app.post-processors[0][0].manifest"
+	"source.null.a" -> "build.app"
+}
+`},
+		{[]string{"graph", "-type=prereqs", filepath.Join(testFixture("graph"), "cycle")}, `digraph {
+	rankdir = "LR"
+
+	"local.a" [shape = "ellipse", color = "red"]
+	"local.b" [shape = "ellipse", color = "red"]
+	"local.c" [shape = "ellipse"]
+
+	"local.a" -> "local.b" [color = "red"]
+	"local.a" -> "local.c"
+	"local.b" -> "local.a" [color = "red"]
+}
+`},
+		{[]string{"graph", "-type=prereqs", "-json", filepath.Join(testFixture("graph"), "cycle")}, `{
+  "format_version": "1.0",
+  "type": "prereqs",
+  "nodes": [
+    {
+      "address": "local.a",
+      "kind": "local",
+      "in_cycle": true
+    },
+    {
+      "address": "local.b",
+      "kind": "local",
+      "in_cycle": true
+    },
+    {
+      "address": "local.c",
+      "kind": "local",
+      "in_cycle": false
+    }
+  ],
+  "edges": [
+    {
+      "from": "local.a",
+      "to": "local.b",
+      "in_cycle": true
+    },
+    {
+      "from": "local.a",
+      "to": "local.c",
+      "in_cycle": false
+    },
+    {
+      "from": "local.b",
+      "to": "local.a",
+      "in_cycle": true
+    }
+  ],
+  "cycles": [
+    [
+      "local.a",
+      "local.b"
+    ]
+  ]
+}
+`},
+	}
+
+	for _, tc := range tc {
+		t.Run(fmt.Sprintf("packer %s", tc.command), func(t *testing.T) {
+			p := helperCommand(t, tc.command...)
+			bs, err := p.Output()
+			if err != nil {
+				t.Fatalf("%v: %s", err, bs)
+			}
+			if diff := cmp.Diff(tc.expected, string(bs)); diff != "" {
+				t.Fatalf("unexpected output %s", diff)
+			}
+		})
+	}
+}
+
+func Test_graph_legacyJSON(t *testing.T) {
+	p := helperCommand(t, "graph", filepath.Join(testFixture("build-only"), "template.json"))
+	bs, err := p.CombinedOutput()
+	if err == nil {
+		t.Fatalf("expected an error, got %s", bs)
+	}
+	if !strings.Contains(string(bs), "only supports HCL2 templates") {
+		t.Fatalf("unexpected output: %s", bs)
+	}
+}
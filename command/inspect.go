@@ -54,7 +54,9 @@ func (c *InspectCommand) RunContext(ctx context.Context, cla *InspectArgs) int {
 	})
 
 	return packerStarter.InspectConfig(packer.InspectConfigOptions{
-		Ui: c.Ui,
+		Ui:         c.Ui,
+		VarSources: cla.VarSources,
+		JSON:       cla.JSON,
 	})
 }
 
@@ -69,6 +71,10 @@ Usage: packer inspect TEMPLATE
 Options:
 
   -machine-readable             Machine-readable output
+  -var 'key=value'              Variable for templates, can be used multiple times.
+  -var-file=path                JSON or HCL2 file containing user variables, can be used multiple times.
+  -var-sources                  List the sources of the value of each variable, by precedence (HCL2 only).
+  -json                         Output as JSON (HCL2 only).
   -use-sequential-evaluation    Fallback to using a sequential approach for local/datasource evaluation.
 `
 
@@ -86,5 +92,7 @@ func (c *InspectCommand) AutocompleteArgs() complete.Predictor {
 func (c *InspectCommand) AutocompleteFlags() complete.Flags {
 	return complete.Flags{
 		"-machine-readable": complete.PredictNothing,
+		"-var-sources":      complete.PredictNothing,
+		"-json":             complete.PredictNothing,
 	}
 }
@@ -167,6 +167,31 @@ and therefore only show in their raw form here.
 			nil,
 			testFixtureContent("hcl-inspect-with-sensitive-vars", "expected-output.txt"),
 		},
+		{
+			[]string{
+				"inspect", "-var-sources",
+				"-var-file=" + testFixture("hcl-inspect-var-sources", "overrides.pkrvars.hcl"),
+				"-var=region=eu-west-3",
+				testFixture("hcl-inspect-var-sources"),
+			},
+			[]string{"PKR_VAR_region=eu-west-2"},
+			testFixtureContent("hcl-inspect-var-sources", "expected-output.txt"),
+		},
+		{
+			[]string{
+				"inspect", "-var-sources", "-json",
+				"-var-file=" + testFixture("hcl-inspect-var-sources", "overrides.pkrvars.hcl"),
+				"-var=region=eu-west-3",
+				testFixture("hcl-inspect-var-sources"),
+			},
+			[]string{"PKR_VAR_region=eu-west-2"},
+			testFixtureContent("hcl-inspect-var-sources", "expected-output.json"),
+		},
+		{
+			[]string{"inspect", "-json", testFixture("hcl-inspect-json")},
+			nil,
+			testFixtureContent("hcl-inspect-json", "expected-output.json"),
+		},
 	}
 
 	for _, tc := range tc {
@@ -0,0 +1,78 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/packer/internal/lsp"
+	"github.com/hashicorp/packer/version"
+	"github.com/posener/complete"
+)
+
+type LspCommand struct {
+	Meta
+}
+
+func (c *LspCommand) Run(args []string) int {
+	flags := c.Meta.FlagSet("lsp")
+	flags.Usage = func() { c.Ui.Say(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+	if len(flags.Args()) != 0 {
+		flags.Usage()
+		return 1
+	}
+
+	return c.RunContext(context.Background())
+}
+
+func (c *LspCommand) RunContext(ctx context.Context) int {
+	server := &lsp.Server{
+		PluginConfig:            c.CoreConfig.Components.PluginConfig,
+		CorePackerVersion:       version.SemVer,
+		CorePackerVersionString: version.FormattedVersion(),
+	}
+
+	// The protocol is spoken over stdio, so errors go to stderr.
+	if err := server.Serve(ctx, os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "Language server error: %s\n", err)
+		return 1
+	}
+	return 0
+}
+
+func (*LspCommand) Help() string {
+	helpText := `
+Usage: packer lsp
+
+  Starts a language server for HCL2 templates, speaking the Language Server
+  Protocol over stdin and stdout. It is meant to be started by an editor.
+
+  The server reports the errors of the templates as they are edited,
+  finds the definitions of variables, locals, data sources and sources,
+  documents functions on hover, and completes the blocks and arguments of
+  templates, including the arguments of installed plugins.
+
+  Data sources are not executed.
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (*LspCommand) Synopsis() string {
+	return "Starts a language server for HCL2 templates"
+}
+
+func (*LspCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (*LspCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{}
+}
@@ -130,6 +130,8 @@ func (m *Meta) GetConfigFromHCL(cla *MetaArgs) (*hcl2template.PackerConfig, int)
 		PluginConfig:            m.CoreConfig.Components.PluginConfig,
 		ValidationOptions: hcl2template.ValidationOptions{
 			WarnOnUndeclaredVar: cla.WarnOnUndeclaredVar,
+			WarnOnUnused:        cla.WarnOnUnusedVar || cla.StrictUnusedVar,
+			Strict:              cla.StrictUnusedVar,
 		},
 	}
 	cfg, diags := parser.Parse(cla.Path, cla.VarFiles, cla.Vars)
@@ -0,0 +1,13 @@
+variable "greeting" {
+  type    = string
+  default = "hello"
+}
+
+source "null" "example" {
+  communicator = "none"
+}
+
+build {
+  name    = "app"
+  sources = ["source.null.example"]
+}
@@ -0,0 +1,23 @@
+variable "name" {
+  type    = string
+  default = "app"
+}
+
+locals {
+  image = "${var.name}-image"
+}
+
+source "null" "a" {
+  communicator = "none"
+}
+
+build {
+  name    = "app"
+  sources = ["source.null.a"]
+
+  provisioner "shell-local" {
+    inline = ["echo ${local.image}"]
+  }
+
+  post-processor "manifest" {}
+}
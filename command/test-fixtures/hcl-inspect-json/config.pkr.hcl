@@ -0,0 +1,67 @@
+packer {
+  required_version = ">= 1.7.0"
+  required_plugins {
+    amazon = {
+      source  = "github.com/hashicorp/amazon"
+      version = "~> 1.2"
+    }
+  }
+}
+
+variable "region" {
+  type        = string
+  description = "The region of the image."
+  default     = "eu-west-1"
+}
+
+variable "password" {
+  type      = string
+  default   = "s3cr3t"
+  sensitive = true
+}
+
+variable "tags" {
+  type = map(string)
+}
+
+locals {
+  name = "image-${var.region}"
+}
+
+source "null" "base" {
+  communicator = "none"
+}
+
+build {
+  name        = "image"
+  description = "Builds the image."
+
+  hcp_packer_registry {
+    bucket_name = "image"
+    bucket_labels = {
+      "os" = "linux"
+    }
+  }
+
+  sources = ["source.null.base"]
+
+  provisioner "shell-local" {
+    name   = "hello"
+    inline = ["echo hello"]
+  }
+
+  error-cleanup-provisioner "shell-local" {
+    inline = ["echo cleanup"]
+  }
+
+  post-processor "manifest" {}
+
+  post-processors {
+    post-processor "shell-local" {
+      inline = ["echo one"]
+    }
+    post-processor "manifest" {
+      output = "manifest.json"
+    }
+  }
+}
@@ -0,0 +1,3 @@
+
+size     = 16
+password = "s3cr3t"
@@ -0,0 +1 @@
+region = "eu-west-1"
@@ -0,0 +1,19 @@
+variable "region" {
+  type    = string
+  default = "us-east-1"
+}
+
+variable "size" {
+  type    = number
+  default = 8
+}
+
+variable "password" {
+  type      = string
+  default   = "changeme"
+  sensitive = true
+}
+
+variable "unset" {
+  type = string
+}
@@ -0,0 +1,24 @@
+source "file" "chocolate" {
+  content = "chocolate"
+  target  = "chocolate.txt"
+}
+
+build {
+  sources = ["source.file.chocolate"]
+}
+
+check "files" {
+  assert {
+    condition     = contains(build.artifacts[0].files, "chocolate.txt")
+    error_message = "The chocolate was not written."
+  }
+}
+
+check "more_files" {
+  on_failure = "error"
+
+  assert {
+    condition     = length(build.artifacts[0].files) > 1
+    error_message = "Only ${length(build.artifacts[0].files)} file was written."
+  }
+}
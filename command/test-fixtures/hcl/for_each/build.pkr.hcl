@@ -0,0 +1,16 @@
+source "file" "fruit" {
+  content = "fruit"
+}
+
+build {
+  source "source.file.fruit" {
+    for_each = ["apple", "banana"]
+    target   = "${each.key}.txt"
+  }
+
+  source "source.file.fruit" {
+    name   = "cake"
+    count  = 2
+    target = "cake-${count.index}.txt"
+  }
+}
@@ -0,0 +1,16 @@
+source "file" "chocolate" {
+  content = "chocolate"
+  target  = "chocolate.txt"
+}
+
+build {
+  sources = ["source.file.chocolate"]
+}
+
+output "file_count" {
+  value = length(build["file.chocolate"].artifacts[0].files)
+}
+
+output "missing" {
+  value = build["file.vanilla"].artifacts
+}
@@ -0,0 +1,22 @@
+source "file" "chocolate" {
+  content = "chocolate"
+  target  = "chocolate.txt"
+}
+
+build {
+  sources = ["source.file.chocolate"]
+}
+
+output "files" {
+  description = "The files written by the build."
+  value       = build["file.chocolate"].artifacts[0].files
+}
+
+output "file_count" {
+  value = length(build["file.chocolate"].artifacts[0].files)
+}
+
+output "secret" {
+  value     = "s3cr3t"
+  sensitive = true
+}
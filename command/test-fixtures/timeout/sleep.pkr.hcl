@@ -0,0 +1,16 @@
+source "file" "roses" {
+  content = "roses"
+  target  = "roses.txt"
+}
+
+build {
+  sources = ["source.file.roses"]
+
+  provisioner "shell-local" {
+    inline = ["sleep 10"]
+  }
+
+  provisioner "shell-local" {
+    inline = ["touch campanules.txt"]
+  }
+}
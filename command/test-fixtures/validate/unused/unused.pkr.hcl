@@ -0,0 +1,48 @@
+variable "content" {
+  type    = string
+  default = "chocolate"
+}
+
+variable "names" {
+  type    = list(string)
+  default = ["a", "b"]
+}
+
+variable "suffix" {
+  type    = string
+  default = "txt"
+
+  validation {
+    condition     = length(var.suffix) > 0
+    error_message = "The suffix must not be empty."
+  }
+}
+
+variable "unused" {
+  type    = string
+  default = "nothing"
+}
+
+variable "passed" {
+  type    = string
+  default = ""
+}
+
+locals {
+  target = "${local.prefix}.${var["suffix"]}"
+  prefix = "chocolate"
+  unused = "nothing"
+}
+
+source "file" "chocolate" {
+  content = var.content
+  target  = local.target
+}
+
+build {
+  sources = ["source.file.chocolate"]
+
+  provisioner "shell-local" {
+    inline = [for name in var.names : "echo ${name}"]
+  }
+}
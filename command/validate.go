@@ -128,6 +128,8 @@ Options:
   -var 'key=value'              Variable for templates, can be used multiple times.
   -var-file=path                JSON or HCL2 file containing user variables, can be used multiple times.
   -no-warn-undeclared-var       Disable warnings for user variable files containing undeclared variables.
+  -unused                       Warn about variables and locals that are declared but never referenced (HCL2 only).
+  -strict                       Report unused variables and locals as errors, implies -unused.
   -evaluate-datasources         Evaluate data sources during validation (HCL2 only, may incur costs); Defaults to false. 
   -ignore-prerelease-plugins    Disable the loading of prerelease plugin binaries (x.y.z-dev).
   -use-sequential-evaluation    Fallback to using a sequential approach for local/datasource evaluation.
@@ -152,5 +154,7 @@ func (*ValidateCommand) AutocompleteFlags() complete.Flags {
 		"-var":              complete.PredictNothing,
 		"-machine-readable": complete.PredictNothing,
 		"-var-file":         complete.PredictNothing,
+		"-unused":           complete.PredictNothing,
+		"-strict":           complete.PredictNothing,
 	}
 }
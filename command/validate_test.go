@@ -6,6 +6,7 @@ package command
 import (
 	"fmt"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -349,6 +350,65 @@ func TestValidateCommand_VarFilesDisableWarnOnUndeclared(t *testing.T) {
 	}
 }
 
+func TestValidateCommand_Unused(t *testing.T) {
+	tt := []struct {
+		name      string
+		extraArgs []string
+		exitCode  int
+		expected  []string
+	}{
+		{name: "no check by default",
+			exitCode: 0,
+		},
+		{name: "unused warns",
+			extraArgs: []string{"-unused", "-var", "passed=value"},
+			exitCode:  0,
+			expected: []string{
+				`Warning: Unused -var value`,
+				`A value is set with -var for the variable "passed", but the variable is never`,
+				`Warning: Unused variable`,
+				`The variable "unused" is declared but never referenced.`,
+				`Warning: Unused local variable`,
+				`The local variable "unused" is declared but never referenced.`,
+			},
+		},
+		{name: "strict fails",
+			extraArgs: []string{"-strict"},
+			exitCode:  1,
+			expected: []string{
+				`Error: Unused variable`,
+				`The variable "passed" is declared but never referenced.`,
+				`The variable "unused" is declared but never referenced.`,
+				`Error: Unused local variable`,
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &ValidateCommand{
+				Meta: TestMetaFile(t),
+			}
+			args := append(tc.extraArgs, testFixture("validate", "unused"))
+			if code := c.Run(args); code != tc.exitCode {
+				fatalCommand(t, c.Meta)
+			}
+
+			stdout, stderr := GetStdoutAndErrFromTestMeta(t, c.Meta)
+			out := stdout + stderr
+			for _, expected := range tc.expected {
+				if !strings.Contains(out, expected) {
+					t.Errorf("output should contain %q:\n%s", expected, out)
+				}
+			}
+			for _, used := range []string{`"content"`, `"names"`, `"suffix"`, `"target"`, `"prefix"`} {
+				if strings.Contains(out, used) {
+					t.Errorf("%s is used, it should not be reported:\n%s", used, out)
+				}
+			}
+		})
+	}
+}
+
 func TestValidateCommand_ShowLineNumForMissing(t *testing.T) {
 	tt := []struct {
 		path      string
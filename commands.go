@@ -47,6 +47,12 @@ func init() {
 			}, nil
 		},
 
+		"graph": func() (cli.Command, error) {
+			return &command.GraphCommand{
+				Meta: *CommandMeta,
+			}, nil
+		},
+
 		"hcl2_upgrade": func() (cli.Command, error) {
 			return &command.HCL2UpgradeCommand{
 				Meta: *CommandMeta,
@@ -65,6 +71,12 @@ func init() {
 			}, nil
 		},
 
+		"lsp": func() (cli.Command, error) {
+			return &command.LspCommand{
+				Meta: *CommandMeta,
+			}, nil
+		},
+
 		"plugins": func() (cli.Command, error) {
 			return &command.PluginsCommand{
 				Meta: *CommandMeta,
@@ -4,6 +4,7 @@
 package hcl2template
 
 import (
+	"regexp"
 	"testing"
 	"time"
 
@@ -336,6 +337,10 @@ var ctyTypeComparer = cmp.Comparer(func(x, y cty.Type) bool {
 	return x.Equals(y)
 })
 
+var regexpComparer = cmp.Comparer(func(x, y *regexp.Regexp) bool {
+	return x.String() == y.String()
+})
+
 var versionComparer = cmp.Comparer(func(x, y *version.Version) bool {
 	return x.Equal(y)
 })
@@ -347,12 +352,19 @@ var versionConstraintComparer = cmp.Comparer(func(x, y *version.Constraint) bool
 var cmpOpts = []cmp.Option{
 	ctyValueComparer,
 	ctyTypeComparer,
+	regexpComparer,
 	versionComparer,
 	versionConstraintComparer,
 	cmpopts.IgnoreUnexported(
 		PackerConfig{},
 		Variable{},
 		SourceBlock{},
+		SourceUseBlock{},
+		BuildBlock{},
+		CommunicatorBlock{},
+		ModuleBlock{},
+		CheckBlock{},
+		HCL2Check{},
 		DatasourceBlock{},
 		ProvisionerBlock{},
 		PostProcessorBlock{},
@@ -368,6 +380,9 @@ var cmpOpts = []cmp.Option{
 		"Cwd",     // Cwd will change for every os type
 		"HCPVars", // HCPVars will not be filled-in during parsing
 	),
+	cmpopts.IgnoreFields(ModuleBlock{},
+		"Config", // checked through the builds of the module
+	),
 	cmpopts.IgnoreFields(VariableAssignment{},
 		"Expr", // its an interface
 	),
@@ -0,0 +1,156 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package hcl2template
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/packer/packer"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// completionDepth is the number of attribute levels, after the variable name,
+// offered for completion in the console. Enough for data.<type>.<name>.<attr>.
+const completionDepth = 3
+
+// buildConsole evaluates the expressions of the console like the provisioners
+// and post-processors of a build see them.
+type buildConsole struct {
+	cfg       *PackerConfig
+	variables map[string]cty.Value
+}
+
+var (
+	_ packer.Evaluator        = new(buildConsole)
+	_ packer.ConsoleCompleter = new(buildConsole)
+)
+
+func (c *buildConsole) EvaluateExpression(line string) (out string, exit bool, diags hcl.Diagnostics) {
+	if line == "variables" {
+		return c.cfg.printVariables() + c.printBuildVariables(), false, nil
+	}
+	return c.cfg.evaluateExpression(line, BuildContext, c.variables)
+}
+
+func (c *buildConsole) CompletionCandidates() []string {
+	return completionCandidates(c.cfg.EvalContext(BuildContext, c.variables))
+}
+
+// printBuildVariables prints the source and build variables of the build.
+func (c *buildConsole) printBuildVariables() string {
+	out := &strings.Builder{}
+	for _, accessor := range []string{sourcesAccessor, buildAccessor} {
+		fmt.Fprintf(out, "\n> %s:\n\n", accessor)
+		val := c.variables[accessor]
+		attrs := make([]string, 0, len(val.Type().AttributeTypes()))
+		for attr := range val.Type().AttributeTypes() {
+			attrs = append(attrs, attr)
+		}
+		sort.Strings(attrs)
+		for _, attr := range attrs {
+			fmt.Fprintf(out, "%s.%s: %q\n", accessor, attr, PrintableCtyValue(val.GetAttr(attr)))
+		}
+	}
+	return out.String()
+}
+
+// BuildEvaluator returns an evaluator for the console in which expressions are
+// evaluated in the context of the build named name, with the `source` and
+// `build` variables its provisioners get. Since no builder is started, the
+// `build` variables are placeholders.
+func (cfg *PackerConfig) BuildEvaluator(name string) (packer.Evaluator, hcl.Diagnostics) {
+	var names []string
+	if console := cfg.findBuildConsole(name, &names); console != nil {
+		return console, nil
+	}
+	return nil, hcl.Diagnostics{&hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  fmt.Sprintf("Unknown build %q", name),
+		Detail:   fmt.Sprintf("Possible build names: %v.", names),
+	}}
+}
+
+// findBuildConsole looks for the build named name in the config and in its
+// modules; the names of the builds that don't match are added to names.
+func (cfg *PackerConfig) findBuildConsole(name string, names *[]string) *buildConsole {
+	for _, build := range cfg.Builds {
+		for _, srcUsage := range build.Sources {
+			pcb := &packer.CoreBuild{
+				BuildName: cfg.buildName(build),
+				Type:      srcUsage.String(),
+			}
+			if pcb.Name() != name {
+				*names = append(*names, pcb.Name())
+				continue
+			}
+
+			buildValues := map[string]cty.Value{
+				"name": cty.StringVal(build.Name),
+			}
+			for k, v := range packer.BasicPlaceholderData() {
+				buildValues[k] = cty.StringVal(v)
+			}
+			variables := srcUsage.instanceValues()
+			variables[sourcesAccessor] = cty.ObjectVal(srcUsage.ctyValues())
+			variables[buildAccessor] = cty.ObjectVal(buildValues)
+			return &buildConsole{cfg: cfg, variables: variables}
+		}
+	}
+
+	moduleNames := make([]string, 0, len(cfg.Modules))
+	for moduleName := range cfg.Modules {
+		moduleNames = append(moduleNames, moduleName)
+	}
+	sort.Strings(moduleNames)
+	for _, moduleName := range moduleNames {
+		module := cfg.Modules[moduleName]
+		if module.Config == nil {
+			continue
+		}
+		if console := module.Config.findBuildConsole(name, names); console != nil {
+			return console
+		}
+	}
+	return nil
+}
+
+// CompletionCandidates returns the variables, with their attributes, and the
+// functions that can be used in the console.
+func (cfg *PackerConfig) CompletionCandidates() []string {
+	return completionCandidates(cfg.EvalContext(NilContext, nil))
+}
+
+// completionCandidates returns the functions, suffixed with an opening
+// parenthesis, and the variables of ectx along with the paths to their
+// attributes.
+func completionCandidates(ectx *hcl.EvalContext) []string {
+	var res []string
+	for name := range ectx.Functions {
+		res = append(res, name+"(")
+	}
+	for name, val := range ectx.Variables {
+		// Only the names of the attributes are used, so the values can be
+		// unmarked.
+		val, _ = val.UnmarkDeep()
+		res = appendAttributePaths(res, name, val, completionDepth)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// appendAttributePaths appends path, and the paths to the attributes of val,
+// up to depth levels, to res.
+func appendAttributePaths(res []string, path string, val cty.Value, depth int) []string {
+	res = append(res, path)
+	if depth == 0 || !val.IsKnown() || val.IsNull() || !val.Type().IsObjectType() {
+		return res
+	}
+	for attr := range val.Type().AttributeTypes() {
+		res = appendAttributePaths(res, path+"."+attr, val.GetAttr(attr), depth-1)
+	}
+	return res
+}
@@ -0,0 +1,55 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package hcl2template
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/packer/packer"
+)
+
+func TestPackerConfig_BuildEvaluator(t *testing.T) {
+	cfg, diags := getBasicParser().Parse("testdata/console", nil, nil)
+	if diags.HasErrors() {
+		t.Fatalf("Parse: %s", diags)
+	}
+	if diags := cfg.Initialize(packer.InitializeOptions{}); diags.HasErrors() {
+		t.Fatalf("Initialize: %s", diags)
+	}
+
+	if _, diags := cfg.BuildEvaluator("app.null.nope"); !diags.HasErrors() ||
+		!strings.Contains(diags[0].Detail, `app.null.a["a"]`) {
+		t.Fatalf("expected an unknown build error, got %s", diags)
+	}
+
+	evaluator, diags := cfg.BuildEvaluator(`app.null.b["b"]`)
+	if diags.HasErrors() {
+		t.Fatalf("BuildEvaluator: %s", diags)
+	}
+	for expr, want := range map[string]string{
+		"source.name":         "b",
+		"source.type":         "null",
+		"each.value":          "b",
+		"build.name":          "app",
+		"build.ID":            packer.BasicPlaceholderData()["ID"],
+		"upper(var.greeting)": "HELLO",
+	} {
+		got, _, diags := evaluator.EvaluateExpression(expr)
+		if diags.HasErrors() {
+			t.Fatalf("EvaluateExpression(%q): %s", expr, diags)
+		}
+		if got != want {
+			t.Errorf("EvaluateExpression(%q) = %q, want %q", expr, got, want)
+		}
+	}
+
+	got := evaluator.(packer.ConsoleCompleter).CompletionCandidates()
+	for _, want := range []string{"build.ID", "each.value", "local.names", "source.name", "upper(", "var.greeting"} {
+		if i := sort.SearchStrings(got, want); i == len(got) || got[i] != want {
+			t.Errorf("%q is not a completion candidate", want)
+		}
+	}
+}
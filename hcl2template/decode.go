@@ -17,5 +17,8 @@ type Decodable interface {
 }
 
 func decodeHCL2Spec(body hcl.Body, ectx *hcl.EvalContext, dec Decodable) (cty.Value, hcl.Diagnostics) {
-	return hcldec.Decode(body, dec.ConfigSpec(), ectx)
+	val, diags := hcldec.Decode(body, dec.ConfigSpec(), ectx)
+	// Plugins know nothing about marks: the sensitive parts of the config are
+	// instead filtered out of the logs.
+	return unmarkSensitive(val), diags
 }
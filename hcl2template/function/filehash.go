@@ -0,0 +1,144 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package function
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-cty-funcs/filesystem"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// MakeFileSha256Func constructs a function that hashes the contents of the
+// file at a path, relative to baseDir, with SHA256.
+func MakeFileSha256Func(baseDir string) function.Function {
+	return makeFileHashFunc(baseDir, sha256.New)
+}
+
+// MakeFileSha512Func constructs a function that hashes the contents of the
+// file at a path, relative to baseDir, with SHA512.
+func MakeFileSha512Func(baseDir string) function.Function {
+	return makeFileHashFunc(baseDir, sha512.New)
+}
+
+// MakeFileMd5Func constructs a function that hashes the contents of the file
+// at a path, relative to baseDir, with MD5.
+func MakeFileMd5Func(baseDir string) function.Function {
+	return makeFileHashFunc(baseDir, md5.New)
+}
+
+// makeFileHashFunc constructs a function returning the hex encoded hash of a
+// file. The file is streamed through the hash, large files are never loaded
+// in memory.
+func makeFileHashFunc(baseDir string, hf func() hash.Hash) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{
+				Name:        "path",
+				Description: "The path of the file to hash.",
+				Type:        cty.String,
+			},
+		},
+		Type:         function.StaticReturnType(cty.String),
+		RefineResult: refineNotNull,
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			path := args[0].AsString()
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(baseDir, path)
+			}
+
+			sum, err := hashFile(path, hf)
+			if err != nil {
+				return cty.UnknownVal(cty.String), err
+			}
+			return cty.StringVal(hex.EncodeToString(sum)), nil
+		},
+	})
+}
+
+func hashFile(path string, hf func() hash.Hash) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file %q: %s", path, err)
+	}
+	defer f.Close()
+
+	h := hf()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, fmt.Errorf("failed to read file %q: %s", path, err)
+	}
+	return h.Sum(nil), nil
+}
+
+// MakeDirHashFunc constructs a function that hashes the files of a directory,
+// relative to baseDir, matching a glob pattern like fileset.
+//
+// The hash is the hex encoded SHA256 of the sorted lines
+// "<SHA256 of the file>  <path of the file relative to the directory>", so it
+// only changes when a matching file is added, removed, renamed or modified.
+func MakeDirHashFunc(baseDir string) function.Function {
+	fileSet := filesystem.MakeFileSetFunc(baseDir)
+
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{
+				Name:        "path",
+				Description: "The directory to hash.",
+				Type:        cty.String,
+			},
+			{
+				Name:        "pattern",
+				Description: "The glob pattern of the files to hash, like `**/*.sh`.",
+				Type:        cty.String,
+			},
+		},
+		Type:         function.StaticReturnType(cty.String),
+		RefineResult: refineNotNull,
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			dir := args[0].AsString()
+			if !filepath.IsAbs(dir) {
+				dir = filepath.Join(baseDir, dir)
+			}
+
+			matches, err := fileSet.Call(args)
+			if err != nil {
+				return cty.UnknownVal(cty.String), err
+			}
+			if matches.LengthInt() == 0 {
+				return cty.UnknownVal(cty.String), fmt.Errorf("no file of %q matches %q", dir, args[1].AsString())
+			}
+
+			files := make([]string, 0, matches.LengthInt())
+			for it := matches.ElementIterator(); it.Next(); {
+				_, file := it.Element()
+				files = append(files, file.AsString())
+			}
+			sort.Strings(files)
+
+			h := sha256.New()
+			for _, file := range files {
+				if strings.Contains(file, "\n") {
+					return cty.UnknownVal(cty.String), fmt.Errorf("cannot hash file %q, its name contains a newline", file)
+				}
+				sum, err := hashFile(filepath.Join(dir, filepath.FromSlash(file)), sha256.New)
+				if err != nil {
+					return cty.UnknownVal(cty.String), err
+				}
+				fmt.Fprintf(h, "%x  %s\n", sum, file)
+			}
+			return cty.StringVal(hex.EncodeToString(h.Sum(nil))), nil
+		},
+	})
+}
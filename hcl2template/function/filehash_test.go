@@ -0,0 +1,145 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package function
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+func TestFileHash(t *testing.T) {
+	tests := []struct {
+		Name string
+		Func function.Function
+		Path string
+		Want cty.Value
+		Err  string
+	}{
+		{
+			"filesha256",
+			MakeFileSha256Func("."),
+			"testdata/hello.txt",
+			cty.StringVal("a591a6d40bf420404a011733cfb7b190d62c65bf0bcda32b57b277d9ad9f146e"),
+			``,
+		},
+		{
+			"filesha512",
+			MakeFileSha512Func("."),
+			"testdata/hello.txt",
+			cty.StringVal("2c74fd17edafd80e8447b0d46741ee243b7eb74dd2149a0ab1b9246fb30382f27e853d8585719e0e67cbda0daa8f51671064615d645ae27acb15bfb1447f459b"),
+			``,
+		},
+		{
+			"filemd5",
+			MakeFileMd5Func("."),
+			"testdata/hello.txt",
+			cty.StringVal("b10a8db164e0754105b7a99be72e3fe5"),
+			``,
+		},
+		{
+			"relative to the base directory",
+			MakeFileSha256Func("testdata"),
+			"hello.txt",
+			cty.StringVal("a591a6d40bf420404a011733cfb7b190d62c65bf0bcda32b57b277d9ad9f146e"),
+			``,
+		},
+		{
+			"missing file",
+			MakeFileSha256Func("."),
+			"testdata/nope.txt",
+			cty.NilVal,
+			`failed to open file "testdata/nope.txt"`,
+		},
+		{
+			"directory",
+			MakeFileSha256Func("."),
+			"testdata",
+			cty.NilVal,
+			`failed to read file "testdata"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			got, err := test.Func.Call([]cty.Value{cty.StringVal(test.Path)})
+
+			if test.Err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.Err) {
+					t.Fatalf("wrong error\ngot:  %v\nwant: %s", err, test.Err)
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !got.RawEquals(test.Want) {
+				t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, test.Want)
+			}
+		})
+	}
+}
+
+func TestDirHash(t *testing.T) {
+	dir := t.TempDir()
+	write := func(path, content string) {
+		path = filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("scripts/a.sh", "a\n")
+	write("scripts/sub/b.sh", "b\n")
+	write("scripts/readme.md", "x")
+
+	dirHash := func(path, pattern string) (string, error) {
+		got, err := MakeDirHashFunc(dir).Call([]cty.Value{cty.StringVal(path), cty.StringVal(pattern)})
+		if err != nil {
+			return "", err
+		}
+		return got.AsString(), nil
+	}
+
+	// sha256 of "<sha256 of a.sh>  a.sh\n<sha256 of sub/b.sh>  sub/b.sh\n"
+	const want = "c6a6ec57e7572c948541e320fd0197733cfcff2993d5411097c069c0f6b65792"
+	got, err := dirHash("scripts", "**/*.sh")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got != want {
+		t.Fatalf("wrong hash\ngot:  %s\nwant: %s", got, want)
+	}
+
+	// Files not matching the pattern don't change the hash.
+	write("scripts/readme.md", "y")
+	if got, _ := dirHash("scripts", "**/*.sh"); got != want {
+		t.Errorf("hash changed after editing a file not matching the pattern: %s", got)
+	}
+
+	// Absolute paths are not relative to the base directory.
+	if got, _ := dirHash(filepath.Join(dir, "scripts"), "**/*.sh"); got != want {
+		t.Errorf("wrong hash for an absolute path: %s", got)
+	}
+
+	// Matching files do.
+	write("scripts/sub/b.sh", "b2\n")
+	changed, err := dirHash("scripts", "**/*.sh")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if changed == want {
+		t.Errorf("hash didn't change after editing a matching file")
+	}
+
+	if _, err := dirHash("scripts", "*.ps1"); err == nil || !strings.Contains(err.Error(), `matches "*.ps1"`) {
+		t.Errorf("expected an error when no file matches, got %v", err)
+	}
+}
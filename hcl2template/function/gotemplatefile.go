@@ -0,0 +1,119 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package function
+
+import (
+	"math/big"
+
+	"github.com/hashicorp/go-cty-funcs/filesystem"
+	"github.com/hashicorp/packer/internal/gotemplate"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// MakeGoTemplateFileFunc constructs a function that takes a file path and
+// an object of named values and renders the referenced file as a Go
+// text/template, for files whose own syntax clashes with HCL templates, like
+// shell scripts using ${}.
+//
+// Templates access the values as fields of the dot, like {{ .name }}, and can
+// only use the helpers of gotemplate.Funcs, none of which reads files or the
+// environment.
+func MakeGoTemplateFileFunc(baseDir string) function.Function {
+	return function.New(&function.Spec{
+		Description: "Renders a file as a Go text/template with the given variables.",
+		Params: []function.Parameter{
+			{
+				Name:        "path",
+				Description: "The path of the template file.",
+				Type:        cty.String,
+			},
+			{
+				Name:        "vars",
+				Description: "The variables available in the template, like {{ .name }}.",
+				Type:        cty.DynamicPseudoType,
+			},
+		},
+		Type:         function.StaticReturnType(cty.String),
+		RefineResult: refineNotNull,
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			vars, err := goTemplateVars(args[1], 1)
+			if err != nil {
+				return cty.UnknownVal(retType), err
+			}
+			if vars == nil {
+				return cty.UnknownVal(retType), nil
+			}
+
+			// We re-use File here to ensure the same filename interpretation
+			// as templatefile, along with its other safety checks.
+			path := args[0].AsString()
+			src, err := filesystem.File(baseDir, cty.StringVal(path))
+			if err != nil {
+				return cty.UnknownVal(retType), err
+			}
+
+			out, err := gotemplate.Render(path, src.AsString(), vars)
+			if err != nil {
+				return cty.UnknownVal(retType), err
+			}
+			return cty.StringVal(out), nil
+		},
+	})
+}
+
+// goTemplateVars converts val, the vars argument at index argIdx, to the
+// data of a Go template. It returns nil vars when they are not known yet.
+func goTemplateVars(val cty.Value, argIdx int) (map[string]interface{}, error) {
+	if ty := val.Type(); !(ty.IsMapType() || ty.IsObjectType()) {
+		return nil, function.NewArgErrorf(argIdx, "invalid vars value: must be a map") // or an object, like templatefile
+	}
+	if !val.IsWhollyKnown() {
+		return nil, nil
+	}
+	vars, _ := ctyToGo(val).(map[string]interface{})
+	if vars == nil {
+		vars = map[string]interface{}{}
+	}
+	return vars, nil
+}
+
+// ctyToGo converts a wholly known value to the Go values a template works
+// with: maps, slices, strings, bools, and int64 or float64 numbers.
+func ctyToGo(val cty.Value) interface{} {
+	if val.IsNull() {
+		return nil
+	}
+
+	ty := val.Type()
+	switch {
+	case ty == cty.String:
+		return val.AsString()
+	case ty == cty.Bool:
+		return val.True()
+	case ty == cty.Number:
+		bf := val.AsBigFloat()
+		if i, acc := bf.Int64(); acc == big.Exact {
+			return i
+		}
+		f, _ := bf.Float64()
+		return f
+	case ty.IsObjectType() || ty.IsMapType():
+		m := make(map[string]interface{}, val.LengthInt())
+		for it := val.ElementIterator(); it.Next(); {
+			k, elem := it.Element()
+			m[k.AsString()] = ctyToGo(elem)
+		}
+		return m
+	case ty.IsListType() || ty.IsTupleType() || ty.IsSetType():
+		s := make([]interface{}, 0, val.LengthInt())
+		for it := val.ElementIterator(); it.Next(); {
+			_, elem := it.Element()
+			s = append(s, ctyToGo(elem))
+		}
+		return s
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package function
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestGoTemplateFile(t *testing.T) {
+	tests := []struct {
+		Name string
+		Path cty.Value
+		Vars cty.Value
+		Want cty.Value
+		Err  string
+	}{
+		{
+			"shell syntax is left as is",
+			cty.StringVal("testdata/gotemplate.tmpl"),
+			cty.ObjectVal(map[string]cty.Value{
+				"hostname": cty.StringVal("Builder"),
+				"users":    cty.TupleVal([]cty.Value{cty.StringVal("ops"), cty.StringVal("ci")}),
+			}),
+			cty.StringVal(`#cloud-config
+hostname: builder
+users:
+  - name: ops
+  - name: ci
+runcmd:
+  - echo "${HOME}" > /tmp/home
+`),
+			``,
+		},
+		{
+			"missing variable",
+			cty.StringVal("testdata/gotemplate.tmpl"),
+			cty.ObjectVal(map[string]cty.Value{
+				"users": cty.ListValEmpty(cty.String),
+			}),
+			cty.NilVal,
+			`map has no entry for key "hostname"`,
+		},
+		{
+			"invalid vars",
+			cty.StringVal("testdata/gotemplate.tmpl"),
+			cty.StringVal("builder"),
+			cty.NilVal,
+			`invalid vars value: must be a map`,
+		},
+		{
+			"missing file",
+			cty.StringVal("testdata/missing"),
+			cty.EmptyObjectVal,
+			cty.NilVal,
+			`no file exists at ` + filepath.Clean("testdata/missing"),
+		},
+		{
+			"unknown vars",
+			cty.StringVal("testdata/gotemplate.tmpl"),
+			cty.ObjectVal(map[string]cty.Value{
+				"hostname": cty.UnknownVal(cty.String),
+			}),
+			cty.UnknownVal(cty.String).RefineNotNull(),
+			``,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			got, err := MakeGoTemplateFileFunc(".").Call([]cty.Value{test.Path, test.Vars})
+
+			if test.Err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.Err) {
+					t.Fatalf("wrong error\ngot:  %v\nwant: %s", err, test.Err)
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !got.RawEquals(test.Want) {
+				t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, test.Want)
+			}
+		})
+	}
+}
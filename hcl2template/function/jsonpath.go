@@ -0,0 +1,812 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package function
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// JSONPathFunc constructs a function that queries a value, like one decoded
+// with jsondecode or yamldecode, with a JSONPath expression.
+//
+// Paths made of names and indexes only, like $.spec.items[0].url, return the
+// value they point to. Other paths, with wildcards, slices, filters or
+// recursive descents, return a tuple of all the values they match. A path
+// matching nothing is an error, telling which part of it didn't match.
+var JSONPathFunc = function.New(&function.Spec{
+	Description: "Queries a value, like one decoded from JSON or YAML, with a JSONPath expression like `$.items[?(@.arch == 'amd64')].url`.",
+	Params: []function.Parameter{
+		{
+			Name:        "value",
+			Description: "The value to query.",
+			Type:        cty.DynamicPseudoType,
+			AllowNull:   true,
+		},
+		{
+			Name:        "path",
+			Description: "The JSONPath expression, starting with `$`.",
+			Type:        cty.String,
+		},
+	},
+	Type:         function.StaticReturnType(cty.DynamicPseudoType),
+	RefineResult: refineNotNull,
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		path, err := parseJSONPath(args[1].AsString())
+		if err != nil {
+			return cty.DynamicVal, function.NewArgError(1, err)
+		}
+		if !args[0].IsWhollyKnown() {
+			return cty.DynamicVal, nil
+		}
+
+		nodes, err := path.query(args[0])
+		if err != nil {
+			return cty.DynamicVal, function.NewArgError(1, err)
+		}
+		if path.definite() {
+			return nodes[0].val, nil
+		}
+		vals := make([]cty.Value, 0, len(nodes))
+		for _, node := range nodes {
+			vals = append(vals, node.val)
+		}
+		return cty.TupleVal(vals), nil
+	},
+})
+
+// jsonPath is a parsed JSONPath expression.
+type jsonPath struct {
+	segments []*jsonPathSegment
+}
+
+// jsonPathSegment is a step of a path, like `.name`, `[0, 2]` or `..*`.
+type jsonPathSegment struct {
+	// src is the segment as written, for error messages.
+	src        string
+	descendant bool
+	selectors  []*jsonPathSelector
+}
+
+type jsonPathSelectorKind int
+
+const (
+	jsonPathName jsonPathSelectorKind = iota
+	jsonPathIndex
+	jsonPathWildcard
+	jsonPathSlice
+	jsonPathFilter
+)
+
+type jsonPathSelector struct {
+	kind   jsonPathSelectorKind
+	name   string
+	index  int
+	slice  [3]*int
+	filter jsonPathExpr
+}
+
+// jsonPathNode is a value matched by a path, with its location.
+type jsonPathNode struct {
+	val  cty.Value
+	path string
+}
+
+// definite tells whether the path always matches at most one value.
+func (p *jsonPath) definite() bool {
+	for _, seg := range p.segments {
+		if seg.descendant || len(seg.selectors) != 1 {
+			return false
+		}
+		if kind := seg.selectors[0].kind; kind != jsonPathName && kind != jsonPathIndex {
+			return false
+		}
+	}
+	return true
+}
+
+// query returns the values of root matched by the path, or an error telling
+// which segment of the path matched nothing.
+func (p *jsonPath) query(root cty.Value) ([]jsonPathNode, error) {
+	nodes := []jsonPathNode{{val: root, path: "$"}}
+	for _, seg := range p.segments {
+		next := seg.apply(root, nodes)
+		if len(next) == 0 {
+			return nil, seg.noMatch(nodes)
+		}
+		nodes = next
+	}
+	return nodes, nil
+}
+
+// matches returns the values matched by the path from current, as a relative
+// path in a filter.
+func (p *jsonPath) matches(root cty.Value, current jsonPathNode) []jsonPathNode {
+	nodes := []jsonPathNode{current}
+	for _, seg := range p.segments {
+		nodes = seg.apply(root, nodes)
+	}
+	return nodes
+}
+
+func (s *jsonPathSegment) apply(root cty.Value, nodes []jsonPathNode) []jsonPathNode {
+	var matched []jsonPathNode
+	for _, node := range nodes {
+		targets := []jsonPathNode{node}
+		if s.descendant {
+			targets = descendants(node)
+		}
+		for _, target := range targets {
+			for _, sel := range s.selectors {
+				matched = append(matched, sel.apply(root, target)...)
+			}
+		}
+	}
+	return matched
+}
+
+// noMatch explains why the segment matched nothing in nodes.
+func (s *jsonPathSegment) noMatch(nodes []jsonPathNode) error {
+	if len(nodes) != 1 || s.descendant || len(s.selectors) != 1 {
+		return fmt.Errorf("%s matches nothing in %s", s.src, describeNodes(nodes))
+	}
+
+	node := nodes[0]
+	ty := node.val.Type()
+	sel := s.selectors[0]
+	switch {
+	case node.val.IsNull():
+		return fmt.Errorf("%s is null, %s matches nothing in it", node.path, s.src)
+	case sel.kind == jsonPathName && ty.IsObjectType():
+		names := make([]string, 0, len(ty.AttributeTypes()))
+		for name := range ty.AttributeTypes() {
+			names = append(names, strconv.Quote(name))
+		}
+		sort.Strings(names)
+		if len(names) == 0 {
+			return fmt.Errorf("%s has no attribute %q, it is an empty object", node.path, sel.name)
+		}
+		return fmt.Errorf("%s has no attribute %q, its attributes are %s", node.path, sel.name, strings.Join(names, ", "))
+	case sel.kind == jsonPathName && ty.IsMapType():
+		return fmt.Errorf("%s has no key %q", node.path, sel.name)
+	case sel.kind == jsonPathName:
+		return fmt.Errorf("%s is %s, not an object: %s matches nothing in it", node.path, ty.FriendlyName(), s.src)
+	case sel.kind == jsonPathIndex && (ty.IsListType() || ty.IsTupleType()):
+		return fmt.Errorf("%s has %d elements, there is no element at index %d", node.path, node.val.LengthInt(), sel.index)
+	case sel.kind == jsonPathIndex || sel.kind == jsonPathSlice:
+		return fmt.Errorf("%s is %s, not a list: %s matches nothing in it", node.path, ty.FriendlyName(), s.src)
+	case sel.kind == jsonPathFilter:
+		return fmt.Errorf("no element of %s matches %s", node.path, s.src)
+	default:
+		return fmt.Errorf("%s matches nothing in %s", s.src, node.path)
+	}
+}
+
+func describeNodes(nodes []jsonPathNode) string {
+	if len(nodes) == 1 {
+		return nodes[0].path
+	}
+	const max = 3
+	paths := make([]string, 0, max)
+	for i := 0; i < len(nodes) && i < max; i++ {
+		paths = append(paths, nodes[i].path)
+	}
+	if len(nodes) > max {
+		paths = append(paths, fmt.Sprintf("and %d more", len(nodes)-max))
+	}
+	return fmt.Sprintf("any of the %d values matched before it (%s)", len(nodes), strings.Join(paths, ", "))
+}
+
+func (sel *jsonPathSelector) apply(root cty.Value, node jsonPathNode) []jsonPathNode {
+	val := node.val
+	if val.IsNull() {
+		return nil
+	}
+	ty := val.Type()
+
+	switch sel.kind {
+	case jsonPathName:
+		switch {
+		case ty.IsObjectType() && ty.HasAttribute(sel.name):
+			return []jsonPathNode{{val: val.GetAttr(sel.name), path: node.path + jsonPathNameSegment(sel.name)}}
+		case ty.IsMapType():
+			key := cty.StringVal(sel.name)
+			if val.HasIndex(key).True() {
+				return []jsonPathNode{{val: val.Index(key), path: node.path + jsonPathNameSegment(sel.name)}}
+			}
+		}
+	case jsonPathIndex:
+		if !ty.IsListType() && !ty.IsTupleType() {
+			return nil
+		}
+		i, n := sel.index, val.LengthInt()
+		if i < 0 {
+			i += n
+		}
+		if i >= 0 && i < n {
+			return []jsonPathNode{{val: val.Index(cty.NumberIntVal(int64(i))), path: fmt.Sprintf("%s[%d]", node.path, i)}}
+		}
+	case jsonPathWildcard:
+		return children(node)
+	case jsonPathSlice:
+		if !ty.IsListType() && !ty.IsTupleType() {
+			return nil
+		}
+		elems := children(node)
+		var matched []jsonPathNode
+		for _, i := range sliceIndexes(sel.slice, len(elems)) {
+			matched = append(matched, elems[i])
+		}
+		return matched
+	case jsonPathFilter:
+		var matched []jsonPathNode
+		for _, child := range children(node) {
+			if sel.filter.test(root, child) {
+				matched = append(matched, child)
+			}
+		}
+		return matched
+	}
+	return nil
+}
+
+// sliceIndexes returns the indexes selected by a [start:end:step] slice of a
+// list of n elements.
+func sliceIndexes(slice [3]*int, n int) []int {
+	step := 1
+	if slice[2] != nil {
+		step = *slice[2]
+	}
+	if step == 0 {
+		return nil
+	}
+
+	bound := func(i *int, def int) int {
+		if i == nil {
+			return def
+		}
+		if *i < 0 {
+			return *i + n
+		}
+		return *i
+	}
+	clamp := func(i, min, max int) int {
+		if i < min {
+			return min
+		}
+		if i > max {
+			return max
+		}
+		return i
+	}
+
+	var indexes []int
+	if step > 0 {
+		start := clamp(bound(slice[0], 0), 0, n)
+		end := clamp(bound(slice[1], n), 0, n)
+		for i := start; i < end; i += step {
+			indexes = append(indexes, i)
+		}
+		return indexes
+	}
+	start := clamp(bound(slice[0], n-1), -1, n-1)
+	end := clamp(bound(slice[1], -n-1), -1, n-1)
+	for i := start; i > end; i += step {
+		indexes = append(indexes, i)
+	}
+	return indexes
+}
+
+// children returns the elements of a list, tuple or set, or the attributes
+// of an object or map.
+func children(node jsonPathNode) []jsonPathNode {
+	val := node.val
+	if val.IsNull() || !val.CanIterateElements() {
+		return nil
+	}
+	ty := val.Type()
+	nodes := make([]jsonPathNode, 0, val.LengthInt())
+	for it := val.ElementIterator(); it.Next(); {
+		key, elem := it.Element()
+		path := fmt.Sprintf("%s[%d]", node.path, len(nodes))
+		if ty.IsObjectType() || ty.IsMapType() {
+			path = node.path + jsonPathNameSegment(key.AsString())
+		}
+		nodes = append(nodes, jsonPathNode{val: elem, path: path})
+	}
+	return nodes
+}
+
+// descendants returns node and all the values nested in it, depth first.
+func descendants(node jsonPathNode) []jsonPathNode {
+	nodes := []jsonPathNode{node}
+	for _, child := range children(node) {
+		nodes = append(nodes, descendants(child)...)
+	}
+	return nodes
+}
+
+func jsonPathNameSegment(name string) string {
+	if isJSONPathName(name) {
+		return "." + name
+	}
+	return "[" + quoteJSONPathString(name) + "]"
+}
+
+func quoteJSONPathString(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+func isJSONPathName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		if !isJSONPathNameRune(r) || (i == 0 && unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+func isJSONPathNameRune(r rune) bool {
+	return r == '_' || r == '-' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// jsonPathExpr is a filter expression, like `@.arch == 'amd64' && @.url`.
+type jsonPathExpr interface {
+	test(root cty.Value, current jsonPathNode) bool
+}
+
+type jsonPathOr struct{ left, right jsonPathExpr }
+
+func (e jsonPathOr) test(root cty.Value, current jsonPathNode) bool {
+	return e.left.test(root, current) || e.right.test(root, current)
+}
+
+type jsonPathAnd struct{ left, right jsonPathExpr }
+
+func (e jsonPathAnd) test(root cty.Value, current jsonPathNode) bool {
+	return e.left.test(root, current) && e.right.test(root, current)
+}
+
+type jsonPathNot struct{ expr jsonPathExpr }
+
+func (e jsonPathNot) test(root cty.Value, current jsonPathNode) bool {
+	return !e.expr.test(root, current)
+}
+
+// jsonPathExists tests whether a query matches a value.
+type jsonPathExists struct{ query *jsonPathQuery }
+
+func (e jsonPathExists) test(root cty.Value, current jsonPathNode) bool {
+	return len(e.query.matches(root, current)) > 0
+}
+
+type jsonPathComparison struct {
+	op          string
+	left, right jsonPathOperand
+}
+
+func (e jsonPathComparison) test(root cty.Value, current jsonPathNode) bool {
+	left, leftOk := e.left.value(root, current)
+	right, rightOk := e.right.value(root, current)
+
+	switch e.op {
+	case "==":
+		return jsonPathEqual(left, leftOk, right, rightOk)
+	case "!=":
+		return !jsonPathEqual(left, leftOk, right, rightOk)
+	}
+	if !leftOk || !rightOk {
+		return false
+	}
+	if jsonPathEqual(left, leftOk, right, rightOk) {
+		return e.op == "<=" || e.op == ">="
+	}
+	less, ok := jsonPathLess(left, right)
+	if !ok {
+		return false
+	}
+	switch e.op {
+	case "<", "<=":
+		return less
+	default:
+		return !less
+	}
+}
+
+// jsonPathEqual compares two operands. Missing operands are only equal to
+// other missing operands.
+func jsonPathEqual(left cty.Value, leftOk bool, right cty.Value, rightOk bool) bool {
+	switch {
+	case !leftOk || !rightOk:
+		return leftOk == rightOk
+	case left.IsNull() || right.IsNull():
+		return left.IsNull() && right.IsNull()
+	case left.Type() == cty.Number && right.Type() == cty.Number:
+		return left.Equals(right).True()
+	case !left.Type().Equals(right.Type()):
+		return false
+	default:
+		return left.Equals(right).True()
+	}
+}
+
+// jsonPathLess orders two numbers or two strings.
+func jsonPathLess(left, right cty.Value) (less bool, ok bool) {
+	switch {
+	case left.IsNull() || right.IsNull():
+		return false, false
+	case left.Type() == cty.Number && right.Type() == cty.Number:
+		return left.LessThan(right).True(), true
+	case left.Type() == cty.String && right.Type() == cty.String:
+		return left.AsString() < right.AsString(), true
+	default:
+		return false, false
+	}
+}
+
+// jsonPathOperand is a side of a comparison: a literal, or a query matching
+// a single value.
+type jsonPathOperand interface {
+	value(root cty.Value, current jsonPathNode) (cty.Value, bool)
+}
+
+type jsonPathLiteral struct{ val cty.Value }
+
+func (o jsonPathLiteral) value(cty.Value, jsonPathNode) (cty.Value, bool) {
+	return o.val, true
+}
+
+// jsonPathQuery is a path in a filter, relative to the current value with
+// `@`, or to the root one with `$`.
+type jsonPathQuery struct {
+	absolute bool
+	path     *jsonPath
+}
+
+func (q *jsonPathQuery) matches(root cty.Value, current jsonPathNode) []jsonPathNode {
+	if q.absolute {
+		current = jsonPathNode{val: root, path: "$"}
+	}
+	return q.path.matches(root, current)
+}
+
+func (q *jsonPathQuery) value(root cty.Value, current jsonPathNode) (cty.Value, bool) {
+	nodes := q.matches(root, current)
+	if len(nodes) != 1 {
+		return cty.NilVal, false
+	}
+	return nodes[0].val, true
+}
+
+// parseJSONPath parses a JSONPath expression.
+func parseJSONPath(src string) (*jsonPath, error) {
+	p := &jsonPathParser{src: strings.TrimSpace(src)}
+	if !p.consume("$") {
+		return nil, fmt.Errorf("invalid JSONPath %q: paths must start with $", src)
+	}
+	path, err := p.parseSegments()
+	if err == nil && p.pos < len(p.src) {
+		err = p.errorf("unexpected %q", p.src[p.pos:])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSONPath %q: %s", src, err)
+	}
+	return path, nil
+}
+
+type jsonPathParser struct {
+	src string
+	pos int
+}
+
+func (p *jsonPathParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("at offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *jsonPathParser) peek() byte {
+	if p.pos >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *jsonPathParser) consume(s string) bool {
+	if strings.HasPrefix(p.src[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+func (p *jsonPathParser) skipSpaces() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t' || p.src[p.pos] == '\n' || p.src[p.pos] == '\r') {
+		p.pos++
+	}
+}
+
+// parseSegments parses segments until the next character can't start one.
+func (p *jsonPathParser) parseSegments() (*jsonPath, error) {
+	path := &jsonPath{}
+	for p.peek() == '.' || p.peek() == '[' {
+		start := p.pos
+		seg := &jsonPathSegment{}
+		switch {
+		case p.consume(".."):
+			seg.descendant = true
+			if p.peek() == '[' {
+				selectors, err := p.parseBracket()
+				if err != nil {
+					return nil, err
+				}
+				seg.selectors = selectors
+				break
+			}
+			fallthrough
+		case p.consume("."):
+			if p.consume("*") {
+				seg.selectors = []*jsonPathSelector{{kind: jsonPathWildcard}}
+				break
+			}
+			name := p.parseName()
+			if name == "" {
+				return nil, p.errorf("expected a name after %q", p.src[start:p.pos])
+			}
+			seg.selectors = []*jsonPathSelector{{kind: jsonPathName, name: name}}
+		default:
+			selectors, err := p.parseBracket()
+			if err != nil {
+				return nil, err
+			}
+			seg.selectors = selectors
+		}
+		seg.src = p.src[start:p.pos]
+		path.segments = append(path.segments, seg)
+	}
+	return path, nil
+}
+
+func (p *jsonPathParser) parseName() string {
+	start := p.pos
+	for _, r := range p.src[p.pos:] {
+		if !isJSONPathNameRune(r) {
+			break
+		}
+		p.pos += len(string(r))
+	}
+	return p.src[start:p.pos]
+}
+
+// parseBracket parses comma separated selectors in brackets.
+func (p *jsonPathParser) parseBracket() ([]*jsonPathSelector, error) {
+	p.consume("[")
+	var selectors []*jsonPathSelector
+	for {
+		p.skipSpaces()
+		sel, err := p.parseSelector()
+		if err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, sel)
+		p.skipSpaces()
+		switch {
+		case p.consume(","):
+		case p.consume("]"):
+			return selectors, nil
+		default:
+			return nil, p.errorf("expected , or ] in brackets")
+		}
+	}
+}
+
+func (p *jsonPathParser) parseSelector() (*jsonPathSelector, error) {
+	switch c := p.peek(); {
+	case c == '\'' || c == '"':
+		name, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		return &jsonPathSelector{kind: jsonPathName, name: name}, nil
+	case p.consume("*"):
+		return &jsonPathSelector{kind: jsonPathWildcard}, nil
+	case p.consume("?"):
+		p.skipSpaces()
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return &jsonPathSelector{kind: jsonPathFilter, filter: expr}, nil
+	case c == '-' || c == ':' || (c >= '0' && c <= '9'):
+		return p.parseIndexOrSlice()
+	default:
+		return nil, p.errorf("expected a name, an index, a slice, * or a filter in brackets")
+	}
+}
+
+func (p *jsonPathParser) parseIndexOrSlice() (*jsonPathSelector, error) {
+	var bounds [3]*int
+	colons := 0
+	for i := 0; i < 3; i++ {
+		p.skipSpaces()
+		if c := p.peek(); c == '-' || (c >= '0' && c <= '9') {
+			n, err := p.parseInt()
+			if err != nil {
+				return nil, err
+			}
+			bounds[i] = &n
+		}
+		p.skipSpaces()
+		if i == 2 || !p.consume(":") {
+			break
+		}
+		colons++
+	}
+	if colons == 0 {
+		return &jsonPathSelector{kind: jsonPathIndex, index: *bounds[0]}, nil
+	}
+	return &jsonPathSelector{kind: jsonPathSlice, slice: bounds}, nil
+}
+
+func (p *jsonPathParser) parseInt() (int, error) {
+	start := p.pos
+	p.consume("-")
+	for c := p.peek(); c >= '0' && c <= '9'; c = p.peek() {
+		p.pos++
+	}
+	n, err := strconv.Atoi(p.src[start:p.pos])
+	if err != nil {
+		return 0, p.errorf("invalid integer %q", p.src[start:p.pos])
+	}
+	return n, nil
+}
+
+func (p *jsonPathParser) parseString() (string, error) {
+	quote := p.src[p.pos]
+	p.pos++
+	var b strings.Builder
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		p.pos++
+		switch {
+		case c == quote:
+			return b.String(), nil
+		case c == '\\' && p.pos < len(p.src):
+			switch e := p.src[p.pos]; e {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte(e)
+			}
+			p.pos++
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+func (p *jsonPathParser) parseOr() (jsonPathExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.skipSpaces(); p.consume("||"); p.skipSpaces() {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = jsonPathOr{left, right}
+	}
+	return left, nil
+}
+
+func (p *jsonPathParser) parseAnd() (jsonPathExpr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.skipSpaces(); p.consume("&&"); p.skipSpaces() {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = jsonPathAnd{left, right}
+	}
+	return left, nil
+}
+
+func (p *jsonPathParser) parseUnary() (jsonPathExpr, error) {
+	p.skipSpaces()
+	switch {
+	case p.peek() == '!' && !strings.HasPrefix(p.src[p.pos:], "!="):
+		p.pos++
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return jsonPathNot{expr}, nil
+	case p.consume("("):
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpaces()
+		if !p.consume(")") {
+			return nil, p.errorf("expected )")
+		}
+		return expr, nil
+	}
+
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if !p.consume(op) {
+			continue
+		}
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return jsonPathComparison{op: op, left: left, right: right}, nil
+	}
+	query, ok := left.(*jsonPathQuery)
+	if !ok {
+		return nil, p.errorf("expected a comparison operator")
+	}
+	return jsonPathExists{query}, nil
+}
+
+func (p *jsonPathParser) parseOperand() (jsonPathOperand, error) {
+	p.skipSpaces()
+	switch c := p.peek(); {
+	case c == '@' || c == '$':
+		p.pos++
+		path, err := p.parseSegments()
+		if err != nil {
+			return nil, err
+		}
+		return &jsonPathQuery{absolute: c == '$', path: path}, nil
+	case c == '\'' || c == '"':
+		s, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		return jsonPathLiteral{cty.StringVal(s)}, nil
+	case c == '-' || (c >= '0' && c <= '9'):
+		start := p.pos
+		p.pos++
+		for strings.IndexByte("0123456789.eE+-", p.peek()) >= 0 && p.peek() != 0 {
+			p.pos++
+		}
+		n, err := cty.ParseNumberVal(p.src[start:p.pos])
+		if err != nil {
+			return nil, p.errorf("invalid number %q", p.src[start:p.pos])
+		}
+		return jsonPathLiteral{n}, nil
+	}
+
+	word := p.parseName()
+	switch word {
+	case "true":
+		return jsonPathLiteral{cty.True}, nil
+	case "false":
+		return jsonPathLiteral{cty.False}, nil
+	case "null":
+		return jsonPathLiteral{cty.NullVal(cty.DynamicPseudoType)}, nil
+	}
+	return nil, p.errorf("expected @, $, a string, a number, true, false or null in filter")
+}
@@ -0,0 +1,207 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package function
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+func TestJSONPath(t *testing.T) {
+	doc := cty.ObjectVal(map[string]cty.Value{
+		"name": cty.StringVal("ubuntu"),
+		"items": cty.TupleVal([]cty.Value{
+			cty.ObjectVal(map[string]cty.Value{
+				"arch": cty.StringVal("amd64"),
+				"size": cty.NumberIntVal(512),
+				"url":  cty.StringVal("https://example.com/amd64.iso"),
+			}),
+			cty.ObjectVal(map[string]cty.Value{
+				"arch": cty.StringVal("arm64"),
+				"size": cty.NumberIntVal(480),
+				"url":  cty.StringVal("https://example.com/arm64.iso"),
+			}),
+			cty.ObjectVal(map[string]cty.Value{
+				"arch": cty.StringVal("s390x"),
+				"size": cty.NumberIntVal(600),
+				"url":  cty.NullVal(cty.String),
+			}),
+		}),
+		"labels": cty.MapVal(map[string]cty.Value{
+			"os":          cty.StringVal("linux"),
+			"release.tag": cty.StringVal("noble"),
+		}),
+	})
+
+	tests := []struct {
+		Name string
+		Path string
+		Want cty.Value
+		Err  string
+	}{
+		{"root", `$`, doc, ``},
+		{"attribute", `$.name`, cty.StringVal("ubuntu"), ``},
+		{"index", `$.items[1].arch`, cty.StringVal("arm64"), ``},
+		{"negative index", `$.items[-1].arch`, cty.StringVal("s390x"), ``},
+		{"quoted key", `$.labels['release.tag']`, cty.StringVal("noble"), ``},
+		{"double quoted key", `$["labels"]["os"]`, cty.StringVal("linux"), ``},
+		{
+			"wildcard", `$.items[*].arch`,
+			cty.TupleVal([]cty.Value{cty.StringVal("amd64"), cty.StringVal("arm64"), cty.StringVal("s390x")}),
+			``,
+		},
+		{
+			"dot wildcard", `$.labels.*`,
+			cty.TupleVal([]cty.Value{cty.StringVal("linux"), cty.StringVal("noble")}),
+			``,
+		},
+		{
+			"slice", `$.items[:2].arch`,
+			cty.TupleVal([]cty.Value{cty.StringVal("amd64"), cty.StringVal("arm64")}),
+			``,
+		},
+		{
+			"reverse slice", `$.items[::-1].size`,
+			cty.TupleVal([]cty.Value{cty.NumberIntVal(600), cty.NumberIntVal(480), cty.NumberIntVal(512)}),
+			``,
+		},
+		{
+			"union", `$.items[0,2].arch`,
+			cty.TupleVal([]cty.Value{cty.StringVal("amd64"), cty.StringVal("s390x")}),
+			``,
+		},
+		{
+			"filter", `$.items[?(@.arch=='amd64')].url`,
+			cty.TupleVal([]cty.Value{cty.StringVal("https://example.com/amd64.iso")}),
+			``,
+		},
+		{
+			"filter without parentheses", `$.items[? @.size < 500].arch`,
+			cty.TupleVal([]cty.Value{cty.StringVal("arm64")}),
+			``,
+		},
+		{
+			"filter with logical operators", `$.items[?(@.size >= 500 && !(@.arch == "s390x") || @.arch == 'arm64')].arch`,
+			cty.TupleVal([]cty.Value{cty.StringVal("amd64"), cty.StringVal("arm64")}),
+			``,
+		},
+		{
+			"filter on null", `$.items[?(@.url == null)].arch`,
+			cty.TupleVal([]cty.Value{cty.StringVal("s390x")}),
+			``,
+		},
+		{
+			"filter on root", `$.items[?(@.arch == $.items[1].arch)].size`,
+			cty.TupleVal([]cty.Value{cty.NumberIntVal(480)}),
+			``,
+		},
+		{
+			"existence filter", `$.items[?(@.url)].arch`,
+			cty.TupleVal([]cty.Value{cty.StringVal("amd64"), cty.StringVal("arm64"), cty.StringVal("s390x")}),
+			``,
+		},
+		{
+			"recursive descent", `$..arch`,
+			cty.TupleVal([]cty.Value{cty.StringVal("amd64"), cty.StringVal("arm64"), cty.StringVal("s390x")}),
+			``,
+		},
+		{
+			"recursive descent with brackets", `$..['os']`,
+			cty.TupleVal([]cty.Value{cty.StringVal("linux")}),
+			``,
+		},
+		{
+			"missing attribute", `$.items[0].sha`,
+			cty.NilVal,
+			`$.items[0] has no attribute "sha", its attributes are "arch", "size", "url"`,
+		},
+		{
+			"missing key", `$.labels.arch`,
+			cty.NilVal,
+			`$.labels has no key "arch"`,
+		},
+		{
+			"index out of range", `$.items[3]`,
+			cty.NilVal,
+			`$.items has 3 elements, there is no element at index 3`,
+		},
+		{
+			"index of object", `$.name[0]`,
+			cty.NilVal,
+			`$.name is string, not a list: [0] matches nothing in it`,
+		},
+		{
+			"filter matching nothing", `$.items[?(@.arch=='riscv64')].url`,
+			cty.NilVal,
+			`no element of $.items matches [?(@.arch=='riscv64')]`,
+		},
+		{
+			"nothing after a wildcard", `$.items[*].sha`,
+			cty.NilVal,
+			`.sha matches nothing in any of the 3 values matched before it ($.items[0], $.items[1], $.items[2])`,
+		},
+		{
+			"no root", `items[0]`,
+			cty.NilVal,
+			`invalid JSONPath "items[0]": paths must start with $`,
+		},
+		{
+			"unterminated bracket", `$.items[0`,
+			cty.NilVal,
+			`invalid JSONPath "$.items[0": at offset 9: expected , or ] in brackets`,
+		},
+		{
+			"trailing garbage", `$.items]`,
+			cty.NilVal,
+			`invalid JSONPath "$.items]": at offset 7: unexpected "]"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			got, err := JSONPathFunc.Call([]cty.Value{doc, cty.StringVal(test.Path)})
+
+			if test.Err != "" {
+				if err == nil {
+					t.Fatalf("succeeded with %#v; want error", got)
+				}
+				if err.Error() != test.Err {
+					t.Fatalf("wrong error\ngot:  %s\nwant: %s", err, test.Err)
+				}
+				if argErr, ok := err.(function.ArgError); !ok || argErr.Index != 1 {
+					t.Errorf("expected an error on the path argument, got %#v", err)
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !got.RawEquals(test.Want) {
+				t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, test.Want)
+			}
+		})
+	}
+}
+
+func TestJSONPath_unknown(t *testing.T) {
+	doc := cty.ObjectVal(map[string]cty.Value{
+		"name": cty.UnknownVal(cty.String),
+	})
+
+	got, err := JSONPathFunc.Call([]cty.Value{doc, cty.StringVal("$.name")})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got.IsKnown() {
+		t.Errorf("expected an unknown result, got %#v", got)
+	}
+
+	_, err = JSONPathFunc.Call([]cty.Value{doc, cty.StringVal("$.name[")})
+	if err == nil || !strings.Contains(err.Error(), "invalid JSONPath") {
+		t.Errorf("expected invalid paths to be reported with unknown values, got %v", err)
+	}
+}
@@ -7,3 +7,7 @@ import (
 func refineNotNull(b *cty.RefinementBuilder) *cty.RefinementBuilder {
 	return b.NotNull()
 }
+
+func refineComparison(b *cty.RefinementBuilder) *cty.RefinementBuilder {
+	return b.NotNull().NumberRangeInclusive(cty.NumberIntVal(-1), cty.NumberIntVal(1))
+}
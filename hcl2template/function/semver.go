@@ -0,0 +1,206 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package function
+
+import (
+	"sort"
+
+	"github.com/hashicorp/go-version"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+var semverType = cty.Object(map[string]cty.Type{
+	"major":      cty.Number,
+	"minor":      cty.Number,
+	"patch":      cty.Number,
+	"prerelease": cty.String,
+	"metadata":   cty.String,
+	"version":    cty.String,
+})
+
+// SemverParseFunc constructs a function that parses a semantic version into
+// its components.
+var SemverParseFunc = function.New(&function.Spec{
+	Description: "Parses a semantic version, like 1.2.3-beta.1+build, into its components.",
+	Params: []function.Parameter{
+		{
+			Name: "version",
+			Type: cty.String,
+		},
+	},
+	Type:         function.StaticReturnType(semverType),
+	RefineResult: refineNotNull,
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		v, err := parseSemver(args[0], 0)
+		if err != nil {
+			return cty.UnknownVal(retType), err
+		}
+		segments := v.Segments64()
+		return cty.ObjectVal(map[string]cty.Value{
+			"major":      cty.NumberIntVal(segments[0]),
+			"minor":      cty.NumberIntVal(segments[1]),
+			"patch":      cty.NumberIntVal(segments[2]),
+			"prerelease": cty.StringVal(v.Prerelease()),
+			"metadata":   cty.StringVal(v.Metadata()),
+			"version":    cty.StringVal(v.String()),
+		}), nil
+	},
+})
+
+// SemverCompareFunc constructs a function that compares two semantic
+// versions, returning -1, 0 or 1 when the first one is respectively older
+// than, equal to or newer than the second one.
+var SemverCompareFunc = function.New(&function.Spec{
+	Description: "Compares two semantic versions, returning -1, 0 or 1 when the first one is older than, equal to or newer than the second one.",
+	Params: []function.Parameter{
+		{
+			Name: "a",
+			Type: cty.String,
+		},
+		{
+			Name: "b",
+			Type: cty.String,
+		},
+	},
+	Type:         function.StaticReturnType(cty.Number),
+	RefineResult: refineComparison,
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		a, err := parseSemver(args[0], 0)
+		if err != nil {
+			return cty.UnknownVal(retType), err
+		}
+		b, err := parseSemver(args[1], 1)
+		if err != nil {
+			return cty.UnknownVal(retType), err
+		}
+		return cty.NumberIntVal(int64(a.Compare(b))), nil
+	},
+})
+
+// SemverSortFunc constructs a function that sorts a list of semantic
+// versions, from the oldest to the newest.
+var SemverSortFunc = function.New(&function.Spec{
+	Description: "Sorts a list of semantic versions, from the oldest to the newest.",
+	Params: []function.Parameter{
+		{
+			Name: "versions",
+			Type: cty.List(cty.String),
+		},
+	},
+	Type:         function.StaticReturnType(cty.List(cty.String)),
+	RefineResult: refineNotNull,
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		versions, vals, err := parseSemverList(args[0])
+		if err != nil {
+			return cty.UnknownVal(retType), err
+		}
+		if len(vals) == 0 {
+			return cty.ListValEmpty(cty.String), nil
+		}
+
+		indexes := make([]int, len(versions))
+		for i := range indexes {
+			indexes[i] = i
+		}
+		sort.SliceStable(indexes, func(i, j int) bool {
+			return versions[indexes[i]].LessThan(versions[indexes[j]])
+		})
+
+		sorted := make([]cty.Value, 0, len(vals))
+		for _, i := range indexes {
+			sorted = append(sorted, vals[i])
+		}
+		return cty.ListVal(sorted), nil
+	},
+})
+
+// SemverMaxFunc constructs a function that returns the newest of a list of
+// semantic versions.
+var SemverMaxFunc = function.New(&function.Spec{
+	Description: "Returns the newest of a list of semantic versions, as written in the list.",
+	Params: []function.Parameter{
+		{
+			Name: "versions",
+			Type: cty.List(cty.String),
+		},
+	},
+	Type:         function.StaticReturnType(cty.String),
+	RefineResult: refineNotNull,
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		versions, vals, err := parseSemverList(args[0])
+		if err != nil {
+			return cty.UnknownVal(retType), err
+		}
+		if len(vals) == 0 {
+			return cty.UnknownVal(retType), function.NewArgErrorf(0, "no versions given")
+		}
+
+		max := 0
+		for i := range versions {
+			if versions[i].GreaterThan(versions[max]) {
+				max = i
+			}
+		}
+		return vals[max], nil
+	},
+})
+
+// SemverConstraintFunc constructs a function that tells whether a semantic
+// version satisfies a constraint, like ">= 1.2, < 2".
+var SemverConstraintFunc = function.New(&function.Spec{
+	Description: "Tells whether a semantic version satisfies a version constraint, like \">= 1.2, < 2\".",
+	Params: []function.Parameter{
+		{
+			Name: "version",
+			Type: cty.String,
+		},
+		{
+			Name: "constraint",
+			Type: cty.String,
+		},
+	},
+	Type:         function.StaticReturnType(cty.Bool),
+	RefineResult: refineNotNull,
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		v, err := parseSemver(args[0], 0)
+		if err != nil {
+			return cty.UnknownVal(retType), err
+		}
+		constraints, err := version.NewConstraint(args[1].AsString())
+		if err != nil {
+			return cty.UnknownVal(retType), function.NewArgErrorf(1, "invalid version constraint: %s", err)
+		}
+		return cty.BoolVal(constraints.Check(v)), nil
+	},
+})
+
+// parseSemver parses val, the argument at index argIdx.
+func parseSemver(val cty.Value, argIdx int) (*version.Version, error) {
+	v, err := version.NewSemver(val.AsString())
+	if err != nil {
+		return nil, function.NewArgErrorf(argIdx, "invalid version %q: %s", val.AsString(), err)
+	}
+	return v, nil
+}
+
+// parseSemverList parses the versions of list, the first argument, and
+// returns them with their values.
+func parseSemverList(list cty.Value) ([]*version.Version, []cty.Value, error) {
+	var versions []*version.Version
+	var vals []cty.Value
+	for it := list.ElementIterator(); it.Next(); {
+		i, val := it.Element()
+		if val.IsNull() {
+			return nil, nil, function.NewArgErrorf(0, "element %s is null", i.AsBigFloat().String())
+		}
+		v, err := version.NewSemver(val.AsString())
+		if err != nil {
+			return nil, nil, function.NewArgErrorf(0, "invalid version %q at index %s: %s", val.AsString(), i.AsBigFloat().String(), err)
+		}
+		versions = append(versions, v)
+		vals = append(vals, val)
+	}
+	return versions, vals, nil
+}
@@ -0,0 +1,262 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package function
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+func TestSemverParse(t *testing.T) {
+	tests := []struct {
+		Version cty.Value
+		Want    cty.Value
+		Err     string
+	}{
+		{
+			cty.StringVal("1.2.3"),
+			cty.ObjectVal(map[string]cty.Value{
+				"major":      cty.NumberIntVal(1),
+				"minor":      cty.NumberIntVal(2),
+				"patch":      cty.NumberIntVal(3),
+				"prerelease": cty.StringVal(""),
+				"metadata":   cty.StringVal(""),
+				"version":    cty.StringVal("1.2.3"),
+			}),
+			``,
+		},
+		{
+			cty.StringVal("v1.10.0-beta.1+build.5"),
+			cty.ObjectVal(map[string]cty.Value{
+				"major":      cty.NumberIntVal(1),
+				"minor":      cty.NumberIntVal(10),
+				"patch":      cty.NumberIntVal(0),
+				"prerelease": cty.StringVal("beta.1"),
+				"metadata":   cty.StringVal("build.5"),
+				"version":    cty.StringVal("1.10.0-beta.1+build.5"),
+			}),
+			``,
+		},
+		{
+			cty.StringVal("2.1"),
+			cty.ObjectVal(map[string]cty.Value{
+				"major":      cty.NumberIntVal(2),
+				"minor":      cty.NumberIntVal(1),
+				"patch":      cty.NumberIntVal(0),
+				"prerelease": cty.StringVal(""),
+				"metadata":   cty.StringVal(""),
+				"version":    cty.StringVal("2.1.0"),
+			}),
+			``,
+		},
+		{
+			cty.StringVal("latest"),
+			cty.NilVal,
+			`invalid version "latest"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("semverparse(%#v)", test.Version), func(t *testing.T) {
+			got, err := SemverParseFunc.Call([]cty.Value{test.Version})
+
+			if test.Err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.Err) {
+					t.Fatalf("wrong error\ngot:  %v\nwant: %s", err, test.Err)
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !got.RawEquals(test.Want) {
+				t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, test.Want)
+			}
+		})
+	}
+}
+
+func TestSemverCompare(t *testing.T) {
+	tests := []struct {
+		A, B cty.Value
+		Want cty.Value
+		Err  string
+	}{
+		{cty.StringVal("1.2.3"), cty.StringVal("1.2.3"), cty.NumberIntVal(0), ``},
+		{cty.StringVal("v1.2.3"), cty.StringVal("1.2.3+build"), cty.NumberIntVal(0), ``},
+		{cty.StringVal("1.2.3"), cty.StringVal("1.10.0"), cty.NumberIntVal(-1), ``},
+		{cty.StringVal("2.0.0"), cty.StringVal("2.0.0-rc.1"), cty.NumberIntVal(1), ``},
+		{cty.StringVal("1.0.0"), cty.StringVal("one"), cty.NilVal, `invalid version "one"`},
+		{
+			cty.UnknownVal(cty.String), cty.StringVal("1.0.0"),
+			cty.UnknownVal(cty.Number).RefineNotNull().Refine().NumberRangeInclusive(cty.NumberIntVal(-1), cty.NumberIntVal(1)).NewValue(),
+			``,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("semvercompare(%#v, %#v)", test.A, test.B), func(t *testing.T) {
+			got, err := SemverCompareFunc.Call([]cty.Value{test.A, test.B})
+
+			if test.Err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.Err) {
+					t.Fatalf("wrong error\ngot:  %v\nwant: %s", err, test.Err)
+				}
+				if argErr, ok := err.(function.ArgError); !ok || argErr.Index != 1 {
+					t.Errorf("expected an error on the second argument, got %#v", err)
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !got.RawEquals(test.Want) {
+				t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, test.Want)
+			}
+		})
+	}
+}
+
+func TestSemverSort(t *testing.T) {
+	tests := []struct {
+		Versions cty.Value
+		Want     cty.Value
+		Err      string
+	}{
+		{
+			cty.ListVal([]cty.Value{
+				cty.StringVal("1.10.0"),
+				cty.StringVal("v1.2.0"),
+				cty.StringVal("1.2.0-beta"),
+				cty.StringVal("0.9.12"),
+			}),
+			cty.ListVal([]cty.Value{
+				cty.StringVal("0.9.12"),
+				cty.StringVal("1.2.0-beta"),
+				cty.StringVal("v1.2.0"),
+				cty.StringVal("1.10.0"),
+			}),
+			``,
+		},
+		{
+			cty.ListValEmpty(cty.String),
+			cty.ListValEmpty(cty.String),
+			``,
+		},
+		{
+			cty.ListVal([]cty.Value{cty.StringVal("1.0.0"), cty.StringVal("nightly")}),
+			cty.NilVal,
+			`invalid version "nightly" at index 1`,
+		},
+		{
+			cty.ListVal([]cty.Value{cty.StringVal("1.0.0"), cty.NullVal(cty.String)}),
+			cty.NilVal,
+			`element 1 is null`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("semversort(%#v)", test.Versions), func(t *testing.T) {
+			got, err := SemverSortFunc.Call([]cty.Value{test.Versions})
+
+			if test.Err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.Err) {
+					t.Fatalf("wrong error\ngot:  %v\nwant: %s", err, test.Err)
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !got.RawEquals(test.Want) {
+				t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, test.Want)
+			}
+		})
+	}
+}
+
+func TestSemverMax(t *testing.T) {
+	tests := []struct {
+		Versions cty.Value
+		Want     cty.Value
+		Err      string
+	}{
+		{
+			cty.ListVal([]cty.Value{
+				cty.StringVal("v1.2.0"),
+				cty.StringVal("1.10.0"),
+				cty.StringVal("1.10.1-rc.1"),
+			}),
+			cty.StringVal("1.10.1-rc.1"),
+			``,
+		},
+		{
+			cty.ListVal([]cty.Value{cty.StringVal("v2.0.0")}),
+			cty.StringVal("v2.0.0"),
+			``,
+		},
+		{
+			cty.ListValEmpty(cty.String),
+			cty.NilVal,
+			`no versions given`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("semvermax(%#v)", test.Versions), func(t *testing.T) {
+			got, err := SemverMaxFunc.Call([]cty.Value{test.Versions})
+
+			if test.Err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.Err) {
+					t.Fatalf("wrong error\ngot:  %v\nwant: %s", err, test.Err)
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !got.RawEquals(test.Want) {
+				t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, test.Want)
+			}
+		})
+	}
+}
+
+func TestSemverConstraint(t *testing.T) {
+	tests := []struct {
+		Version    cty.Value
+		Constraint cty.Value
+		Want       cty.Value
+		Err        string
+	}{
+		{cty.StringVal("1.5.0"), cty.StringVal(">= 1.2, < 2"), cty.True, ``},
+		{cty.StringVal("2.0.0"), cty.StringVal(">= 1.2, < 2"), cty.False, ``},
+		{cty.StringVal("1.2.9"), cty.StringVal("~> 1.2.0"), cty.True, ``},
+		{cty.StringVal("1.3.0"), cty.StringVal("~> 1.2.0"), cty.False, ``},
+		{cty.StringVal("1.5.0"), cty.StringVal("newer than 1"), cty.NilVal, `invalid version constraint`},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("semverconstraint(%#v, %#v)", test.Version, test.Constraint), func(t *testing.T) {
+			got, err := SemverConstraintFunc.Call([]cty.Value{test.Version, test.Constraint})
+
+			if test.Err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.Err) {
+					t.Fatalf("wrong error\ngot:  %v\nwant: %s", err, test.Err)
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !got.RawEquals(test.Want) {
+				t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, test.Want)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package function
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// TOMLDecodeFunc constructs a function that parses a TOML document into an
+// object. Dates and times are decoded as strings, as written in RFC 3339
+// format.
+var TOMLDecodeFunc = function.New(&function.Spec{
+	Description: "Parses a TOML document into an object.",
+	Params: []function.Parameter{
+		{
+			Name: "src",
+			Type: cty.String,
+		},
+	},
+	Type:         function.StaticReturnType(cty.DynamicPseudoType),
+	RefineResult: refineNotNull,
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		var doc map[string]interface{}
+		if _, err := toml.Decode(args[0].AsString(), &doc); err != nil {
+			return cty.DynamicVal, fmt.Errorf("failed to parse TOML: %s", err)
+		}
+		val, err := tomlToCty(doc, "")
+		if err != nil {
+			return cty.DynamicVal, function.NewArgError(0, err)
+		}
+		return val, nil
+	},
+})
+
+// TOMLEncodeFunc constructs a function that encodes an object or a map as a
+// TOML document. Null attributes are omitted, as TOML has no null value.
+var TOMLEncodeFunc = function.New(&function.Spec{
+	Description: "Encodes an object or a map as a TOML document.",
+	Params: []function.Parameter{
+		{
+			Name: "value",
+			Type: cty.DynamicPseudoType,
+		},
+	},
+	Type:         function.StaticReturnType(cty.String),
+	RefineResult: refineNotNull,
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		val := args[0]
+		if !val.IsWhollyKnown() {
+			return cty.UnknownVal(retType), nil
+		}
+		if ty := val.Type(); !ty.IsObjectType() && !ty.IsMapType() {
+			return cty.UnknownVal(retType), function.NewArgErrorf(0, "TOML documents are tables, cannot encode %s", ty.FriendlyName())
+		}
+
+		doc, err := ctyToTOML(val, "")
+		if err != nil {
+			return cty.UnknownVal(retType), function.NewArgError(0, err)
+		}
+
+		var buf bytes.Buffer
+		enc := toml.NewEncoder(&buf)
+		enc.Indent = ""
+		if err := enc.Encode(doc); err != nil {
+			return cty.UnknownVal(retType), function.NewArgErrorf(0, "failed to encode TOML: %s", err)
+		}
+		return cty.StringVal(buf.String()), nil
+	},
+})
+
+// tomlToCty converts a value decoded by the TOML decoder to a cty value. path
+// locates v in errors.
+func tomlToCty(v interface{}, path string) (cty.Value, error) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		attrs := make(map[string]cty.Value, len(v))
+		for k, elem := range v {
+			val, err := tomlToCty(elem, tomlPath(path, k))
+			if err != nil {
+				return cty.NilVal, err
+			}
+			attrs[k] = val
+		}
+		return cty.ObjectVal(attrs), nil
+	case []map[string]interface{}:
+		elems := make([]cty.Value, 0, len(v))
+		for _, elem := range v {
+			val, err := tomlToCty(elem, fmt.Sprintf("%s[%d]", path, len(elems)))
+			if err != nil {
+				return cty.NilVal, err
+			}
+			elems = append(elems, val)
+		}
+		return cty.TupleVal(elems), nil
+	case []interface{}:
+		elems := make([]cty.Value, 0, len(v))
+		for _, elem := range v {
+			val, err := tomlToCty(elem, fmt.Sprintf("%s[%d]", path, len(elems)))
+			if err != nil {
+				return cty.NilVal, err
+			}
+			elems = append(elems, val)
+		}
+		return cty.TupleVal(elems), nil
+	case string:
+		return cty.StringVal(v), nil
+	case bool:
+		return cty.BoolVal(v), nil
+	case int64:
+		return cty.NumberIntVal(v), nil
+	case float64:
+		if math.IsNaN(v) {
+			return cty.NilVal, fmt.Errorf("cannot decode nan%s, numbers cannot be NaN", tomlPathSuffix(path))
+		}
+		return cty.NumberFloatVal(v), nil
+	case time.Time:
+		// Local dates and times have no offset, the decoder marks them with
+		// a location of their own.
+		switch v.Location().String() {
+		case "datetime-local":
+			return cty.StringVal(v.Format("2006-01-02T15:04:05.999999999")), nil
+		case "date-local":
+			return cty.StringVal(v.Format("2006-01-02")), nil
+		case "time-local":
+			return cty.StringVal(v.Format("15:04:05.999999999")), nil
+		}
+		return cty.StringVal(v.Format(time.RFC3339Nano)), nil
+	default:
+		return cty.StringVal(fmt.Sprint(v)), nil
+	}
+}
+
+// ctyToTOML converts val to the Go values encoded by the TOML encoder. path
+// locates val in errors.
+func ctyToTOML(val cty.Value, path string) (interface{}, error) {
+	if val.IsNull() {
+		return nil, fmt.Errorf("cannot encode null value%s in TOML", tomlPathSuffix(path))
+	}
+
+	ty := val.Type()
+	switch {
+	case ty == cty.String:
+		return val.AsString(), nil
+	case ty == cty.Bool:
+		return val.True(), nil
+	case ty == cty.Number:
+		bf := val.AsBigFloat()
+		if i, acc := bf.Int64(); acc == big.Exact {
+			return i, nil
+		}
+		f, _ := bf.Float64()
+		return f, nil
+	case ty.IsObjectType() || ty.IsMapType():
+		doc := map[string]interface{}{}
+		for it := val.ElementIterator(); it.Next(); {
+			k, elem := it.Element()
+			if elem.IsNull() {
+				continue
+			}
+			v, err := ctyToTOML(elem, tomlPath(path, k.AsString()))
+			if err != nil {
+				return nil, err
+			}
+			doc[k.AsString()] = v
+		}
+		return doc, nil
+	case ty.IsListType() || ty.IsTupleType() || ty.IsSetType():
+		elems := make([]interface{}, 0, val.LengthInt())
+		for it := val.ElementIterator(); it.Next(); {
+			_, elem := it.Element()
+			v, err := ctyToTOML(elem, fmt.Sprintf("%s[%d]", path, len(elems)))
+			if err != nil {
+				return nil, err
+			}
+			elems = append(elems, v)
+		}
+		return elems, nil
+	default:
+		return nil, fmt.Errorf("cannot encode %s%s in TOML", ty.FriendlyName(), tomlPathSuffix(path))
+	}
+}
+
+func tomlPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func tomlPathSuffix(path string) string {
+	if path == "" {
+		return ""
+	}
+	return fmt.Sprintf(" at %s", path)
+}
@@ -0,0 +1,176 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package function
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestTOMLDecode(t *testing.T) {
+	tests := []struct {
+		Name string
+		Src  string
+		Want cty.Value
+		Err  string
+	}{
+		{
+			"document",
+			`
+title = "image"
+size = 20
+ratio = 1.5
+enabled = true
+tags = ["a", "b"]
+released = 2024-01-02T03:04:05Z
+day = 2024-01-02
+
+[owner]
+name = "ops"
+
+[[disks]]
+size = 10
+
+[[disks]]
+size = 20
+`,
+			cty.ObjectVal(map[string]cty.Value{
+				"title":    cty.StringVal("image"),
+				"size":     cty.NumberIntVal(20),
+				"ratio":    cty.NumberFloatVal(1.5),
+				"enabled":  cty.True,
+				"tags":     cty.TupleVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b")}),
+				"released": cty.StringVal("2024-01-02T03:04:05Z"),
+				"day":      cty.StringVal("2024-01-02"),
+				"owner": cty.ObjectVal(map[string]cty.Value{
+					"name": cty.StringVal("ops"),
+				}),
+				"disks": cty.TupleVal([]cty.Value{
+					cty.ObjectVal(map[string]cty.Value{"size": cty.NumberIntVal(10)}),
+					cty.ObjectVal(map[string]cty.Value{"size": cty.NumberIntVal(20)}),
+				}),
+			}),
+			``,
+		},
+		{
+			"empty",
+			``,
+			cty.EmptyObjectVal,
+			``,
+		},
+		{
+			"invalid",
+			`title = `,
+			cty.NilVal,
+			`failed to parse TOML`,
+		},
+		{
+			"nan",
+			"[disk]\nratio = nan",
+			cty.NilVal,
+			`cannot decode nan at disk.ratio`,
+		},
+		{
+			"inf",
+			`ratio = inf`,
+			cty.ObjectVal(map[string]cty.Value{
+				"ratio": cty.PositiveInfinity,
+			}),
+			``,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			got, err := TOMLDecodeFunc.Call([]cty.Value{cty.StringVal(test.Src)})
+
+			if test.Err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.Err) {
+					t.Fatalf("wrong error\ngot:  %v\nwant: %s", err, test.Err)
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !got.RawEquals(test.Want) {
+				t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, test.Want)
+			}
+		})
+	}
+}
+
+func TestTOMLEncode(t *testing.T) {
+	tests := []struct {
+		Name  string
+		Value cty.Value
+		Want  string
+		Err   string
+	}{
+		{
+			"document",
+			cty.ObjectVal(map[string]cty.Value{
+				"title":   cty.StringVal("image"),
+				"size":    cty.NumberIntVal(20),
+				"ratio":   cty.NumberFloatVal(1.5),
+				"enabled": cty.True,
+				"comment": cty.NullVal(cty.String),
+				"tags":    cty.ListVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b")}),
+				"owner": cty.MapVal(map[string]cty.Value{
+					"name": cty.StringVal("ops"),
+				}),
+			}),
+			`enabled = true
+ratio = 1.5
+size = 20
+tags = ["a", "b"]
+title = "image"
+
+[owner]
+name = "ops"
+`,
+			``,
+		},
+		{
+			"not a table",
+			cty.StringVal("image"),
+			``,
+			`TOML documents are tables, cannot encode string`,
+		},
+		{
+			"null element",
+			cty.ObjectVal(map[string]cty.Value{
+				"tags": cty.TupleVal([]cty.Value{cty.StringVal("a"), cty.NullVal(cty.String)}),
+			}),
+			``,
+			`cannot encode null value at tags[1] in TOML`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			got, err := TOMLEncodeFunc.Call([]cty.Value{test.Value})
+
+			if test.Err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.Err) {
+					t.Fatalf("wrong error\ngot:  %v\nwant: %s", err, test.Err)
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if got.AsString() != test.Want {
+				t.Errorf("wrong result\ngot:\n%s\nwant:\n%s", got.AsString(), test.Want)
+			}
+
+			// Encoded documents decode to the original value, without nulls.
+			if _, err := TOMLDecodeFunc.Call([]cty.Value{got}); err != nil {
+				t.Errorf("failed to decode the encoded document: %s", err)
+			}
+		})
+	}
+}
@@ -4,8 +4,17 @@
 package function
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	vaultapi "github.com/hashicorp/vault/api"
 	"github.com/zclconf/go-cty/cty"
 	"github.com/zclconf/go-cty/cty/function"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
 
 	commontpl "github.com/hashicorp/packer-plugin-sdk/template"
 )
@@ -32,3 +41,162 @@ var VaultFunc = function.New(&function.Spec{
 		return cty.StringVal(val), err
 	},
 })
+
+// MakeVaultSecretFunc constructs a function that retrieves a whole KV secret
+// from HC vault, as an object. Each function has its own cache: a secret is
+// read once, whatever the number of calls, unless reading it failed.
+func MakeVaultSecretFunc() function.Function {
+	cache := &vaultSecretCache{
+		secrets: map[string]*vaultSecret{},
+	}
+
+	return function.New(&function.Spec{
+		Description: "Reads a secret from a Vault KV store, and returns its data with, for KV v2 stores, its version and metadata.",
+		Params: []function.Parameter{
+			{
+				Name:        "path",
+				Description: "The path of the secret, like secret/data/name for a KV v2 store.",
+				Type:        cty.String,
+			},
+		},
+		VarParam: &function.Parameter{
+			Name:        "version",
+			Description: "The version of the secret to read from a KV v2 store, the latest one by default.",
+			Type:        cty.Number,
+		},
+		Type: function.StaticReturnType(cty.DynamicPseudoType),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			path := args[0].AsString()
+			version := ""
+			switch len(args) {
+			case 1:
+			case 2:
+				bf := args[1].AsBigFloat()
+				if !bf.IsInt() || bf.Sign() <= 0 {
+					return cty.DynamicVal, function.NewArgErrorf(1, "version must be a positive whole number")
+				}
+				version = bf.Text('f', 0)
+			default:
+				return cty.DynamicVal, function.NewArgErrorf(2, "too many arguments, expected a path and an optional version")
+			}
+
+			return cache.get(path, version)
+		},
+	})
+}
+
+// vaultSecretCache memoizes the secrets read by a vault_secret function, by
+// path and version. Failed reads are forgotten so that they can be retried.
+type vaultSecretCache struct {
+	l       sync.Mutex
+	secrets map[string]*vaultSecret
+}
+
+type vaultSecret struct {
+	once  sync.Once
+	value cty.Value
+	err   error
+}
+
+func (c *vaultSecretCache) get(path, version string) (cty.Value, error) {
+	key := path + "?version=" + version
+
+	c.l.Lock()
+	secret, found := c.secrets[key]
+	if !found {
+		secret = &vaultSecret{}
+		c.secrets[key] = secret
+	}
+	c.l.Unlock()
+
+	// Concurrent calls for the same secret wait for the first one.
+	secret.once.Do(func() {
+		secret.value, secret.err = readVaultSecret(path, version)
+	})
+	if secret.err != nil {
+		c.l.Lock()
+		if c.secrets[key] == secret {
+			delete(c.secrets, key)
+		}
+		c.l.Unlock()
+	}
+	return secret.value, secret.err
+}
+
+// readVaultSecret reads the secret at path, the latest version of it when
+// version is empty.
+func readVaultSecret(path, version string) (cty.Value, error) {
+	if token := os.Getenv("VAULT_TOKEN"); token == "" {
+		return cty.DynamicVal, errors.New("Must set VAULT_TOKEN env var in order to use vault_secret function")
+	}
+
+	cli, err := vaultapi.NewClient(vaultapi.DefaultConfig())
+	if err != nil {
+		return cty.DynamicVal, fmt.Errorf("Error getting Vault client: %s", err)
+	}
+
+	var data map[string][]string
+	if version != "" {
+		data = map[string][]string{"version": {version}}
+	}
+	secret, err := cli.Logical().ReadWithData(path, data)
+	if err != nil {
+		return cty.DynamicVal, fmt.Errorf("Error reading vault secret: %s", err)
+	}
+	if secret == nil || secret.Data == nil {
+		return cty.DynamicVal, fmt.Errorf("Vault secret %q does not exist", path)
+	}
+
+	secretData := secret.Data
+	_, hasData := secret.Data["data"]
+	metadata, hasMetadata := secret.Data["metadata"]
+	kvV2 := hasData && hasMetadata
+	if kvV2 {
+		inner, ok := secret.Data["data"].(map[string]interface{})
+		if !ok {
+			// The version was deleted or destroyed.
+			return cty.DynamicVal, fmt.Errorf("Vault secret %q has no data for this version. "+
+				"Original warnings from Vault call: %s", path, strings.Join(secret.Warnings, "; "))
+		}
+		secretData = inner
+	} else if version != "" {
+		return cty.DynamicVal, fmt.Errorf("Vault secret %q is not versioned, only secrets of KV v2 stores have versions", path)
+	}
+
+	dataVal, err := jsonToCty(secretData)
+	if err != nil {
+		return cty.DynamicVal, fmt.Errorf("Error decoding vault secret %q: %s", path, err)
+	}
+
+	versionVal := cty.NullVal(cty.Number)
+	metadataVal := cty.NullVal(cty.DynamicPseudoType)
+	if kvV2 {
+		metadataVal, err = jsonToCty(metadata)
+		if err != nil {
+			return cty.DynamicVal, fmt.Errorf("Error decoding metadata of vault secret %q: %s", path, err)
+		}
+		if t := metadataVal.Type(); t.IsObjectType() && t.HasAttribute("version") && t.AttributeType("version") == cty.Number {
+			versionVal = metadataVal.GetAttr("version")
+		}
+	}
+
+	return cty.ObjectVal(map[string]cty.Value{
+		"data":     dataVal,
+		"version":  versionVal,
+		"metadata": metadataVal,
+	}), nil
+}
+
+// jsonToCty converts a value decoded from JSON to a cty value of its implied
+// type: objects for JSON objects, tuples for JSON arrays.
+func jsonToCty(v interface{}) (cty.Value, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return cty.NilVal, err
+	}
+	t, err := ctyjson.ImpliedType(b)
+	if err != nil {
+		return cty.NilVal, err
+	}
+	return ctyjson.Unmarshal(b, t)
+}
@@ -0,0 +1,218 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package function
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// fakeVault serves KV v1 secrets under /v1/kv/, and KV v2 secrets, at
+// version 1 and 2, under /v1/secret/data/.
+func fakeVault(t *testing.T) (*httptest.Server, *int64) {
+	var requests int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requests, 1)
+		if r.Header.Get("X-Vault-Token") != "test-token" {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"errors":["permission denied"]}`)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/kv/app":
+			fmt.Fprint(w, `{"data":{"user":"admin","port":5432}}`)
+		case "/v1/secret/data/app":
+			switch r.URL.Query().Get("version") {
+			case "", "2":
+				fmt.Fprint(w, `{"data":{"data":{"user":"admin","password":"s3cr3t"},"metadata":{"created_time":"2024-01-02T00:00:00Z","deletion_time":"","destroyed":false,"version":2}}}`)
+			case "1":
+				fmt.Fprint(w, `{"data":{"data":{"user":"root","password":"hunter2"},"metadata":{"created_time":"2024-01-01T00:00:00Z","deletion_time":"","destroyed":false,"version":1}}}`)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, `{"errors":[]}`)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"errors":[]}`)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	t.Setenv("VAULT_ADDR", server.URL)
+	t.Setenv("VAULT_TOKEN", "test-token")
+	t.Setenv("VAULT_MAX_RETRIES", "0")
+	return server, &requests
+}
+
+func TestVaultSecret(t *testing.T) {
+	fakeVault(t)
+
+	kvV2Metadata := func(version int64, created string) cty.Value {
+		return cty.ObjectVal(map[string]cty.Value{
+			"created_time":  cty.StringVal(created),
+			"deletion_time": cty.StringVal(""),
+			"destroyed":     cty.False,
+			"version":       cty.NumberIntVal(version),
+		})
+	}
+
+	tests := []struct {
+		Name string
+		Args []cty.Value
+		Want cty.Value
+		Err  string
+	}{
+		{
+			"kv v1",
+			[]cty.Value{cty.StringVal("kv/app")},
+			cty.ObjectVal(map[string]cty.Value{
+				"data": cty.ObjectVal(map[string]cty.Value{
+					"user": cty.StringVal("admin"),
+					"port": cty.NumberIntVal(5432),
+				}),
+				"version":  cty.NullVal(cty.Number),
+				"metadata": cty.NullVal(cty.DynamicPseudoType),
+			}),
+			``,
+		},
+		{
+			"kv v2 latest",
+			[]cty.Value{cty.StringVal("secret/data/app")},
+			cty.ObjectVal(map[string]cty.Value{
+				"data": cty.ObjectVal(map[string]cty.Value{
+					"user":     cty.StringVal("admin"),
+					"password": cty.StringVal("s3cr3t"),
+				}),
+				"version":  cty.NumberIntVal(2),
+				"metadata": kvV2Metadata(2, "2024-01-02T00:00:00Z"),
+			}),
+			``,
+		},
+		{
+			"kv v2 version",
+			[]cty.Value{cty.StringVal("secret/data/app"), cty.NumberIntVal(1)},
+			cty.ObjectVal(map[string]cty.Value{
+				"data": cty.ObjectVal(map[string]cty.Value{
+					"user":     cty.StringVal("root"),
+					"password": cty.StringVal("hunter2"),
+				}),
+				"version":  cty.NumberIntVal(1),
+				"metadata": kvV2Metadata(1, "2024-01-01T00:00:00Z"),
+			}),
+			``,
+		},
+		{
+			"unknown version",
+			[]cty.Value{cty.StringVal("secret/data/app"), cty.NumberIntVal(3)},
+			cty.DynamicVal,
+			`Vault secret "secret/data/app" does not exist`,
+		},
+		{
+			"kv v1 version",
+			[]cty.Value{cty.StringVal("kv/app"), cty.NumberIntVal(1)},
+			cty.DynamicVal,
+			`Vault secret "kv/app" is not versioned, only secrets of KV v2 stores have versions`,
+		},
+		{
+			"invalid version",
+			[]cty.Value{cty.StringVal("secret/data/app"), cty.NumberFloatVal(1.5)},
+			cty.DynamicVal,
+			`version must be a positive whole number`,
+		},
+		{
+			"too many arguments",
+			[]cty.Value{cty.StringVal("secret/data/app"), cty.NumberIntVal(1), cty.NumberIntVal(2)},
+			cty.DynamicVal,
+			`too many arguments, expected a path and an optional version`,
+		},
+		{
+			"missing secret",
+			[]cty.Value{cty.StringVal("kv/nope")},
+			cty.DynamicVal,
+			`Vault secret "kv/nope" does not exist`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			got, err := MakeVaultSecretFunc().Call(test.Args)
+
+			if test.Err != "" {
+				if err == nil {
+					t.Fatal("succeeded; want error")
+				}
+				if !strings.Contains(err.Error(), test.Err) {
+					t.Fatalf("wrong error\ngot:  %s\nwant: %s", err, test.Err)
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !got.RawEquals(test.Want) {
+				t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, test.Want)
+			}
+		})
+	}
+}
+
+func TestVaultSecret_cache(t *testing.T) {
+	_, requests := fakeVault(t)
+
+	fn := MakeVaultSecretFunc()
+	for i := 0; i < 20; i++ {
+		got, err := fn.Call([]cty.Value{cty.StringVal("secret/data/app")})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if !got.GetAttr("version").RawEquals(cty.NumberIntVal(2)) {
+			t.Fatalf("unexpected version: %#v", got.GetAttr("version"))
+		}
+	}
+	if n := atomic.LoadInt64(requests); n != 1 {
+		t.Errorf("expected one request to Vault, got %d", n)
+	}
+
+	// Other versions are other secrets.
+	if _, err := fn.Call([]cty.Value{cty.StringVal("secret/data/app"), cty.NumberIntVal(1)}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if n := atomic.LoadInt64(requests); n != 2 {
+		t.Errorf("expected two requests to Vault, got %d", n)
+	}
+}
+
+func TestVaultSecret_noToken(t *testing.T) {
+	fakeVault(t)
+	t.Setenv("VAULT_TOKEN", "")
+
+	_, err := MakeVaultSecretFunc().Call([]cty.Value{cty.StringVal("kv/app")})
+	if err == nil || !strings.Contains(err.Error(), "Must set VAULT_TOKEN") {
+		t.Fatalf("expected a missing token error, got %v", err)
+	}
+}
+
+func TestVaultSecret_retryFailedRead(t *testing.T) {
+	fakeVault(t)
+	t.Setenv("VAULT_TOKEN", "wrong-token")
+
+	fn := MakeVaultSecretFunc()
+	if _, err := fn.Call([]cty.Value{cty.StringVal("kv/app")}); err == nil {
+		t.Fatal("expected an error with a wrong token")
+	}
+
+	// A failed read is not cached.
+	t.Setenv("VAULT_TOKEN", "test-token")
+	if _, err := fn.Call([]cty.Value{cty.StringVal("kv/app")}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
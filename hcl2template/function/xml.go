@@ -0,0 +1,131 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package function
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// XMLDecodeFunc constructs a function that parses an XML document into an
+// object, keyed by the name of its root element.
+//
+// Elements with only text decode to strings. Other elements decode to
+// objects, with their attributes prefixed by "@", their text, if any, as
+// "#text", and their child elements by name: a tuple when an element has
+// several children of the same name.
+var XMLDecodeFunc = function.New(&function.Spec{
+	Description: "Parses an XML document into an object, keyed by the name of its root element.",
+	Params: []function.Parameter{
+		{
+			Name: "src",
+			Type: cty.String,
+		},
+	},
+	Type:         function.StaticReturnType(cty.DynamicPseudoType),
+	RefineResult: refineNotNull,
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		root, err := parseXML(args[0].AsString())
+		if err != nil {
+			return cty.DynamicVal, fmt.Errorf("failed to parse XML: %s", err)
+		}
+		return cty.ObjectVal(map[string]cty.Value{
+			root.name: root.value(),
+		}), nil
+	},
+})
+
+type xmlElement struct {
+	name     string
+	attrs    []xml.Attr
+	children []*xmlElement
+	text     strings.Builder
+}
+
+func parseXML(src string) (*xmlElement, error) {
+	dec := xml.NewDecoder(strings.NewReader(src))
+
+	var root *xmlElement
+	var stack []*xmlElement
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			elem := &xmlElement{name: tok.Name.Local}
+			for _, attr := range tok.Attr {
+				if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" {
+					continue
+				}
+				elem.attrs = append(elem.attrs, attr)
+			}
+			switch {
+			case len(stack) > 0:
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, elem)
+			case root != nil:
+				line, _ := dec.InputPos()
+				return nil, fmt.Errorf("line %d: a document has a single root element, found a second one, %s", line, elem.name)
+			default:
+				root = elem
+			}
+			stack = append(stack, elem)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(tok)
+			}
+		}
+	}
+	if root == nil {
+		return nil, errors.New("no root element")
+	}
+	return root, nil
+}
+
+func (e *xmlElement) value() cty.Value {
+	text := strings.TrimSpace(e.text.String())
+	if len(e.attrs) == 0 && len(e.children) == 0 {
+		return cty.StringVal(text)
+	}
+
+	attrs := make(map[string]cty.Value, len(e.attrs)+len(e.children))
+	for _, attr := range e.attrs {
+		attrs["@"+attr.Name.Local] = cty.StringVal(attr.Value)
+	}
+
+	var names []string
+	children := map[string][]cty.Value{}
+	for _, child := range e.children {
+		if _, found := children[child.name]; !found {
+			names = append(names, child.name)
+		}
+		children[child.name] = append(children[child.name], child.value())
+	}
+	for _, name := range names {
+		if vals := children[name]; len(vals) == 1 {
+			attrs[name] = vals[0]
+		} else {
+			attrs[name] = cty.TupleVal(vals)
+		}
+	}
+
+	if text != "" {
+		attrs["#text"] = cty.StringVal(text)
+	}
+	return cty.ObjectVal(attrs)
+}
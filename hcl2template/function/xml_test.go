@@ -0,0 +1,96 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package function
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestXMLDecode(t *testing.T) {
+	tests := []struct {
+		Name string
+		Src  string
+		Want cty.Value
+		Err  string
+	}{
+		{
+			"document",
+			`<?xml version="1.0" encoding="UTF-8"?>
+<!-- releases -->
+<releases xmlns="https://example.com/releases" product="ubuntu">
+  <release arch="amd64">
+    <url>https://example.com/amd64.iso</url>
+  </release>
+  <release arch="arm64">
+    <url>https://example.com/arm64.iso</url>
+    <checksum type="sha256">abc123</checksum>
+  </release>
+  <latest>24.04</latest>
+  <notes/>
+</releases>`,
+			cty.ObjectVal(map[string]cty.Value{
+				"releases": cty.ObjectVal(map[string]cty.Value{
+					"@product": cty.StringVal("ubuntu"),
+					"release": cty.TupleVal([]cty.Value{
+						cty.ObjectVal(map[string]cty.Value{
+							"@arch": cty.StringVal("amd64"),
+							"url":   cty.StringVal("https://example.com/amd64.iso"),
+						}),
+						cty.ObjectVal(map[string]cty.Value{
+							"@arch": cty.StringVal("arm64"),
+							"url":   cty.StringVal("https://example.com/arm64.iso"),
+							"checksum": cty.ObjectVal(map[string]cty.Value{
+								"@type": cty.StringVal("sha256"),
+								"#text": cty.StringVal("abc123"),
+							}),
+						}),
+					}),
+					"latest": cty.StringVal("24.04"),
+					"notes":  cty.StringVal(""),
+				}),
+			}),
+			``,
+		},
+		{
+			"empty",
+			``,
+			cty.NilVal,
+			`failed to parse XML: no root element`,
+		},
+		{
+			"two roots",
+			"<a/>\n<b/>",
+			cty.NilVal,
+			`failed to parse XML: line 2: a document has a single root element, found a second one, b`,
+		},
+		{
+			"unclosed",
+			`<a><b></a>`,
+			cty.NilVal,
+			`failed to parse XML`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			got, err := XMLDecodeFunc.Call([]cty.Value{cty.StringVal(test.Src)})
+
+			if test.Err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.Err) {
+					t.Fatalf("wrong error\ngot:  %v\nwant: %s", err, test.Err)
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !got.RawEquals(test.Want) {
+				t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, test.Want)
+			}
+		})
+	}
+}
@@ -59,6 +59,7 @@ func Functions(basedir string) map[string]function.Function {
 		"contains":               stdlib.ContainsFunc,
 		"convert":                typeexpr.ConvertFunc,
 		"csvdecode":              stdlib.CSVDecodeFunc,
+		"dirhash":                pkrfunction.MakeDirHashFunc(basedir),
 		"dirname":                filesystem.DirnameFunc,
 		"distinct":               stdlib.DistinctFunc,
 		"element":                stdlib.ElementFunc,
@@ -66,17 +67,22 @@ func Functions(basedir string) map[string]function.Function {
 		"file":                   filesystem.MakeFileFunc(basedir, false),
 		"filebase64":             pkrfunction.Filebase64,
 		"fileexists":             filesystem.MakeFileExistsFunc(basedir),
+		"filemd5":                pkrfunction.MakeFileMd5Func(basedir),
 		"fileset":                filesystem.MakeFileSetFunc(basedir),
+		"filesha256":             pkrfunction.MakeFileSha256Func(basedir),
+		"filesha512":             pkrfunction.MakeFileSha512Func(basedir),
 		"flatten":                stdlib.FlattenFunc,
 		"floor":                  stdlib.FloorFunc,
 		"format":                 stdlib.FormatFunc,
 		"formatdate":             stdlib.FormatDateFunc,
 		"formatlist":             stdlib.FormatListFunc,
+		"gotemplatefile":         pkrfunction.MakeGoTemplateFileFunc(basedir),
 		"indent":                 stdlib.IndentFunc,
 		"index":                  pkrfunction.IndexFunc, // stdlib.IndexFunc is not compatible
 		"join":                   stdlib.JoinFunc,
 		"jsondecode":             stdlib.JSONDecodeFunc,
 		"jsonencode":             stdlib.JSONEncodeFunc,
+		"jsonpath":               pkrfunction.JSONPathFunc,
 		"keys":                   stdlib.KeysFunc,
 		"legacy_isotime":         pkrfunction.LegacyIsotimeFunc,
 		"legacy_strftime":        pkrfunction.LegacyStrftimeFunc,
@@ -98,6 +104,11 @@ func Functions(basedir string) map[string]function.Function {
 		"regexall":               stdlib.RegexAllFunc,
 		"regex_replace":          stdlib.RegexReplaceFunc,
 		"rsadecrypt":             crypto.RsaDecryptFunc,
+		"semvercompare":          pkrfunction.SemverCompareFunc,
+		"semverconstraint":       pkrfunction.SemverConstraintFunc,
+		"semvermax":              pkrfunction.SemverMaxFunc,
+		"semverparse":            pkrfunction.SemverParseFunc,
+		"semversort":             pkrfunction.SemverSortFunc,
 		"setintersection":        stdlib.SetIntersectionFunc,
 		"setproduct":             stdlib.SetProductFunc,
 		"setunion":               stdlib.SetUnionFunc,
@@ -118,6 +129,8 @@ func Functions(basedir string) map[string]function.Function {
 		"timestamp":              pkrfunction.TimestampFunc,
 		"timeadd":                stdlib.TimeAddFunc,
 		"title":                  stdlib.TitleFunc,
+		"tomldecode":             pkrfunction.TOMLDecodeFunc,
+		"tomlencode":             pkrfunction.TOMLEncodeFunc,
 		"trim":                   stdlib.TrimFunc,
 		"trimprefix":             stdlib.TrimPrefixFunc,
 		"trimspace":              stdlib.TrimSpaceFunc,
@@ -129,6 +142,8 @@ func Functions(basedir string) map[string]function.Function {
 		"uuidv5":                 uuid.V5Func,
 		"values":                 stdlib.ValuesFunc,
 		"vault":                  pkrfunction.VaultFunc,
+		"vault_secret":           pkrfunction.MakeVaultSecretFunc(),
+		"xmldecode":              pkrfunction.XMLDecodeFunc,
 		"yamldecode":             ctyyaml.YAMLDecodeFunc,
 		"yamlencode":             ctyyaml.YAMLEncodeFunc,
 		"zipmap":                 stdlib.ZipmapFunc,
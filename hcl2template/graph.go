@@ -0,0 +1,404 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package hcl2template
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/packer/internal/dag"
+	"github.com/hashicorp/packer/packer"
+)
+
+// GraphFormatVersion is the version of the JSON output of graph. Its minor
+// version is incremented when fields are added, its major version when fields
+// are changed or removed.
+const GraphFormatVersion = "1.0"
+
+// The types of graph that can be output.
+const (
+	GraphTypePrereqs = "prereqs"
+	GraphTypeBuilds  = "builds"
+	GraphTypeFull    = "full"
+)
+
+// The kinds of the nodes of a graph, in the order they are output.
+var graphNodeKinds = []string{
+	"variable",
+	"local",
+	"data",
+	"source",
+	"build",
+	"provisioner",
+	"post-processor",
+}
+
+// dotNodeShapes are the shapes of the nodes of a DOT graph, by kind.
+var dotNodeShapes = map[string]string{
+	"variable":       "ellipse",
+	"local":          "ellipse",
+	"data":           "cylinder",
+	"source":         "component",
+	"build":          "box3d",
+	"provisioner":    "box",
+	"post-processor": "box",
+}
+
+// GraphedConfig is the JSON representation of the graph of a config. Its
+// edges go from a block to the blocks that depend on it, or that run after
+// it.
+type GraphedConfig struct {
+	FormatVersion string        `json:"format_version"`
+	Type          string        `json:"type"`
+	Nodes         []GraphedNode `json:"nodes"`
+	Edges         []GraphedEdge `json:"edges"`
+	// Cycles lists the addresses of the nodes of each dependency cycle.
+	Cycles [][]string `json:"cycles"`
+}
+
+// GraphedNode is the JSON representation of a block in a graph.
+type GraphedNode struct {
+	// Address is the address the block is referenced with, for example
+	// `local.name` or `build.app.provisioner[0].shell`.
+	Address string `json:"address"`
+	Kind    string `json:"kind"`
+	InCycle bool   `json:"in_cycle"`
+}
+
+// GraphedEdge is the JSON representation of an edge in a graph.
+type GraphedEdge struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	InCycle bool   `json:"in_cycle"`
+}
+
+// configGraph builds the graph of a config and of its modules. The vertices of
+// the graph are the addresses of the blocks.
+type configGraph struct {
+	graphType string
+	graph     dag.AcyclicGraph
+	kinds     map[string]string
+	// edges are connected once all of the vertices are added, as modules can
+	// be referenced before they are visited.
+	edges [][2]string
+}
+
+func (g *configGraph) prereqs() bool { return g.graphType != GraphTypeBuilds }
+func (g *configGraph) builds() bool  { return g.graphType != GraphTypePrereqs }
+
+// references tells whether the references of the blocks of the builds are
+// part of the graph.
+func (g *configGraph) references() bool { return g.graphType == GraphTypeFull }
+
+func (g *configGraph) add(address, kind string) {
+	g.graph.Add(address)
+	g.kinds[address] = kind
+}
+
+func (g *configGraph) connect(from, to string) {
+	g.edges = append(g.edges, [2]string{from, to})
+}
+
+// connectReferences connects the variables, locals and datasources referenced
+// in travs, prefixed with prefix, to the block at address to.
+func (g *configGraph) connectReferences(prefix string, travs []hcl.Traversal, to string) {
+	for _, trav := range travs {
+		if ref, ok := graphReference(trav); ok {
+			g.connect(prefix+ref, to)
+		}
+	}
+}
+
+// connectDependencies connects the dependencies deps, prefixed with prefix, to
+// the block at address to.
+func (g *configGraph) connectDependencies(prefix string, deps []refString, to string) {
+	for _, dep := range deps {
+		g.connect(prefix+dep.String(), to)
+	}
+}
+
+// graphReference returns the address of the variable, local or datasource
+// referenced by trav.
+func graphReference(trav hcl.Traversal) (string, bool) {
+	var attrs []string
+	for _, step := range trav[1:] {
+		attr, ok := step.(hcl.TraverseAttr)
+		if !ok {
+			break
+		}
+		attrs = append(attrs, attr.Name)
+	}
+
+	switch root := trav.RootName(); {
+	case (root == "var" || root == "local") && len(attrs) >= 1:
+		return root + "." + attrs[0], true
+	case root == "data" && len(attrs) >= 2:
+		return root + "." + attrs[0] + "." + attrs[1], true
+	}
+	return "", false
+}
+
+// bodyReferences returns the references to variables, locals and datasources
+// in body.
+func bodyReferences(body hcl.Body) []hcl.Traversal {
+	if body == nil {
+		return nil
+	}
+	return GetVarsByType(&hcl.Block{Body: body}, "var", "local", "data")
+}
+
+// sourceUseReferences returns the references in the body of a source usage
+// that are not in the source block it uses: once initialized, the body of a
+// source usage is merged with the one of its source block.
+func sourceUseReferences(cfg *PackerConfig, src SourceUseBlock) []hcl.Traversal {
+	inSource := map[string]bool{}
+	if def, ok := cfg.Sources[src.SourceRef]; ok && def.block != nil {
+		for _, trav := range bodyReferences(def.block.Body) {
+			if ref, ok := graphReference(trav); ok {
+				inSource[ref] = true
+			}
+		}
+	}
+
+	var res []hcl.Traversal
+	for _, trav := range bodyReferences(src.Body) {
+		if ref, ok := graphReference(trav); ok && !inSource[ref] {
+			res = append(res, trav)
+		}
+	}
+	return res
+}
+
+// addConfig adds the blocks of cfg, and of its modules, to the graph.
+func (g *configGraph) addConfig(cfg *PackerConfig) {
+	prefix := ""
+	if cfg.modulePrefix != "" {
+		prefix = cfg.modulePrefix + "."
+	}
+
+	if g.prereqs() {
+		for name := range cfg.InputVariables {
+			g.add(prefix+"var."+name, "variable")
+		}
+		// The dependencies of locals and datasources are the ones detected to
+		// evaluate them.
+		for _, local := range cfg.LocalBlocks {
+			address := prefix + local.Name()
+			g.add(address, "local")
+			g.connectDependencies(prefix, local.variables, address)
+			g.connectDependencies(prefix, local.dependencies, address)
+		}
+		for _, ds := range cfg.Datasources {
+			address := prefix + "data." + ds.Name()
+			g.add(address, "data")
+			g.connectDependencies(prefix, ds.variables, address)
+			g.connectDependencies(prefix, ds.Dependencies, address)
+		}
+		for _, module := range cfg.Modules {
+			for name, input := range module.inputs {
+				g.connectReferences(prefix, input.Expr.Variables(), prefix+module.String()+".var."+name)
+			}
+		}
+	}
+
+	if g.builds() {
+		for ref, src := range cfg.Sources {
+			address := prefix + sourceLabel + "." + ref.String()
+			g.add(address, "source")
+			if g.references() && src.block != nil {
+				g.connectReferences(prefix, bodyReferences(src.block.Body), address)
+			}
+		}
+		for i, build := range cfg.Builds {
+			g.addBuild(cfg, prefix, i, build)
+		}
+	}
+
+	for _, module := range cfg.Modules {
+		if module.Config != nil {
+			g.addConfig(module.Config)
+		}
+	}
+}
+
+// addBuild adds a build block to the graph, followed by its provisioners, in
+// order, and by its post-processors chains.
+func (g *configGraph) addBuild(cfg *PackerConfig, prefix string, index int, build *BuildBlock) {
+	address := prefix + buildLabel + "." + build.Name
+	if build.Name == "" {
+		address = fmt.Sprintf("%s%s[%d]", prefix, buildLabel, index)
+	}
+	g.add(address, "build")
+
+	for _, src := range build.Sources {
+		g.connect(prefix+sourceLabel+"."+src.SourceRef.String(), address)
+		if g.references() {
+			g.connectReferences(prefix, sourceUseReferences(cfg, src), address)
+		}
+	}
+
+	previous := address
+	for i, pb := range build.ProvisionerBlocks {
+		pAddress := fmt.Sprintf("%s.%s[%d].%s", address, buildProvisionerLabel, i, pb.PType)
+		g.add(pAddress, "provisioner")
+		g.connect(previous, pAddress)
+		if g.references() {
+			g.connectReferences(prefix, bodyReferences(pb.Rest), pAddress)
+		}
+		previous = pAddress
+	}
+
+	if pb := build.ErrorCleanupProvisionerBlock; pb != nil {
+		pAddress := fmt.Sprintf("%s.%s.%s", address, buildErrorCleanupProvisionerLabel, pb.PType)
+		g.add(pAddress, "provisioner")
+		g.connect(address, pAddress)
+		if g.references() {
+			g.connectReferences(prefix, bodyReferences(pb.Rest), pAddress)
+		}
+	}
+
+	for i, chain := range build.PostProcessorsLists {
+		chainPrevious := previous
+		for j, ppb := range chain {
+			ppAddress := fmt.Sprintf("%s.%s[%d][%d].%s", address, buildPostProcessorsLabel, i, j, ppb.PType)
+			g.add(ppAddress, "post-processor")
+			g.connect(chainPrevious, ppAddress)
+			if g.references() {
+				g.connectReferences(prefix, bodyReferences(ppb.Rest), ppAddress)
+			}
+			chainPrevious = ppAddress
+		}
+	}
+}
+
+// Graph returns the graph of the config and of its modules, with the blocks
+// selected by graphType, one of GraphTypePrereqs, GraphTypeBuilds or
+// GraphTypeFull. The dependency cycles of the graph are reported instead of
+// failing.
+func (cfg *PackerConfig) Graph(graphType string) GraphedConfig {
+	g := &configGraph{
+		graphType: graphType,
+		kinds:     map[string]string{},
+	}
+	g.addConfig(cfg)
+	for _, edge := range g.edges {
+		// References to undeclared blocks are reported by validate.
+		if g.graph.HasVertex(edge[0]) && g.graph.HasVertex(edge[1]) {
+			g.graph.Connect(dag.BasicEdge(edge[0], edge[1]))
+		}
+	}
+
+	res := GraphedConfig{
+		FormatVersion: GraphFormatVersion,
+		Type:          graphType,
+		Nodes:         []GraphedNode{},
+		Edges:         []GraphedEdge{},
+		Cycles:        [][]string{},
+	}
+
+	// cycleOf is the index of the cycle each node is part of.
+	cycleOf := map[string]int{}
+	for _, cycle := range g.graph.Cycles() {
+		addresses := make([]string, 0, len(cycle))
+		for _, v := range cycle {
+			addresses = append(addresses, dag.VertexName(v))
+		}
+		sort.Strings(addresses)
+		res.Cycles = append(res.Cycles, addresses)
+	}
+	for _, edge := range g.graph.Edges() {
+		// A block referencing itself is a cycle too.
+		if edge.Source() == edge.Target() {
+			res.Cycles = append(res.Cycles, []string{dag.VertexName(edge.Source())})
+		}
+	}
+	sort.Slice(res.Cycles, func(i, j int) bool { return res.Cycles[i][0] < res.Cycles[j][0] })
+	for i, cycle := range res.Cycles {
+		for _, address := range cycle {
+			cycleOf[address] = i
+		}
+	}
+
+	for _, v := range g.graph.Vertices() {
+		address := dag.VertexName(v)
+		_, inCycle := cycleOf[address]
+		res.Nodes = append(res.Nodes, GraphedNode{
+			Address: address,
+			Kind:    g.kinds[address],
+			InCycle: inCycle,
+		})
+	}
+	kindOrder := map[string]int{}
+	for i, kind := range graphNodeKinds {
+		kindOrder[kind] = i
+	}
+	sort.Slice(res.Nodes, func(i, j int) bool {
+		if ki, kj := kindOrder[res.Nodes[i].Kind], kindOrder[res.Nodes[j].Kind]; ki != kj {
+			return ki < kj
+		}
+		return res.Nodes[i].Address < res.Nodes[j].Address
+	})
+
+	for _, edge := range g.graph.Edges() {
+		from, to := dag.VertexName(edge.Source()), dag.VertexName(edge.Target())
+		fromCycle, fromInCycle := cycleOf[from]
+		toCycle, toInCycle := cycleOf[to]
+		res.Edges = append(res.Edges, GraphedEdge{
+			From:    from,
+			To:      to,
+			InCycle: fromInCycle && toInCycle && fromCycle == toCycle,
+		})
+	}
+	sort.Slice(res.Edges, func(i, j int) bool {
+		if res.Edges[i].From != res.Edges[j].From {
+			return res.Edges[i].From < res.Edges[j].From
+		}
+		return res.Edges[i].To < res.Edges[j].To
+	})
+
+	return res
+}
+
+// DOT returns the graph in the DOT language of Graphviz. The nodes and edges
+// of dependency cycles are red.
+func (g GraphedConfig) DOT() string {
+	out := &strings.Builder{}
+	out.WriteString("digraph {\n")
+	out.WriteString("\trankdir = \"LR\"\n")
+	if len(g.Nodes) > 0 {
+		out.WriteString("\n")
+	}
+	for _, node := range g.Nodes {
+		fmt.Fprintf(out, "\t%q [shape = %q", node.Address, dotNodeShapes[node.Kind])
+		if node.InCycle {
+			out.WriteString(", color = \"red\"")
+		}
+		out.WriteString("]\n")
+	}
+	if len(g.Edges) > 0 {
+		out.WriteString("\n")
+	}
+	for _, edge := range g.Edges {
+		fmt.Fprintf(out, "\t%q -> %q", edge.From, edge.To)
+		if edge.InCycle {
+			out.WriteString(" [color = \"red\"]")
+		}
+		out.WriteString("\n")
+	}
+	out.WriteString("}")
+	return out.String()
+}
+
+var _ packer.ConfigGrapher = new(PackerConfig)
+
+func (p *PackerConfig) GraphConfig(opts packer.GraphConfigOptions) int {
+	graph := p.Graph(opts.Type)
+	if opts.JSON {
+		return sayJSON(opts.Ui, graph)
+	}
+	opts.Ui.Say(graph.DOT())
+	return 0
+}
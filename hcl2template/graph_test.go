@@ -0,0 +1,89 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package hcl2template
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/packer/packer"
+)
+
+func TestPackerConfig_Graph(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		graphType  string
+		wantEdges  []GraphedEdge
+		wantCycles [][]string
+	}{
+		{
+			name:      "full",
+			path:      "testdata/graph",
+			graphType: GraphTypeFull,
+			wantEdges: []GraphedEdge{
+				{From: "build[0]", To: "build[0].error-cleanup-provisioner.shell"},
+				{From: "build[0]", To: "build[0].provisioner[0].shell"},
+				{From: "build[0].post-processors[1][0].amazon-import", To: "build[0].post-processors[1][1].manifest"},
+				{From: "build[0].provisioner[0].shell", To: "build[0].provisioner[1].file"},
+				{From: "build[0].provisioner[1].file", To: "build[0].post-processors[0][0].manifest"},
+				{From: "build[0].provisioner[1].file", To: "build[0].post-processors[1][0].amazon-import"},
+				{From: "data.amazon-ami.base", To: "build[0]"},
+				{From: "local.loop", To: "build[0].post-processors[1][1].manifest"},
+				{From: "local.loop", To: "local.loop", InCycle: true},
+				{From: "source.amazon-ebs.web", To: "build[0]"},
+				{From: "var.region", To: "build[0].provisioner[0].shell"},
+				{From: "var.region", To: "data.amazon-ami.base"},
+				{From: "var.region", To: "source.amazon-ebs.web"},
+			},
+			wantCycles: [][]string{{"local.loop"}},
+		},
+		{
+			name:      "prereqs",
+			path:      "testdata/graph",
+			graphType: GraphTypePrereqs,
+			wantEdges: []GraphedEdge{
+				{From: "local.loop", To: "local.loop", InCycle: true},
+				{From: "var.region", To: "data.amazon-ami.base"},
+			},
+			wantCycles: [][]string{{"local.loop"}},
+		},
+		{
+			name:      "modules",
+			path:      "testdata/modules/basic",
+			graphType: GraphTypeFull,
+			wantEdges: []GraphedEdge{
+				{From: "module.base.source.amazon-ebs.ubuntu-1604", To: "module.base.build.x"},
+				{From: "module.base.var.image_name", To: "module.base.source.amazon-ebs.ubuntu-1604"},
+				{From: "source.virtualbox-iso.ubuntu-1204", To: "build[0]"},
+				{From: "var.version", To: "module.base.var.image_name"},
+			},
+			wantCycles: [][]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, diags := getBasicParser().Parse(tt.path, nil, nil)
+			if diags.HasErrors() {
+				t.Fatalf("Parse: %s", diags)
+			}
+			// The cycle makes the initialization fail, the graph is still
+			// expected to be complete.
+			_ = cfg.Initialize(packer.InitializeOptions{SkipDatasourcesExecution: true})
+
+			graph := cfg.Graph(tt.graphType)
+			if diff := cmp.Diff(tt.wantEdges, graph.Edges); diff != "" {
+				t.Errorf("unexpected edges: %s", diff)
+			}
+			if diff := cmp.Diff(tt.wantCycles, graph.Cycles); diff != "" {
+				t.Errorf("unexpected cycles: %s", diff)
+			}
+			for _, node := range graph.Nodes {
+				if node.Kind == "" {
+					t.Errorf("node %q has no kind", node.Address)
+				}
+			}
+		})
+	}
+}
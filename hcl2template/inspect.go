@@ -0,0 +1,437 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package hcl2template
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+const sensitiveValue = "<sensitive>"
+
+// VariableSources lists the assignments of an input variable, by ascending
+// precedence: the value of the last one is the value of the variable.
+type VariableSources struct {
+	Name      string           `json:"name"`
+	Sensitive bool             `json:"sensitive"`
+	Value     json.RawMessage  `json:"value"`
+	Sources   []VariableSource `json:"sources"`
+}
+
+// VariableSource is an assignment of an input variable.
+type VariableSource struct {
+	// From is where the value was set: default, env, auto_varfile,
+	// varfile, cmd or module.
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+	// Filename and Line locate the assignment, they are not set for values
+	// from the environment or the command line.
+	Filename string `json:"filename,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	// Env is the environment variable the value was read from.
+	Env string `json:"env,omitempty"`
+
+	value cty.Value
+}
+
+// location returns where the value was set, to be displayed.
+func (s VariableSource) location() string {
+	switch {
+	case s.Env != "":
+		return s.Env
+	case s.Filename != "":
+		return fmt.Sprintf("%s:%d", s.Filename, s.Line)
+	case s.From == "cmd":
+		return "-var"
+	}
+	return ""
+}
+
+// VariableSources returns the assignments of the input variables of the
+// config, sorted by variable name. The values of sensitive variables are
+// redacted.
+func (cfg *PackerConfig) VariableSources() []VariableSources {
+	keys := cfg.InputVariables.Keys()
+	sort.Strings(keys)
+
+	res := make([]VariableSources, 0, len(keys))
+	for _, key := range keys {
+		v := cfg.InputVariables[key]
+		sources := VariableSources{
+			Name:      v.Name,
+			Sensitive: v.Sensitive,
+			Value:     jsonVariableValue(v.Value(), v.Sensitive),
+			Sources:   []VariableSource{},
+		}
+		for _, assignment := range v.Values {
+			source := VariableSource{
+				From:  assignment.From,
+				Value: jsonVariableValue(assignment.Value, v.Sensitive),
+				value: assignment.Value,
+			}
+			switch assignment.From {
+			case "env":
+				source.Env = VarEnvPrefix + v.Name
+			case "cmd":
+			default:
+				if assignment.Expr == nil {
+					break
+				}
+				rng := assignment.Expr.Range()
+				source.Filename = rng.Filename
+				source.Line = rng.Start.Line
+				if assignment.From == "varfile" &&
+					(strings.HasSuffix(rng.Filename, hcl2AutoVarFileExt) ||
+						strings.HasSuffix(rng.Filename, hcl2AutoVarJsonFileExt)) {
+					source.From = "auto_varfile"
+				}
+			}
+			sources.Sources = append(sources.Sources, source)
+		}
+		res = append(res, sources)
+	}
+	return res
+}
+
+// jsonVariableValue returns the JSON encoding of val. Sensitive values are
+// redacted and unknown values are null.
+func jsonVariableValue(val cty.Value, sensitive bool) json.RawMessage {
+	switch {
+	case sensitive || val.ContainsMarked():
+		return json.RawMessage(`"` + sensitiveValue + `"`)
+	case !val.IsWhollyKnown():
+		return json.RawMessage("null")
+	}
+	b, err := ctyjson.Marshal(val, val.Type())
+	if err != nil {
+		return json.RawMessage("null")
+	}
+	return b
+}
+
+func (cfg *PackerConfig) printVariableSources() string {
+	out := &strings.Builder{}
+	out.WriteString("> input-variables sources, by ascending precedence:\n")
+	for _, v := range cfg.VariableSources() {
+		value := sensitiveValue
+		if !v.Sensitive {
+			value = PrintableCtyValue(cfg.InputVariables[v.Name].Value())
+		}
+		fmt.Fprintf(out, "\nvar.%s: %q\n", v.Name, value)
+		if len(v.Sources) == 0 {
+			out.WriteString("  <no value>\n")
+		}
+		for i, source := range v.Sources {
+			value := sensitiveValue
+			if !v.Sensitive {
+				value = PrintableCtyValue(source.value)
+			}
+			fmt.Fprintf(out, "  %d. %s: %q", i+1, source.From, value)
+			if location := source.location(); location != "" {
+				fmt.Fprintf(out, " (%s)", location)
+			}
+			out.WriteString("\n")
+		}
+	}
+	return out.String()
+}
+
+// inspectVariableSources outputs the sources of the input variables, as text
+// or as JSON.
+func (cfg *PackerConfig) inspectVariableSources(ui packersdk.Ui, asJSON bool) int {
+	if !asJSON {
+		ui.Say(cfg.printVariableSources())
+		return 0
+	}
+
+	return sayJSON(ui, struct {
+		FormatVersion string            `json:"format_version"`
+		Variables     []VariableSources `json:"variables"`
+	}{
+		FormatVersion: InspectFormatVersion,
+		Variables:     cfg.VariableSources(),
+	})
+}
+
+// sayJSON outputs v as indented JSON.
+func sayJSON(ui packersdk.Ui, v interface{}) int {
+	out := &strings.Builder{}
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		ui.Error(fmt.Sprintf("Failed to encode the inspection: %s", err))
+		return 1
+	}
+	ui.Say(strings.TrimSpace(out.String()))
+	return 0
+}
+
+// InspectFormatVersion is the version of the JSON output of inspect. Its
+// minor version is incremented when fields are added, its major version when
+// fields are changed or removed.
+const InspectFormatVersion = "1.0"
+
+// InspectedConfig is the JSON representation of a config.
+type InspectedConfig struct {
+	FormatVersion     string                    `json:"format_version"`
+	RequiredVersion   []string                  `json:"required_version"`
+	RequiredPlugins   []InspectedRequiredPlugin `json:"required_plugins"`
+	Variables         []InspectedVariable       `json:"variables"`
+	Locals            []InspectedLocal          `json:"locals"`
+	Datasources       []InspectedBlock          `json:"data_sources"`
+	Sources           []InspectedBlock          `json:"sources"`
+	Builds            []InspectedBuild          `json:"builds"`
+	HCPPackerRegistry *InspectedHCPRegistry     `json:"hcp_packer_registry,omitempty"`
+}
+
+// InspectedRange is the JSON representation of an hcl.Range.
+type InspectedRange struct {
+	Filename string       `json:"filename"`
+	Start    InspectedPos `json:"start"`
+	End      InspectedPos `json:"end"`
+}
+
+// InspectedPos is the JSON representation of an hcl.Pos.
+type InspectedPos struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+func inspectedRange(rng hcl.Range) InspectedRange {
+	return InspectedRange{
+		Filename: rng.Filename,
+		Start:    InspectedPos{Line: rng.Start.Line, Column: rng.Start.Column},
+		End:      InspectedPos{Line: rng.End.Line, Column: rng.End.Column},
+	}
+}
+
+// InspectedRequiredPlugin is the JSON representation of a required plugin.
+type InspectedRequiredPlugin struct {
+	Name    string         `json:"name"`
+	Source  string         `json:"source"`
+	Version string         `json:"version"`
+	Range   InspectedRange `json:"range"`
+}
+
+// InspectedVariable is the JSON representation of an input variable.
+type InspectedVariable struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+	Sensitive   bool   `json:"sensitive"`
+	// Default is not set when the variable has no default value.
+	Default json.RawMessage `json:"default,omitempty"`
+	Range   InspectedRange  `json:"range"`
+}
+
+// InspectedLocal is the JSON representation of a local variable.
+type InspectedLocal struct {
+	Name      string `json:"name"`
+	Sensitive bool   `json:"sensitive"`
+	// Value is null when the local could not be evaluated.
+	Value json.RawMessage `json:"value"`
+	Range InspectedRange  `json:"range"`
+}
+
+// InspectedBlock is the JSON representation of a block with a type and a
+// name, like a source or a provisioner.
+type InspectedBlock struct {
+	Type  string         `json:"type"`
+	Name  string         `json:"name,omitempty"`
+	Range InspectedRange `json:"range"`
+}
+
+// InspectedBuild is the JSON representation of a build block, with its
+// provisioners and its post-processor chains.
+type InspectedBuild struct {
+	Name                    string                `json:"name,omitempty"`
+	Description             string                `json:"description,omitempty"`
+	Sources                 []string              `json:"sources"`
+	Provisioners            []InspectedBlock      `json:"provisioners"`
+	ErrorCleanupProvisioner *InspectedBlock       `json:"error_cleanup_provisioner,omitempty"`
+	PostProcessors          [][]InspectedBlock    `json:"post_processors"`
+	HCPPackerRegistry       *InspectedHCPRegistry `json:"hcp_packer_registry,omitempty"`
+	Range                   InspectedRange        `json:"range"`
+}
+
+// InspectedHCPRegistry is the JSON representation of an hcp_packer_registry
+// block.
+type InspectedHCPRegistry struct {
+	BucketName   string            `json:"bucket_name,omitempty"`
+	Description  string            `json:"description,omitempty"`
+	BucketLabels map[string]string `json:"bucket_labels,omitempty"`
+	BuildLabels  map[string]string `json:"build_labels,omitempty"`
+	Range        InspectedRange    `json:"range"`
+}
+
+func inspectedHCPRegistry(reg *HCPPackerRegistryBlock) *InspectedHCPRegistry {
+	if reg == nil {
+		return nil
+	}
+	return &InspectedHCPRegistry{
+		BucketName:   reg.Slug,
+		Description:  reg.Description,
+		BucketLabels: reg.BucketLabels,
+		BuildLabels:  reg.BuildLabels,
+		Range:        inspectedRange(reg.DefRange),
+	}
+}
+
+// Inspect returns the JSON representation of the config. The values of the
+// sensitive variables and locals are redacted.
+func (cfg *PackerConfig) Inspect() InspectedConfig {
+	res := InspectedConfig{
+		FormatVersion:   InspectFormatVersion,
+		RequiredVersion: []string{},
+		RequiredPlugins: []InspectedRequiredPlugin{},
+		Variables:       []InspectedVariable{},
+		Locals:          []InspectedLocal{},
+		Datasources:     []InspectedBlock{},
+		Sources:         []InspectedBlock{},
+		Builds:          []InspectedBuild{},
+
+		HCPPackerRegistry: inspectedHCPRegistry(cfg.HCPPackerRegistry),
+	}
+
+	for _, constraint := range cfg.Packer.VersionConstraints {
+		res.RequiredVersion = append(res.RequiredVersion, constraint.Required.String())
+	}
+
+	for _, block := range cfg.Packer.RequiredPlugins {
+		names := make([]string, 0, len(block.RequiredPlugins))
+		for name := range block.RequiredPlugins {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			plugin := block.RequiredPlugins[name]
+			inspected := InspectedRequiredPlugin{
+				Name:    plugin.Name,
+				Source:  plugin.Source,
+				Version: plugin.Requirement.Required.String(),
+				Range:   inspectedRange(plugin.DeclRange),
+			}
+			if plugin.Type != nil {
+				inspected.Source = plugin.Type.String()
+			}
+			res.RequiredPlugins = append(res.RequiredPlugins, inspected)
+		}
+	}
+
+	keys := cfg.InputVariables.Keys()
+	sort.Strings(keys)
+	for _, key := range keys {
+		v := cfg.InputVariables[key]
+		inspected := InspectedVariable{
+			Name:        v.Name,
+			Type:        "any",
+			Description: v.Description,
+			Sensitive:   v.Sensitive,
+			Range:       inspectedRange(v.Range),
+		}
+		if v.Type != cty.NilType && v.Type != cty.DynamicPseudoType {
+			inspected.Type = typeexpr.TypeString(v.Type)
+		}
+		for _, assignment := range v.Values {
+			if assignment.From == "default" {
+				inspected.Default = jsonVariableValue(assignment.Value, v.Sensitive)
+			}
+		}
+		res.Variables = append(res.Variables, inspected)
+	}
+
+	locals := append([]*LocalBlock{}, cfg.LocalBlocks...)
+	sort.Slice(locals, func(i, j int) bool {
+		return locals[i].LocalName < locals[j].LocalName
+	})
+	for _, local := range locals {
+		value := json.RawMessage("null")
+		if v, found := cfg.LocalVariables[local.LocalName]; found {
+			value = jsonVariableValue(v.Value(), local.Sensitive)
+		}
+		res.Locals = append(res.Locals, InspectedLocal{
+			Name:      local.LocalName,
+			Sensitive: local.Sensitive,
+			Value:     value,
+			Range:     inspectedRange(local.Expr.Range()),
+		})
+	}
+
+	for _, ds := range cfg.Datasources {
+		res.Datasources = append(res.Datasources, InspectedBlock{
+			Type:  ds.Type,
+			Name:  ds.DSName,
+			Range: inspectedRange(ds.block.DefRange),
+		})
+	}
+	sort.Slice(res.Datasources, func(i, j int) bool {
+		return res.Datasources[i].Type+"."+res.Datasources[i].Name <
+			res.Datasources[j].Type+"."+res.Datasources[j].Name
+	})
+
+	for ref, source := range cfg.Sources {
+		res.Sources = append(res.Sources, InspectedBlock{
+			Type:  ref.Type,
+			Name:  ref.Name,
+			Range: inspectedRange(source.block.DefRange),
+		})
+	}
+	sort.Slice(res.Sources, func(i, j int) bool {
+		return res.Sources[i].Type+"."+res.Sources[i].Name <
+			res.Sources[j].Type+"."+res.Sources[j].Name
+	})
+
+	for _, named := range cfg.AllBuilds() {
+		build := named.Build
+		inspected := InspectedBuild{
+			Name:              named.Name,
+			Description:       build.Description,
+			Sources:           []string{},
+			Provisioners:      []InspectedBlock{},
+			PostProcessors:    [][]InspectedBlock{},
+			HCPPackerRegistry: inspectedHCPRegistry(build.HCPPackerRegistry),
+			Range:             inspectedRange(build.HCL2Ref.DefRange),
+		}
+		for _, source := range build.Sources {
+			inspected.Sources = append(inspected.Sources, source.String())
+		}
+		for _, prov := range build.ProvisionerBlocks {
+			inspected.Provisioners = append(inspected.Provisioners, InspectedBlock{
+				Type:  prov.PType,
+				Name:  prov.PName,
+				Range: inspectedRange(prov.DefRange),
+			})
+		}
+		if prov := build.ErrorCleanupProvisionerBlock; prov != nil {
+			inspected.ErrorCleanupProvisioner = &InspectedBlock{
+				Type:  prov.PType,
+				Name:  prov.PName,
+				Range: inspectedRange(prov.DefRange),
+			}
+		}
+		for _, ppList := range build.PostProcessorsLists {
+			list := []InspectedBlock{}
+			for _, pp := range ppList {
+				list = append(list, InspectedBlock{
+					Type:  pp.PType,
+					Name:  pp.PName,
+					Range: inspectedRange(pp.DefRange),
+				})
+			}
+			inspected.PostProcessors = append(inspected.PostProcessors, list)
+		}
+		res.Builds = append(res.Builds, inspected)
+	}
+
+	return res
+}
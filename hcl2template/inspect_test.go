@@ -0,0 +1,47 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package hcl2template
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hashicorp/packer/packer"
+)
+
+func TestPackerConfig_Inspect(t *testing.T) {
+	cfg, diags := getBasicParser().Parse("testdata/datasources/basic.pkr.hcl", nil, nil)
+	if diags.HasErrors() {
+		t.Fatalf("Parse: %s", diags)
+	}
+	if diags := cfg.Initialize(packer.InitializeOptions{}); diags.HasErrors() {
+		t.Fatalf("Initialize: %s", diags)
+	}
+
+	inspected := cfg.Inspect()
+	if inspected.FormatVersion != InspectFormatVersion {
+		t.Errorf("unexpected format version %q", inspected.FormatVersion)
+	}
+	if len(inspected.Datasources) != 1 ||
+		inspected.Datasources[0].Type != "amazon-ami" ||
+		inspected.Datasources[0].Name != "test" ||
+		inspected.Datasources[0].Range.Filename != "testdata/datasources/basic.pkr.hcl" ||
+		inspected.Datasources[0].Range.Start.Line != 1 {
+		t.Errorf("unexpected data sources: %#v", inspected.Datasources)
+	}
+
+	b, err := json.Marshal(inspected)
+	if err != nil {
+		t.Fatalf("Marshal: %s", err)
+	}
+	var res map[string]interface{}
+	if err := json.Unmarshal(b, &res); err != nil {
+		t.Fatalf("Unmarshal: %s", err)
+	}
+	for _, key := range []string{"required_plugins", "variables", "locals", "sources", "builds"} {
+		if _, ok := res[key].([]interface{}); !ok {
+			t.Errorf("%s should be an empty list, got %#v", key, res[key])
+		}
+	}
+}
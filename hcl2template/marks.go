@@ -0,0 +1,150 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package hcl2template
+
+import (
+	"github.com/hashicorp/hcl/v2"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// valueMark is the type of the cty marks set by Packer on values.
+type valueMark string
+
+// SensitiveMark marks a value as sensitive. Values coming from a sensitive
+// variable or local carry this mark, and cty propagates it through every
+// operation and function call, so anything derived from a sensitive value is
+// sensitive too.
+const SensitiveMark = valueMark("sensitive")
+
+// sensitiveValueDisplay is shown instead of a value that is sensitive.
+const sensitiveValueDisplay = "(sensitive value)"
+
+// markSensitive returns val marked as sensitive.
+func markSensitive(val cty.Value) cty.Value {
+	return val.Mark(SensitiveMark)
+}
+
+// filterSensitiveFromLogs registers every known string of val that is, or is
+// nested in, a value marked as sensitive in the log secret filter.
+func filterSensitiveFromLogs(val cty.Value) {
+	if !val.ContainsMarked() {
+		return
+	}
+	unmarked, pvm := val.UnmarkDeepWithPaths()
+	for _, pm := range pvm {
+		if _, ok := pm.Marks[SensitiveMark]; !ok {
+			continue
+		}
+		sensitive, err := pm.Path.Apply(unmarked)
+		if err != nil {
+			continue
+		}
+		filterFromLogs(sensitive)
+	}
+}
+
+// filterFromLogs registers every known string of val in the log secret
+// filter.
+func filterFromLogs(val cty.Value) {
+	// The value of a sensitive variable or local can be marked.
+	val, _ = val.UnmarkDeep()
+	_ = cty.Walk(val, func(_ cty.Path, nested cty.Value) (bool, error) {
+		if nested.IsWhollyKnown() && !nested.IsNull() && nested.Type().Equals(cty.String) {
+			packersdk.LogSecretFilter.Set(nested.AsString())
+		}
+		return true, nil
+	})
+}
+
+// unmarkSensitive returns val without marks, after having registered its
+// sensitive parts in the log secret filter. It is to be used on values leaving
+// HCL, like the configuration sent to plugins.
+func unmarkSensitive(val cty.Value) cty.Value {
+	filterSensitiveFromLogs(val)
+	unmarked, _ := val.UnmarkDeep()
+	return unmarked
+}
+
+// unmarkedEvalContext returns a copy of ectx, and of its parents, in which
+// variables are unmarked. gohcl cannot decode marked values, so this is the
+// context to pass it.
+func unmarkedEvalContext(ectx *hcl.EvalContext) *hcl.EvalContext {
+	if ectx == nil {
+		return nil
+	}
+	var res *hcl.EvalContext
+	if parent := ectx.Parent(); parent != nil {
+		res = unmarkedEvalContext(parent).NewChild()
+	} else {
+		res = &hcl.EvalContext{}
+	}
+	res.Functions = ectx.Functions
+	if ectx.Variables != nil {
+		res.Variables = make(map[string]cty.Value, len(ectx.Variables))
+		for k, v := range ectx.Variables {
+			res.Variables[k] = unmarkSensitive(v)
+		}
+	}
+	return res
+}
+
+// RedactSensitiveDiagnostics returns a copy of diags in which the sensitive
+// values of the evaluation contexts are replaced with unknown values, so that
+// diagnostics writers, which ignore unknown values, don't print them.
+func RedactSensitiveDiagnostics(diags hcl.Diagnostics) hcl.Diagnostics {
+	res := make(hcl.Diagnostics, 0, len(diags))
+	for _, diag := range diags {
+		if diag.EvalContext != nil {
+			redacted := *diag
+			redacted.EvalContext = redactedEvalContext(diag.EvalContext)
+			diag = &redacted
+		}
+		res = append(res, diag)
+	}
+	return res
+}
+
+// redactedEvalContext returns a copy of ectx, and of its parents, in which the
+// sensitive values are replaced with unknown values.
+func redactedEvalContext(ectx *hcl.EvalContext) *hcl.EvalContext {
+	var res *hcl.EvalContext
+	if parent := ectx.Parent(); parent != nil {
+		res = redactedEvalContext(parent).NewChild()
+	} else {
+		res = &hcl.EvalContext{}
+	}
+	res.Functions = ectx.Functions
+	if ectx.Variables != nil {
+		res.Variables = make(map[string]cty.Value, len(ectx.Variables))
+		for k, v := range ectx.Variables {
+			res.Variables[k] = redactSensitive(v)
+		}
+	}
+	return res
+}
+
+// redactSensitive returns val without marks, in which the values marked as
+// sensitive are replaced with unknown values.
+func redactSensitive(val cty.Value) cty.Value {
+	if !val.ContainsMarked() {
+		return val
+	}
+	unmarked, pvm := val.UnmarkDeepWithPaths()
+	var sensitivePaths []cty.Path
+	for _, pm := range pvm {
+		if _, ok := pm.Marks[SensitiveMark]; ok {
+			sensitivePaths = append(sensitivePaths, pm.Path)
+		}
+	}
+	res, _ := cty.Transform(unmarked, func(p cty.Path, v cty.Value) (cty.Value, error) {
+		for _, sp := range sensitivePaths {
+			if p.Equals(sp) {
+				return cty.UnknownVal(v.Type()), nil
+			}
+		}
+		return v, nil
+	})
+	return res
+}
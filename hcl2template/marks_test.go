@@ -0,0 +1,127 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package hcl2template
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer/packer"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestSensitiveMarks_propagation(t *testing.T) {
+	cfg, diags := getBasicParser().Parse("testdata/sensitive", nil, nil)
+	if diags.HasErrors() {
+		t.Fatalf("Parse: %s", diags)
+	}
+	if diags := cfg.Initialize(packer.InitializeOptions{}); diags.HasErrors() {
+		t.Fatalf("Initialize: %s", diags)
+	}
+
+	locals := cfg.LocalVariables.Values()
+	for name, want := range map[string]bool{
+		"formatted": true,
+		"joined":    true,
+		"encoded":   true,
+		"public":    false,
+	} {
+		if got := locals[name].HasMark(SensitiveMark); got != want {
+			t.Errorf("local.%s sensitive = %t, want %t", name, got, want)
+		}
+	}
+
+	for expr, want := range map[string]string{
+		"var.password":  sensitiveValueDisplay,
+		"local.encoded": sensitiveValueDisplay,
+		"local.public":  "ADMIN",
+	} {
+		got, _, diags := cfg.handleEval(expr, NilContext, nil)
+		if diags.HasErrors() {
+			t.Fatalf("handleEval(%q): %s", expr, diags)
+		}
+		if got != want {
+			t.Errorf("handleEval(%q) = %s, want %s", expr, got, want)
+		}
+	}
+
+	// Plugins receive unmarked values, the derived ones being filtered out
+	// of the logs.
+	if _, diags := cfg.GetBuilds(packer.GetBuildsOptions{}); diags.HasErrors() {
+		t.Fatalf("GetBuilds: %s", diags)
+	}
+	encoded := base64.StdEncoding.EncodeToString([]byte("s3cr3t-p4ssw0rd"))
+	for _, secret := range []string{encoded, "admin:s3cr3t-p4ssw0rd", "admin,s3cr3t-p4ssw0rd"} {
+		if got := packersdk.LogSecretFilter.FilterString(secret); got == secret || strings.Contains(got, "s3cr3t-p4ssw0rd") {
+			t.Errorf("%q is not filtered out of the logs, got %q", secret, got)
+		}
+	}
+	if got := packersdk.LogSecretFilter.FilterString("ADMIN"); got != "ADMIN" {
+		t.Errorf("non sensitive value filtered out of the logs, got %q", got)
+	}
+}
+
+func TestRedactSensitiveDiagnostics(t *testing.T) {
+	ectx := &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"var": cty.ObjectVal(map[string]cty.Value{
+				"public": cty.StringVal("public"),
+				"secret": markSensitive(cty.StringVal("secret")),
+			}),
+		},
+	}
+	diags := RedactSensitiveDiagnostics(hcl.Diagnostics{
+		{Severity: hcl.DiagError, Summary: "no context"},
+		{Severity: hcl.DiagError, Summary: "with context", EvalContext: ectx},
+	})
+
+	if diags[0].EvalContext != nil {
+		t.Fatalf("unexpected eval context: %#v", diags[0].EvalContext)
+	}
+	got := diags[1].EvalContext.Variables["var"]
+	want := cty.ObjectVal(map[string]cty.Value{
+		"public": cty.StringVal("public"),
+		"secret": cty.UnknownVal(cty.String),
+	})
+	if !got.RawEquals(want) {
+		t.Fatalf("unexpected redacted variables: got %#v, want %#v", got, want)
+	}
+	if !ectx.Variables["var"].ContainsMarked() {
+		t.Fatal("the original eval context was modified")
+	}
+}
+
+func TestSensitiveMarks_dynamicBlocksAndModules(t *testing.T) {
+	cfg, diags := getBasicParser().Parse("testdata/sensitive/dynamic", nil, nil)
+	if diags.HasErrors() {
+		t.Fatalf("Parse: %s", diags)
+	}
+	if diags := cfg.Initialize(packer.InitializeOptions{}); diags.HasErrors() {
+		t.Fatalf("Initialize: %s", diags)
+	}
+
+	// A sensitive local derived from a sensitive variable is filtered out of
+	// the logs.
+	if got := packersdk.LogSecretFilter.FilterString("s3cr3t-p4ssw0rd-suffixed"); strings.Contains(got, "s3cr3t-p4ssw0rd") {
+		t.Errorf("the sensitive local is not filtered out of the logs, got %q", got)
+	}
+
+	// Dynamic blocks can iterate over sensitive values.
+	if got := len(cfg.Builds[0].ProvisionerBlocks); got != 2 {
+		t.Fatalf("expected 2 dynamic provisioners, got %d", got)
+	}
+
+	// The variable of a module set from a sensitive value is sensitive, and
+	// so are the values derived from it.
+	child := cfg.Modules["child"].Config
+	if !child.InputVariables["password"].Sensitive {
+		t.Error("the module variable set from a sensitive value is not sensitive")
+	}
+	if !child.LocalVariables.Values()["upper"].HasMark(SensitiveMark) {
+		t.Error("the local derived from a sensitive module variable is not sensitive")
+	}
+}
@@ -8,13 +8,14 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"sort"
 
 	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/go-version"
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/ext/dynblock"
 	"github.com/hashicorp/hcl/v2/hclparse"
-	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	pkrfunction "github.com/hashicorp/packer/hcl2template/function"
 	"github.com/hashicorp/packer/internal/dag"
 	"github.com/hashicorp/packer/packer"
 	"github.com/zclconf/go-cty/cty"
@@ -31,6 +32,9 @@ const (
 	buildLabel             = "build"
 	hcpPackerRegistryLabel = "hcp_packer_registry"
 	communicatorLabel      = "communicator"
+	moduleLabel            = "module"
+	checkLabel             = "check"
+	outputLabel            = "output"
 )
 
 var configSchema = &hcl.BodySchema{
@@ -45,6 +49,9 @@ var configSchema = &hcl.BodySchema{
 		{Type: buildLabel},
 		{Type: hcpPackerRegistryLabel},
 		{Type: communicatorLabel, LabelNames: []string{"type", "name"}},
+		{Type: moduleLabel, LabelNames: []string{"name"}},
+		{Type: checkLabel, LabelNames: []string{"name"}},
+		{Type: outputLabel, LabelNames: []string{"name"}},
 	},
 }
 
@@ -96,6 +103,13 @@ const (
 // init should be called next to expand dynamic blocks and verify that used
 // things do exist.
 func (p *Parser) Parse(filename string, varFiles []string, argVars map[string]string) (*PackerConfig, hcl.Diagnostics) {
+	return p.parse(filename, varFiles, argVars, os.Environ(), true)
+}
+
+// parse loads the config files of filename. Variable values are read from
+// env, from the auto var files when autoVarFiles is set, and from varFiles and
+// argVars.
+func (p *Parser) parse(filename string, varFiles []string, argVars map[string]string, env []string, autoVarFiles bool) (*PackerConfig, hcl.Diagnostics) {
 	var files []*hcl.File
 	var diags hcl.Diagnostics
 
@@ -151,7 +165,10 @@ func (p *Parser) Parse(filename string, varFiles []string, argVars map[string]st
 		ValidationOptions:       p.ValidationOptions,
 		parser:                  p,
 		files:                   files,
+		env:                     env,
 	}
+	vaultSecretFunc := pkrfunction.MakeVaultSecretFunc()
+	cfg.vaultSecretFunc = &vaultSecretFunc
 
 	for _, file := range files {
 		coreVersionConstraints, moreDiags := sniffCoreVersionRequirements(file.Body)
@@ -171,8 +188,17 @@ func (p *Parser) Parse(filename string, varFiles []string, argVars map[string]st
 	// Looks for invalid arguments or unsupported block types
 	{
 		for _, file := range files {
-			_, moreDiags := file.Body.Content(configSchema)
+			content, moreDiags := file.Body.Content(configSchema)
 			diags = append(diags, moreDiags...)
+			for _, block := range content.Blocks {
+				switch block.Type {
+				case variableLabel, variablesLabel, localsLabel, localLabel, dataSourceLabel:
+					// The references of datasources and locals are registered
+					// with their dependencies.
+					continue
+				}
+				cfg.registerUsedReferences(GetVarsByType(block, inputVariablesAccessor, localsAccessor))
+			}
 		}
 	}
 
@@ -199,9 +225,10 @@ func (p *Parser) Parse(filename string, varFiles []string, argVars map[string]st
 		}
 
 		for _, file := range files {
-			moreLocals, morediags := parseLocalVariableBlocks(file)
+			moreLocals, moreConditions, morediags := parseLocalVariableBlocks(file)
 			diags = append(diags, morediags...)
 			cfg.LocalBlocks = append(cfg.LocalBlocks, moreLocals...)
+			cfg.localsConditions = append(cfg.localsConditions, moreConditions...)
 		}
 
 		diags = diags.Extend(cfg.checkForDuplicateLocalDefinition())
@@ -209,8 +236,12 @@ func (p *Parser) Parse(filename string, varFiles []string, argVars map[string]st
 
 	// parse var files
 	{
-		hclVarFiles, jsonVarFiles, moreDiags := GetHCL2Files(filename, hcl2AutoVarFileExt, hcl2AutoVarJsonFileExt)
-		diags = append(diags, moreDiags...)
+		var hclVarFiles, jsonVarFiles []string
+		if autoVarFiles {
+			var moreDiags hcl.Diagnostics
+			hclVarFiles, jsonVarFiles, moreDiags = GetHCL2Files(filename, hcl2AutoVarFileExt, hcl2AutoVarJsonFileExt)
+			diags = append(diags, moreDiags...)
+		}
 
 		// Combine all variable files into a single list, preserving the intended precedence and order.
 		// The order is: auto-loaded HCL files, auto-loaded JSON files, followed by user-specified varFiles.
@@ -248,7 +279,7 @@ func (p *Parser) Parse(filename string, varFiles []string, argVars map[string]st
 
 		}
 
-		diags = append(diags, cfg.collectInputVariableValues(os.Environ(), variableFiles, argVars)...)
+		diags = append(diags, cfg.collectInputVariableValues(env, variableFiles, argVars)...)
 	}
 
 	return cfg, diags
@@ -303,13 +334,7 @@ func filterVarsFromLogs(inputOrLocal Variables) {
 		if !variable.Sensitive {
 			continue
 		}
-		value := variable.Value()
-		_ = cty.Walk(value, func(_ cty.Path, nested cty.Value) (bool, error) {
-			if nested.IsWhollyKnown() && !nested.IsNull() && nested.Type().Equals(cty.String) {
-				packersdk.LogSecretFilter.Set(nested.AsString())
-			}
-			return true, nil
-		})
+		filterFromLogs(variable.Value())
 	}
 }
 
@@ -317,8 +342,9 @@ func (cfg *PackerConfig) detectBuildPrereqDependencies() hcl.Diagnostics {
 	var diags hcl.Diagnostics
 
 	for _, ds := range cfg.Datasources {
-		dependencies := GetVarsByType(ds.block, "data")
-		dependencies = append(dependencies, GetVarsByType(ds.block, "local")...)
+		references := GetVarsByType(ds.block, "data", "local", inputVariablesAccessor)
+		cfg.registerUsedReferences(references)
+		dependencies := FilterTraversalsByType(references, "data", "local", inputVariablesAccessor)
 
 		for _, dep := range dependencies {
 			// If something is locally aliased as `local` or `data`, we'll falsely
@@ -332,6 +358,9 @@ func (cfg *PackerConfig) detectBuildPrereqDependencies() hcl.Diagnostics {
 			if len(dep) < 2 {
 				continue
 			}
+			if _, ok := dep[1].(hcl.TraverseAttr); !ok {
+				continue
+			}
 			rs, err := NewRefStringFromDep(dep)
 			if err != nil {
 				diags = diags.Append(&hcl.Diagnostic{
@@ -358,8 +387,9 @@ func (cfg *PackerConfig) detectBuildPrereqDependencies() hcl.Diagnostics {
 	}
 
 	for _, loc := range cfg.LocalBlocks {
-		dependencies := FilterTraversalsByType(loc.Expr.Variables(), "data")
-		dependencies = append(dependencies, FilterTraversalsByType(loc.Expr.Variables(), "local")...)
+		references := loc.references()
+		cfg.registerUsedReferences(references)
+		dependencies := FilterTraversalsByType(references, "data", "local", inputVariablesAccessor)
 
 		for _, dep := range dependencies {
 			// If something is locally aliased as `local` or `data`, we'll falsely
@@ -373,6 +403,9 @@ func (cfg *PackerConfig) detectBuildPrereqDependencies() hcl.Diagnostics {
 			if len(dep) < 2 {
 				continue
 			}
+			if _, ok := dep[1].(hcl.TraverseAttr); !ok {
+				continue
+			}
 			rs, err := NewRefStringFromDep(dep)
 			if err != nil {
 				diags = diags.Append(&hcl.Diagnostic{
@@ -399,6 +432,85 @@ func (cfg *PackerConfig) detectBuildPrereqDependencies() hcl.Diagnostics {
 	return diags
 }
 
+// registerUsedReferences records the input and local variables referenced by
+// travs, so that detectUnusedVariables can report the ones that are not.
+func (cfg *PackerConfig) registerUsedReferences(travs []hcl.Traversal) {
+	if cfg.usedReferences == nil {
+		cfg.usedReferences = map[string]bool{}
+	}
+	for _, dep := range FilterTraversalsByType(travs, inputVariablesAccessor, localsAccessor) {
+		root := dep.RootName()
+		if len(dep) < 2 {
+			// The whole object is referenced, like in `keys(var)`.
+			cfg.usedReferences[root] = true
+			continue
+		}
+		switch step := dep[1].(type) {
+		case hcl.TraverseAttr:
+			cfg.usedReferences[root+"."+step.Name] = true
+		case hcl.TraverseIndex:
+			if step.Key.Type() == cty.String && step.Key.IsKnown() && !step.Key.IsNull() {
+				cfg.usedReferences[root+"."+step.Key.AsString()] = true
+			}
+		}
+	}
+}
+
+// detectUnusedVariables reports the input and local variables that are
+// declared but never referenced, from the references registered while
+// parsing the config and detecting the dependencies of datasources and
+// locals. An input variable that is only referenced by its own validation
+// rules is unused.
+func (cfg *PackerConfig) detectUnusedVariables() hcl.Diagnostics {
+	var diags hcl.Diagnostics
+
+	severity := hcl.DiagWarning
+	if cfg.ValidationOptions.Strict {
+		severity = hcl.DiagError
+	}
+
+	used := cfg.usedReferences
+	names := make([]string, 0, len(cfg.InputVariables))
+	for name := range cfg.InputVariables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if used[inputVariablesAccessor] || used[inputVariablesAccessor+"."+name] {
+			continue
+		}
+		variable := cfg.InputVariables[name]
+		diag := &hcl.Diagnostic{
+			Severity: severity,
+			Summary:  "Unused variable",
+			Detail:   fmt.Sprintf("The variable %q is declared but never referenced.", name),
+			Subject:  variable.Range.Ptr(),
+		}
+		for _, value := range variable.Values {
+			if value.From == "cmd" {
+				diag.Summary = "Unused -var value"
+				diag.Detail = fmt.Sprintf("A value is set with -var for the variable %q, "+
+					"but the variable is never referenced.", name)
+			}
+		}
+		diags = append(diags, diag)
+	}
+
+	for _, loc := range cfg.LocalBlocks {
+		if used[localsAccessor] || used[loc.Name()] {
+			continue
+		}
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: severity,
+			Summary:  "Unused local variable",
+			Detail:   fmt.Sprintf("The local variable %q is declared but never referenced.", loc.LocalName),
+			Subject:  loc.Expr.Range().Ptr(),
+		})
+	}
+
+	return diags
+}
+
 func (cfg *PackerConfig) buildPrereqsDAG() (*dag.AcyclicGraph, error) {
 	retGraph := dag.AcyclicGraph{}
 
@@ -482,18 +594,13 @@ func (cfg *PackerConfig) buildPrereqsDAG() (*dag.AcyclicGraph, error) {
 }
 
 func (cfg *PackerConfig) evaluateBuildPrereqs(skipDatasources bool) hcl.Diagnostics {
-	diags := cfg.detectBuildPrereqDependencies()
-	if diags.HasErrors() {
-		return diags
-	}
-
 	graph, err := cfg.buildPrereqsDAG()
 	if err != nil {
-		return diags.Append(&hcl.Diagnostic{
+		return hcl.Diagnostics{&hcl.Diagnostic{
 			Severity: hcl.DiagError,
 			Summary:  "failed to prepare execution graph",
 			Detail:   fmt.Sprintf("An error occurred while building the graph for datasources/locals: %s", err),
-		})
+		}}
 	}
 
 	walkFunc := func(v dag.Vertex) hcl.Diagnostics {
@@ -537,8 +644,7 @@ func (cfg *PackerConfig) evaluateBuildPrereqs(skipDatasources bool) hcl.Diagnost
 	for _, vtx := range graph.ReverseTopologicalOrder() {
 		vtxDiags := walkFunc(vtx)
 		if vtxDiags.HasErrors() {
-			diags = diags.Extend(vtxDiags)
-			return diags
+			return vtxDiags
 		}
 	}
 
@@ -546,14 +652,23 @@ func (cfg *PackerConfig) evaluateBuildPrereqs(skipDatasources bool) hcl.Diagnost
 }
 
 func (cfg *PackerConfig) Initialize(opts packer.InitializeOptions) hcl.Diagnostics {
-	diags := cfg.InputVariables.ValidateValues()
+	diags := cfg.InputVariables.ValidateValues(opts.AllowUnsetVariables)
+	depDiags := cfg.detectBuildPrereqDependencies()
+	diags = diags.Extend(depDiags)
+	if cfg.ValidationOptions.WarnOnUnused {
+		diags = diags.Extend(cfg.detectUnusedVariables())
+	}
 
-	if opts.UseSequential {
+	switch {
+	case opts.UseSequential:
 		diags = diags.Extend(cfg.evaluateDatasources(opts.SkipDatasourcesExecution))
 		diags = diags.Extend(cfg.evaluateLocalVariables(cfg.LocalBlocks))
-	} else {
+	case !depDiags.HasErrors():
 		diags = diags.Extend(cfg.evaluateBuildPrereqs(opts.SkipDatasourcesExecution))
 	}
+	if !diags.HasErrors() {
+		diags = diags.Extend(cfg.checkLocalsConditions())
+	}
 
 	filterVarsFromLogs(cfg.InputVariables)
 	filterVarsFromLogs(cfg.LocalVariables)
@@ -564,6 +679,7 @@ func (cfg *PackerConfig) Initialize(opts packer.InitializeOptions) hcl.Diagnosti
 	}
 
 	diags = append(diags, cfg.initializeBlocks()...)
+	diags = append(diags, cfg.initializeModules(opts)...)
 
 	return diags
 }
@@ -574,7 +690,9 @@ func (p *Parser) parseConfig(f *hcl.File, cfg *PackerConfig) hcl.Diagnostics {
 	var diags hcl.Diagnostics
 
 	body := f.Body
-	body = dynblock.Expand(body, cfg.EvalContext(DatasourceContext, nil))
+	// dynblock cannot iterate over marked values, like the ones of sensitive
+	// variables.
+	body = dynblock.Expand(body, unmarkedEvalContext(cfg.EvalContext(DatasourceContext, nil)))
 	content, moreDiags := body.Content(configSchema)
 	diags = append(diags, moreDiags...)
 
@@ -622,6 +740,113 @@ func (p *Parser) parseConfig(f *hcl.File, cfg *PackerConfig) hcl.Diagnostics {
 			}
 			cfg.Sources[ref] = source
 
+		case communicatorLabel:
+			communicator, moreDiags := p.decodeCommunicator(block)
+			diags = append(diags, moreDiags...)
+			if moreDiags.HasErrors() {
+				continue
+			}
+
+			ref := communicator.Ref()
+			if existing, found := cfg.Communicators[ref]; found {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Duplicate " + communicatorLabel + " block",
+					Detail: fmt.Sprintf("This "+communicatorLabel+" block has the "+
+						"same type and name as a previous block declared "+
+						"at %s. Each "+communicatorLabel+" must have a unique name per type.",
+						existing.block.DefRange.Ptr()),
+					Subject: communicator.block.DefRange.Ptr(),
+				})
+				continue
+			}
+
+			if cfg.Communicators == nil {
+				cfg.Communicators = map[CommunicatorRef]CommunicatorBlock{}
+			}
+			cfg.Communicators[ref] = *communicator
+
+		case outputLabel:
+			output, moreDiags := p.decodeOutput(block)
+			diags = append(diags, moreDiags...)
+			if moreDiags.HasErrors() {
+				continue
+			}
+
+			duplicate := false
+			for _, existing := range cfg.Outputs {
+				if existing.Name != output.Name {
+					continue
+				}
+				duplicate = true
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Duplicate " + outputLabel + " block",
+					Detail: fmt.Sprintf("This "+outputLabel+" block has the "+
+						"same name as a previous block declared at %s. "+
+						"Each "+outputLabel+" must have a unique name.",
+						existing.block.DefRange.Ptr()),
+					Subject: output.block.DefRange.Ptr(),
+				})
+			}
+			if duplicate {
+				continue
+			}
+			cfg.Outputs = append(cfg.Outputs, output)
+
+		case checkLabel:
+			check, moreDiags := p.decodeCheck(block)
+			diags = append(diags, moreDiags...)
+			if moreDiags.HasErrors() {
+				continue
+			}
+
+			duplicate := false
+			for _, existing := range cfg.Checks {
+				if existing.Name != check.Name {
+					continue
+				}
+				duplicate = true
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Duplicate " + checkLabel + " block",
+					Detail: fmt.Sprintf("This "+checkLabel+" block has the "+
+						"same name as a previous block declared at %s. "+
+						"Each "+checkLabel+" must have a unique name.",
+						existing.block.DefRange.Ptr()),
+					Subject: check.block.DefRange.Ptr(),
+				})
+			}
+			if duplicate {
+				continue
+			}
+			cfg.Checks = append(cfg.Checks, check)
+
+		case moduleLabel:
+			module, moreDiags := p.decodeModule(block)
+			diags = append(diags, moreDiags...)
+			if moreDiags.HasErrors() {
+				continue
+			}
+
+			if existing, found := cfg.Modules[module.Name]; found {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Duplicate " + moduleLabel + " block",
+					Detail: fmt.Sprintf("This "+moduleLabel+" block has the "+
+						"same name as a previous block declared at %s. "+
+						"Each "+moduleLabel+" must have a unique name.",
+						existing.block.DefRange.Ptr()),
+					Subject: module.block.DefRange.Ptr(),
+				})
+				continue
+			}
+
+			if cfg.Modules == nil {
+				cfg.Modules = map[string]*ModuleBlock{}
+			}
+			cfg.Modules[module.Name] = module
+
 		case buildLabel:
 			build, moreDiags := p.decodeBuildConfig(block, cfg)
 			diags = append(diags, moreDiags...)
@@ -188,6 +188,14 @@ func (cfg *PackerConfig) initializeBlocks() hcl.Diagnostics {
 				body = hcl.MergeBodies([]hcl.Body{body, srcUsage.Body})
 			}
 
+			body, moreDiags := cfg.expandCommunicator(body)
+			diags = append(diags, moreDiags...)
+
+			content, body, moreDiags := body.PartialContent(conditionsSchema)
+			diags = append(diags, moreDiags...)
+			srcUsage.conditions, moreDiags = decodeConditions(content.Blocks)
+			diags = append(diags, moreDiags...)
+
 			srcUsage.Body = body
 		}
 
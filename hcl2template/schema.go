@@ -0,0 +1,73 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package hcl2template
+
+import (
+	"github.com/hashicorp/hcl/v2"
+)
+
+// buildBlockAttributes are the arguments of a build block, they are decoded
+// with gohcl so they are not part of buildSchema.
+var buildBlockAttributes = []hcl.AttributeSchema{
+	{Name: "name"},
+	{Name: "description"},
+	{Name: "sources"},
+	{Name: "timeout"},
+	{Name: "post_processors_parallel"},
+}
+
+// BlockSchema returns the schema of a block from its type and the types of
+// its parent blocks, for example `BlockSchema("build", "post-processors")`.
+// Without type, the schema of the top level of a config is returned.
+//
+// It is meant for tooling like editors, the body of the blocks configuring
+// plugin components depends on the plugin, and nil is returned for them, or
+// for unknown blocks.
+func BlockSchema(blockTypes ...string) *hcl.BodySchema {
+	if len(blockTypes) == 0 {
+		return configSchema
+	}
+
+	switch blockTypes[len(blockTypes)-1] {
+	case preconditionLabel, postconditionLabel, assertLabel:
+		return checkRuleBlockSchema
+	case "validation":
+		return variableValidationBlockSchema
+	}
+
+	switch blockTypes[0] {
+	case packerLabel:
+		if len(blockTypes) == 1 {
+			return packerBlockSchema
+		}
+	case variableLabel:
+		if len(blockTypes) == 1 {
+			return variableBlockSchema
+		}
+	case localLabel:
+		if len(blockTypes) == 1 {
+			return localBlockSchema
+		}
+	case checkLabel:
+		if len(blockTypes) == 1 {
+			return checkBlockSchema
+		}
+	case outputLabel:
+		if len(blockTypes) == 1 {
+			return outputBlockSchema
+		}
+	case buildLabel:
+		switch {
+		case len(blockTypes) == 1:
+			return &hcl.BodySchema{
+				Attributes: buildBlockAttributes,
+				Blocks:     buildSchema.Blocks,
+			}
+		case len(blockTypes) == 2 && blockTypes[1] == buildPostProcessorsLabel:
+			return postProcessorsSchema
+		}
+	}
+
+	return nil
+}
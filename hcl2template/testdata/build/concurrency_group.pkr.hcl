@@ -0,0 +1,9 @@
+
+build {
+    source "source.virtualbox-iso.ubuntu-1204" {
+        concurrency_group = "vbox"
+    }
+}
+
+source "virtualbox-iso" "ubuntu-1204" {
+}
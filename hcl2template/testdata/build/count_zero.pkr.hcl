@@ -0,0 +1,8 @@
+build {
+    source "source.virtualbox-iso.ubuntu-1204" {
+        count = 0
+    }
+}
+
+source "virtualbox-iso" "ubuntu-1204" {
+}
@@ -0,0 +1,15 @@
+build {
+    source "source.amazon-ebs.ubuntu-1604" {
+        for_each = []
+    }
+
+    source "source.virtualbox-iso.ubuntu-1204" {
+        count = 1
+    }
+}
+
+source "virtualbox-iso" "ubuntu-1204" {
+}
+
+source "amazon-ebs" "ubuntu-1604" {
+}
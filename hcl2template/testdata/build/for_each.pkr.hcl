@@ -0,0 +1,18 @@
+build {
+    source "source.amazon-ebs.ubuntu-1604" {
+        for_each = {
+            us-east-1 = "ami-1"
+            eu-west-1 = "ami-2"
+        }
+    }
+
+    source "source.virtualbox-iso.ubuntu-1204" {
+        count = 2
+    }
+}
+
+source "virtualbox-iso" "ubuntu-1204" {
+}
+
+source "amazon-ebs" "ubuntu-1604" {
+}
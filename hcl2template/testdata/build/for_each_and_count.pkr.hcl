@@ -0,0 +1,9 @@
+build {
+    source "source.virtualbox-iso.ubuntu-1204" {
+        for_each = ["a", "b"]
+        count    = 2
+    }
+}
+
+source "virtualbox-iso" "ubuntu-1204" {
+}
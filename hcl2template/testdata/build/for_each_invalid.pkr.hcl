@@ -0,0 +1,8 @@
+build {
+    source "source.virtualbox-iso.ubuntu-1204" {
+        for_each = "a"
+    }
+}
+
+source "virtualbox-iso" "ubuntu-1204" {
+}
@@ -0,0 +1,22 @@
+build {
+    sources = [
+        "source.virtualbox-iso.ubuntu-1204",
+    ]
+
+    post-processors {
+        on_error = "warn"
+
+        post-processor "amazon-import" {
+        }
+
+        post-processor "manifest" {
+            on_error = "ignore"
+        }
+    }
+
+    post-processor "manifest" {
+    }
+}
+
+source "virtualbox-iso" "ubuntu-1204" {
+}
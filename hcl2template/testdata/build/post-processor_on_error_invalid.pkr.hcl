@@ -0,0 +1,12 @@
+build {
+    sources = [
+        "source.virtualbox-iso.ubuntu-1204",
+    ]
+
+    post-processor "manifest" {
+        on_error = "retry"
+    }
+}
+
+source "virtualbox-iso" "ubuntu-1204" {
+}
@@ -0,0 +1,16 @@
+build {
+    post_processors_parallel = true
+
+    sources = [
+        "source.virtualbox-iso.ubuntu-1204",
+    ]
+
+    post-processor "amazon-import" {
+    }
+
+    post-processor "manifest" {
+    }
+}
+
+source "virtualbox-iso" "ubuntu-1204" {
+}
@@ -0,0 +1,23 @@
+
+build {
+    retry {
+        attempts = 3
+        backoff  = "30s"
+    }
+
+    source "source.virtualbox-iso.ubuntu-1204" {
+        retry {
+            attempts = 2
+            retry_on = ["(?i)timeout"]
+        }
+    }
+
+    source "source.amazon-ebs.ubuntu-1604" {
+    }
+}
+
+source "virtualbox-iso" "ubuntu-1204" {
+}
+
+source "amazon-ebs" "ubuntu-1604" {
+}
@@ -0,0 +1,17 @@
+
+build {
+    timeout = "1h"
+
+    source "source.virtualbox-iso.ubuntu-1204" {
+        timeout = "90m"
+    }
+
+    source "source.amazon-ebs.ubuntu-1604" {
+    }
+}
+
+source "virtualbox-iso" "ubuntu-1204" {
+}
+
+source "amazon-ebs" "ubuntu-1604" {
+}
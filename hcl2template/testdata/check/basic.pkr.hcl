@@ -0,0 +1,31 @@
+source "null" "test" {
+  communicator = "none"
+}
+
+build {
+  sources = ["source.null.test"]
+}
+
+check "artifacts" {
+  assert {
+    condition     = length(build.artifacts) > 0
+    error_message = "The build produced no artifact."
+  }
+  assert {
+    condition     = alltrue([for a in build.artifacts : length(a.files) > 0])
+    error_message = "An artifact has no file."
+  }
+}
+
+check "source_image" {
+  on_failure = "warn"
+
+  assert {
+    condition     = build.generated_data.SourceImage == build.SourceImage
+    error_message = "The build used ${build.SourceImage} instead of ubuntu."
+  }
+  assert {
+    condition     = build.SourceImage == "ubuntu"
+    error_message = "The build used ${build.SourceImage} instead of ubuntu."
+  }
+}
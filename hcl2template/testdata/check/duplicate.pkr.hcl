@@ -0,0 +1,13 @@
+check "artifacts" {
+  assert {
+    condition     = length(build.artifacts) > 0
+    error_message = "The build produced no artifact."
+  }
+}
+
+check "artifacts" {
+  assert {
+    condition     = length(build.artifacts) > 1
+    error_message = "The build produced one artifact."
+  }
+}
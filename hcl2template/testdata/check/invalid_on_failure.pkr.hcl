@@ -0,0 +1,8 @@
+check "artifacts" {
+  on_failure = "ignore"
+
+  assert {
+    condition     = length(build.artifacts) > 0
+    error_message = "The build produced no artifact."
+  }
+}
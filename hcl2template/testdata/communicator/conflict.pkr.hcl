@@ -0,0 +1,15 @@
+communicator "ssh" "bastion" {
+    ssh_host     = "10.0.0.5"
+    ssh_username = "ubuntu"
+    ssh_password = "ubuntu"
+}
+
+source "null" "test" {
+    communicator = communicator.ssh.bastion
+}
+
+build {
+    source "source.null.test" {
+        ssh_username = "admin"
+    }
+}
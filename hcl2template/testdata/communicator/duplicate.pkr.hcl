@@ -0,0 +1,7 @@
+communicator "ssh" "bastion" {
+    ssh_username = "ubuntu"
+}
+
+communicator "ssh" "bastion" {
+    ssh_username = "admin"
+}
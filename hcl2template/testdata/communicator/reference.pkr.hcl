@@ -0,0 +1,15 @@
+communicator "ssh" "bastion" {
+    ssh_host         = "10.0.0.5"
+    ssh_username     = "ubuntu"
+    ssh_password     = "ubuntu"
+    ssh_bastion_host = "bastion.example.com"
+    ssh_bastion_password = "bastion"
+}
+
+source "null" "test" {
+    communicator = communicator.ssh.bastion
+}
+
+build {
+    sources = ["source.null.test"]
+}
@@ -0,0 +1,12 @@
+communicator "ssh" "bastion" {
+    ssh_host     = "10.0.0.5"
+    ssh_username = "ubuntu"
+}
+
+source "null" "test" {
+    communicator = communicator.ssh.jump
+}
+
+build {
+    sources = ["source.null.test"]
+}
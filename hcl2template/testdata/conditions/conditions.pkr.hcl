@@ -0,0 +1,61 @@
+variable "version" {
+  default = "1.2.3"
+}
+
+// fail selects the condition to fail.
+variable "fail" {
+  default = ""
+}
+
+data "null" "version" {
+  input = var.version
+
+  precondition {
+    condition     = var.fail != "data_pre"
+    error_message = "Data precondition."
+  }
+  postcondition {
+    condition     = var.fail != "data_post" && self.output == var.version
+    error_message = "Data postcondition."
+  }
+}
+
+locals {
+  major = split(".", data.null.version.output)[0]
+
+  precondition {
+    condition     = var.fail != "locals_pre" && data.null.version.output != ""
+    error_message = "Locals precondition."
+  }
+  postcondition {
+    condition     = var.fail != "locals_post" && self.major == "1"
+    error_message = "Locals postcondition for version ${local.major}."
+  }
+}
+
+source "null" "test" {
+  communicator = "none"
+
+  precondition {
+    condition     = var.fail != "source_pre" && source.name == "test"
+    error_message = "Source precondition."
+  }
+  postcondition {
+    condition     = var.fail != "source_post" && self.communicator == "none"
+    error_message = "Source postcondition."
+  }
+}
+
+build {
+  name    = "b"
+  sources = ["source.null.test"]
+
+  precondition {
+    condition     = var.fail != "build_pre" && build.name == "b"
+    error_message = "Build precondition."
+  }
+  postcondition {
+    condition     = var.fail != "build_post" && contains(self.builds, "b.null.test")
+    error_message = "Build postcondition."
+  }
+}
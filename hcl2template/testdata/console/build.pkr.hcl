@@ -0,0 +1,21 @@
+variable "greeting" {
+  type    = string
+  default = "hello"
+}
+
+locals {
+  names = ["a", "b"]
+}
+
+source "null" "example" {
+  communicator = "none"
+}
+
+build {
+  name = "app"
+
+  source "source.null.example" {
+    for_each = local.names
+    name     = each.value
+  }
+}
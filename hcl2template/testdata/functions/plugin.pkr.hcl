@@ -0,0 +1,5 @@
+
+locals {
+  greeting = provider::example::greet("packer")
+  shouting = upper(provider::example::greet("packer"))
+}
@@ -0,0 +1,38 @@
+variable "region" {
+  default = "us-east-1"
+}
+
+locals {
+  loop = "${local.loop}-again"
+}
+
+data "amazon-ami" "base" {
+  region = var.region
+}
+
+source "amazon-ebs" "web" {
+  region = var.region
+}
+
+build {
+  source "source.amazon-ebs.web" {
+    ami = data.amazon-ami.base.id
+  }
+
+  provisioner "shell" {
+    inline = ["echo ${var.region}"]
+  }
+
+  provisioner "file" {}
+
+  error-cleanup-provisioner "shell" {}
+
+  post-processor "manifest" {}
+
+  post-processors {
+    post-processor "amazon-import" {}
+    post-processor "manifest" {
+      output = "${local.loop}.json"
+    }
+  }
+}
@@ -0,0 +1,15 @@
+variable "version" {
+    default = "1.0"
+}
+
+source "virtualbox-iso" "ubuntu-1204" {
+}
+
+build {
+    sources = ["source.virtualbox-iso.ubuntu-1204"]
+}
+
+module "base" {
+    source     = "./modules/base"
+    image_name = "base-${var.version}"
+}
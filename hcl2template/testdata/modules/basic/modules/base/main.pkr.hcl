@@ -0,0 +1,17 @@
+variable "image_name" {
+    type = string
+}
+
+variable "owner" {
+    type    = string
+    default = "packer"
+}
+
+source "amazon-ebs" "ubuntu-1604" {
+    string = "${var.image_name}-${path.root}"
+}
+
+build {
+    name    = "x"
+    sources = ["source.amazon-ebs.ubuntu-1604"]
+}
@@ -0,0 +1,3 @@
+module "base" {
+    source = "github.com/example/packer-modules//base"
+}
@@ -0,0 +1,5 @@
+module "base" {
+    source  = "../basic/modules/base"
+    image_name = "base"
+    version = "1.0"
+}
@@ -0,0 +1,21 @@
+variable "region" {
+  default = "eu-west-1"
+}
+
+source "null" "test" {
+  communicator = "none"
+}
+
+build {
+  sources = ["source.null.test"]
+}
+
+output "image_id" {
+  description = "The id of the image."
+  value       = "${var.region}:${build["null.test"].artifacts[0].id}"
+}
+
+output "source_image" {
+  value     = build["null.test"].generated_data.SourceImage
+  sensitive = true
+}
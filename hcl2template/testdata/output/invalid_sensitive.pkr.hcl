@@ -0,0 +1,4 @@
+output "id" {
+  value     = "a"
+  sensitive = "maybe"
+}
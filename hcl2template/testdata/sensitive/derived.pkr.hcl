@@ -0,0 +1,25 @@
+variable "password" {
+  type      = string
+  default   = "s3cr3t-p4ssw0rd"
+  sensitive = true
+}
+
+locals {
+  formatted = format("admin:%s", var.password)
+  joined    = join(",", ["admin", var.password])
+  encoded   = base64encode(var.password)
+  public    = upper("admin")
+}
+
+source "null" "test" {
+  communicator = "none"
+}
+
+build {
+  sources = ["source.null.test"]
+
+  provisioner "shell" {
+    string       = local.encoded
+    slice_string = [local.formatted, local.joined, local.public]
+  }
+}
@@ -0,0 +1,40 @@
+variable "tags" {
+  type = map(string)
+  default = {
+    first  = "one"
+    second = "two"
+  }
+  sensitive = true
+}
+
+variable "password" {
+  type      = string
+  default   = "s3cr3t-p4ssw0rd"
+  sensitive = true
+}
+
+local "suffixed" {
+  expression = "${var.password}-suffixed"
+  sensitive  = true
+}
+
+source "null" "test" {
+  communicator = "none"
+}
+
+build {
+  sources = ["source.null.test"]
+
+  dynamic "provisioner" {
+    labels   = ["shell"]
+    for_each = var.tags
+    content {
+      string = provisioner.value
+    }
+  }
+}
+
+module "child" {
+  source   = "../modules/child"
+  password = local.suffixed
+}
@@ -0,0 +1,7 @@
+variable "password" {
+  type = string
+}
+
+locals {
+  upper = upper(var.password)
+}
@@ -0,0 +1,12 @@
+locals {
+  foo = "bar"
+
+  precondition {
+    condition     = true
+    error_message = "Never fails."
+  }
+
+  bogus {
+    x = 1
+  }
+}
@@ -5,6 +5,7 @@ package hcl2template
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/gohcl"
@@ -37,10 +38,16 @@ var buildSchema = &hcl.BodySchema{
 		{Type: buildPostProcessorLabel, LabelNames: []string{"type"}},
 		{Type: buildPostProcessorsLabel, LabelNames: []string{}},
 		{Type: buildHCPPackerRegistryLabel},
+		{Type: buildRetryLabel},
+		{Type: preconditionLabel},
+		{Type: postconditionLabel},
 	},
 }
 
 var postProcessorsSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "on_error"},
+	},
 	Blocks: []hcl.BlockHeaderSchema{
 		{Type: buildPostProcessorLabel, LabelNames: []string{"type"}},
 	},
@@ -82,7 +89,23 @@ type BuildBlock struct {
 	// steps.
 	PostProcessorsLists [][]*PostProcessorBlock
 
+	// PostProcessorsParallel tells to run the post-processor lists
+	// concurrently, as they all start from the artifact of the build.
+	PostProcessorsParallel bool
+
+	// Retry configures how the builds of this block are retried when they
+	// fail. It can be overridden in a source block.
+	Retry *RetryBlock
+
+	// Timeout bounds the time each build of this block can take. It can be
+	// overridden in a source block.
+	Timeout time.Duration
+
 	HCL2Ref HCL2Ref
+
+	// conditions are checked before and after the builds of this block are
+	// prepared.
+	conditions Conditions
 }
 
 type Builds []*BuildBlock
@@ -91,14 +114,16 @@ type Builds []*BuildBlock
 // load the references to the contents of the build block.
 func (p *Parser) decodeBuildConfig(block *hcl.Block, cfg *PackerConfig) (*BuildBlock, hcl.Diagnostics) {
 	var b struct {
-		Name        string   `hcl:"name,optional"`
-		Description string   `hcl:"description,optional"`
-		FromSources []string `hcl:"sources,optional"`
-		Config      hcl.Body `hcl:",remain"`
+		Name                   string   `hcl:"name,optional"`
+		Description            string   `hcl:"description,optional"`
+		Timeout                string   `hcl:"timeout,optional"`
+		PostProcessorsParallel bool     `hcl:"post_processors_parallel,optional"`
+		FromSources            []string `hcl:"sources,optional"`
+		Config                 hcl.Body `hcl:",remain"`
 	}
 
 	body := block.Body
-	diags := gohcl.DecodeBody(body, cfg.EvalContext(LocalContext, nil), &b)
+	diags := gohcl.DecodeBody(body, unmarkedEvalContext(cfg.EvalContext(LocalContext, nil)), &b)
 	if diags.HasErrors() {
 		return nil, diags
 	}
@@ -109,8 +134,22 @@ func (p *Parser) decodeBuildConfig(block *hcl.Block, cfg *PackerConfig) (*BuildB
 
 	build.Name = b.Name
 	build.Description = b.Description
+	build.PostProcessorsParallel = b.PostProcessorsParallel
 	build.HCL2Ref.DefRange = block.DefRange
 
+	if b.Timeout != "" {
+		timeout, err := time.ParseDuration(b.Timeout)
+		if err != nil {
+			return nil, append(diags, &hcl.Diagnostic{
+				Summary:  "Failed to parse timeout duration",
+				Severity: hcl.DiagError,
+				Detail:   err.Error(),
+				Subject:  block.DefRange.Ptr(),
+			})
+		}
+		build.Timeout = timeout
+	}
+
 	// Expose build.name during parsing of pps and provisioners
 	ectx := cfg.EvalContext(BuildContext, nil)
 	ectx.Variables[buildAccessor] = cty.ObjectVal(map[string]cty.Value{
@@ -154,6 +193,7 @@ func (p *Parser) decodeBuildConfig(block *hcl.Block, cfg *PackerConfig) (*BuildB
 	if diags.HasErrors() {
 		return nil, diags
 	}
+	var conditionBlocks hcl.Blocks
 	for _, block := range content.Blocks {
 		switch block.Type {
 		case buildHCPPackerRegistryLabel:
@@ -171,14 +211,29 @@ func (p *Parser) decodeBuildConfig(block *hcl.Block, cfg *PackerConfig) (*BuildB
 				continue
 			}
 			build.HCPPackerRegistry = hcpPackerRegistry
+		case buildRetryLabel:
+			if build.Retry != nil {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Only one " + buildRetryLabel + " block is allowed",
+					Subject:  block.DefRange.Ptr(),
+				})
+				continue
+			}
+			retry, moreDiags := decodeRetryBlock(block, ectx)
+			diags = append(diags, moreDiags...)
+			if moreDiags.HasErrors() {
+				continue
+			}
+			build.Retry = retry
 		case sourceLabel:
 			hadSource = true
-			ref, moreDiags := p.decodeBuildSource(block)
+			refs, moreDiags := p.decodeBuildSource(block, ectx)
 			diags = append(diags, moreDiags...)
 			if moreDiags.HasErrors() {
 				continue
 			}
-			build.Sources = append(build.Sources, ref)
+			build.Sources = append(build.Sources, refs...)
 		case buildProvisionerLabel:
 			p, moreDiags := p.decodeProvisioner(block, ectx)
 			diags = append(diags, moreDiags...)
@@ -216,6 +271,16 @@ func (p *Parser) decodeBuildConfig(block *hcl.Block, cfg *PackerConfig) (*BuildB
 				continue
 			}
 
+			onError := ""
+			if attr, ok := content.Attributes["on_error"]; ok {
+				moreDiags := gohcl.DecodeExpression(attr.Expr, unmarkedEvalContext(ectx), &onError)
+				moreDiags = moreDiags.Extend(validatePostProcessorOnError(onError, attr.Range.Ptr()))
+				diags = append(diags, moreDiags...)
+				if moreDiags.HasErrors() {
+					continue
+				}
+			}
+
 			errored := false
 			postProcessors := []*PostProcessorBlock{}
 			for _, block := range content.Blocks {
@@ -225,14 +290,23 @@ func (p *Parser) decodeBuildConfig(block *hcl.Block, cfg *PackerConfig) (*BuildB
 					errored = true
 					break
 				}
+				if pp.OnError == "" {
+					pp.OnError = onError
+				}
 				postProcessors = append(postProcessors, pp)
 			}
 			if errored == false {
 				build.PostProcessorsLists = append(build.PostProcessorsLists, postProcessors)
 			}
+		case preconditionLabel, postconditionLabel:
+			conditionBlocks = append(conditionBlocks, block)
 		}
 	}
 
+	conditions, moreDiags := decodeConditions(conditionBlocks)
+	diags = append(diags, moreDiags...)
+	build.conditions = conditions
+
 	if !hadSource {
 		diags = append(diags, &hcl.Diagnostic{
 			Summary:  "missing source reference",
@@ -240,6 +314,14 @@ func (p *Parser) decodeBuildConfig(block *hcl.Block, cfg *PackerConfig) (*BuildB
 			Severity: hcl.DiagError,
 			Subject:  block.DefRange.Ptr(),
 		})
+	} else if len(build.Sources) == 0 && !diags.HasErrors() {
+		diags = append(diags, &hcl.Diagnostic{
+			Summary: "no source instances",
+			Detail: "all the sources of this build block expand to zero " +
+				"instances, so it has nothing to build",
+			Severity: hcl.DiagError,
+			Subject:  block.DefRange.Ptr(),
+		})
 	}
 
 	return build, diags
@@ -27,7 +27,9 @@ type HCPPackerRegistryBlock struct {
 var bucketNameRegexp = regexp.MustCompile("^[a-zA-Z0-9-]{3,36}$")
 
 func (p *Parser) decodeHCPRegistry(block *hcl.Block, cfg *PackerConfig) (*HCPPackerRegistryBlock, hcl.Diagnostics) {
-	par := &HCPPackerRegistryBlock{}
+	par := &HCPPackerRegistryBlock{
+		HCL2Ref: newHCL2Ref(block, nil),
+	}
 	body := block.Body
 
 	var b struct {
@@ -40,7 +42,7 @@ func (p *Parser) decodeHCPRegistry(block *hcl.Block, cfg *PackerConfig) (*HCPPac
 		Config       hcl.Body          `hcl:",remain"`
 	}
 	ectx := cfg.EvalContext(BuildContext, nil)
-	diags := gohcl.DecodeBody(body, ectx, &b)
+	diags := gohcl.DecodeBody(body, unmarkedEvalContext(ectx), &b)
 	if diags.HasErrors() {
 		return nil, diags
 	}
@@ -18,10 +18,37 @@ type PostProcessorBlock struct {
 	PName             string
 	OnlyExcept        OnlyExcept
 	KeepInputArtifact *bool
+	// OnError is the policy applied when the post-processor fails, one of
+	// "fail", "warn" or "ignore". It defaults to the one of the enclosing
+	// post-processors block, if any.
+	OnError string
 
 	HCL2Ref
 }
 
+// postProcessorOnErrorValues are the accepted values of on_error.
+var postProcessorOnErrorValues = []string{"fail", "warn", "ignore"}
+
+// validatePostProcessorOnError returns an error diagnostic when onError is
+// not a valid on_error value.
+func validatePostProcessorOnError(onError string, subject *hcl.Range) hcl.Diagnostics {
+	if onError == "" {
+		return nil
+	}
+	for _, v := range postProcessorOnErrorValues {
+		if onError == v {
+			return nil
+		}
+	}
+	return hcl.Diagnostics{&hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Invalid on_error value",
+		Detail: fmt.Sprintf("on_error must be one of %q, got %q.",
+			postProcessorOnErrorValues, onError),
+		Subject: subject,
+	}}
+}
+
 func (p *PostProcessorBlock) String() string {
 	return fmt.Sprintf(buildPostProcessorLabel+"-block %q %q", p.PType, p.PName)
 }
@@ -32,10 +59,11 @@ func (p *Parser) decodePostProcessor(block *hcl.Block, ectx *hcl.EvalContext) (*
 		Only              []string `hcl:"only,optional"`
 		Except            []string `hcl:"except,optional"`
 		KeepInputArtifact *bool    `hcl:"keep_input_artifact,optional"`
+		OnError           string   `hcl:"on_error,optional"`
 		Rest              hcl.Body `hcl:",remain"`
 	}
 
-	diags := gohcl.DecodeBody(block.Body, ectx, &b)
+	diags := gohcl.DecodeBody(block.Body, unmarkedEvalContext(ectx), &b)
 	if diags.HasErrors() {
 		return nil, diags
 	}
@@ -46,9 +74,11 @@ func (p *Parser) decodePostProcessor(block *hcl.Block, ectx *hcl.EvalContext) (*
 		OnlyExcept:        OnlyExcept{Only: b.Only, Except: b.Except},
 		HCL2Ref:           newHCL2Ref(block, b.Rest),
 		KeepInputArtifact: b.KeepInputArtifact,
+		OnError:           b.OnError,
 	}
 
 	diags = diags.Extend(postProcessor.OnlyExcept.Validate())
+	diags = diags.Extend(validatePostProcessorOnError(b.OnError, block.DefRange.Ptr()))
 	if diags.HasErrors() {
 		return nil, diags
 	}
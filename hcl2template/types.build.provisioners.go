@@ -22,12 +22,16 @@ type OnlyExcept struct {
 	Except []string `json:"except,omitempty"`
 }
 
-// Skip says whether or not to skip the build with the given name.
-func (o *OnlyExcept) Skip(n string) bool {
+// Skip says whether or not to skip the build with the given names. A build
+// can have more than one name when it is an instance of a source block with
+// for_each or count, in which case it can be selected by any of them.
+func (o *OnlyExcept) Skip(names ...string) bool {
 	if len(o.Only) > 0 {
 		for _, v := range o.Only {
-			if v == n {
-				return false
+			for _, n := range names {
+				if v == n {
+					return false
+				}
 			}
 		}
 
@@ -36,8 +40,10 @@ func (o *OnlyExcept) Skip(n string) bool {
 
 	if len(o.Except) > 0 {
 		for _, v := range o.Except {
-			if v == n {
-				return true
+			for _, n := range names {
+				if v == n {
+					return true
+				}
 			}
 		}
 
@@ -88,7 +94,7 @@ func (p *Parser) decodeProvisioner(block *hcl.Block, ectx *hcl.EvalContext) (*Pr
 		Override    cty.Value `hcl:"override,optional"`
 		Rest        hcl.Body  `hcl:",remain"`
 	}
-	diags := gohcl.DecodeBody(block.Body, ectx, &b)
+	diags := gohcl.DecodeBody(block.Body, unmarkedEvalContext(ectx), &b)
 	if diags.HasErrors() {
 		return nil, diags
 	}
@@ -0,0 +1,114 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package hcl2template
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/packer/packer"
+)
+
+const buildRetryLabel = "retry"
+
+// RetryBlock references an HCL 'retry' block. It can be set in a build block
+// or in a source block of a build block, in which case it takes precedence
+// over the one of the build block:
+//
+//	build {
+//	  retry {
+//	    attempts = 3
+//	    backoff  = "30s"
+//	    retry_on = ["(?i)timeout"]
+//	  }
+//	}
+type RetryBlock struct {
+	// Attempts is the total number of times a build can be run.
+	Attempts int
+	// Backoff is the time to wait before the first retry, it is doubled
+	// after each failed attempt.
+	Backoff time.Duration
+	// RetryOn is a list of regular expressions; a failed build is only
+	// retried if its error matches one of them. Any error is retried when
+	// empty.
+	RetryOn []string
+
+	HCL2Ref
+}
+
+func decodeRetryBlock(block *hcl.Block, ectx *hcl.EvalContext) (*RetryBlock, hcl.Diagnostics) {
+	var b struct {
+		Attempts int      `hcl:"attempts"`
+		Backoff  string   `hcl:"backoff,optional"`
+		RetryOn  []string `hcl:"retry_on,optional"`
+	}
+	diags := gohcl.DecodeBody(block.Body, unmarkedEvalContext(ectx), &b)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	r := &RetryBlock{
+		Attempts: b.Attempts,
+		RetryOn:  b.RetryOn,
+		HCL2Ref:  newHCL2Ref(block, block.Body),
+	}
+
+	if b.Attempts < 1 {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid " + buildRetryLabel + " attempts",
+			Detail:   fmt.Sprintf("attempts must be at least 1, got %d.", b.Attempts),
+			Subject:  block.DefRange.Ptr(),
+		})
+	}
+
+	if b.Backoff != "" {
+		backoff, err := time.ParseDuration(b.Backoff)
+		if err != nil {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Failed to parse backoff duration",
+				Detail:   err.Error(),
+				Subject:  block.DefRange.Ptr(),
+			})
+		}
+		r.Backoff = backoff
+	}
+
+	for _, pattern := range b.RetryOn {
+		if _, err := regexp.Compile(pattern); err != nil {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid retry_on regular expression",
+				Detail:   fmt.Sprintf("%q: %s", pattern, err),
+				Subject:  block.DefRange.Ptr(),
+			})
+		}
+	}
+
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	return r, diags
+}
+
+// buildRetry returns the packer.BuildRetry configuration for this block. The
+// patterns were validated when decoding the block.
+func (r *RetryBlock) buildRetry() packer.BuildRetry {
+	if r == nil {
+		return packer.BuildRetry{}
+	}
+	res := packer.BuildRetry{
+		Attempts: r.Attempts,
+		Backoff:  r.Backoff,
+	}
+	for _, pattern := range r.RetryOn {
+		res.RetryOn = append(res.RetryOn, regexp.MustCompile(pattern))
+	}
+	return res
+}
@@ -5,7 +5,9 @@ package hcl2template
 
 import (
 	"path/filepath"
+	"regexp"
 	"testing"
+	"time"
 
 	. "github.com/hashicorp/packer/hcl2template/internal"
 	"github.com/hashicorp/packer/packer"
@@ -719,6 +721,496 @@ func TestParse_build(t *testing.T) {
 			false,
 			nil,
 		},
+		{"build and source retry blocks",
+			defaultParser,
+			parseTestArgs{"testdata/build/retry.pkr.hcl", nil, nil},
+			&PackerConfig{
+				CorePackerVersionString: lockedVersion,
+				Basedir:                 filepath.Join("testdata", "build"),
+				Sources: map[SourceRef]SourceBlock{
+					refVBIsoUbuntu1204:  {Type: "virtualbox-iso", Name: "ubuntu-1204"},
+					refAWSEBSUbuntu1604: {Type: "amazon-ebs", Name: "ubuntu-1604"},
+				},
+				Builds: Builds{
+					&BuildBlock{
+						Retry: &RetryBlock{
+							Attempts: 3,
+							Backoff:  30 * time.Second,
+						},
+						Sources: []SourceUseBlock{
+							{
+								SourceRef: refVBIsoUbuntu1204,
+								Retry: &RetryBlock{
+									Attempts: 2,
+									RetryOn:  []string{"(?i)timeout"},
+								},
+							},
+							{
+								SourceRef: refAWSEBSUbuntu1604,
+							},
+						},
+					},
+				},
+			},
+			false, false,
+			[]*packer.CoreBuild{
+				&packer.CoreBuild{
+					Type:          "virtualbox-iso.ubuntu-1204",
+					BuilderType:   "virtualbox-iso",
+					Prepared:      true,
+					Builder:       emptyMockBuilder,
+					SensitiveVars: []string{},
+					Retry: packer.BuildRetry{
+						Attempts: 2,
+						RetryOn:  []*regexp.Regexp{regexp.MustCompile("(?i)timeout")},
+					},
+					Provisioners:   []packer.CoreBuildProvisioner{},
+					PostProcessors: [][]packer.CoreBuildPostProcessor{},
+				},
+				&packer.CoreBuild{
+					Type:          "amazon-ebs.ubuntu-1604",
+					BuilderType:   "amazon-ebs",
+					Prepared:      true,
+					Builder:       emptyMockBuilder,
+					SensitiveVars: []string{},
+					Retry: packer.BuildRetry{
+						Attempts: 3,
+						Backoff:  30 * time.Second,
+					},
+					Provisioners:   []packer.CoreBuildProvisioner{},
+					PostProcessors: [][]packer.CoreBuildPostProcessor{},
+				},
+			},
+			false,
+			nil,
+		},
+		{"build and source timeouts",
+			defaultParser,
+			parseTestArgs{"testdata/build/timeout.pkr.hcl", nil, nil},
+			&PackerConfig{
+				CorePackerVersionString: lockedVersion,
+				Basedir:                 filepath.Join("testdata", "build"),
+				Sources: map[SourceRef]SourceBlock{
+					refVBIsoUbuntu1204:  {Type: "virtualbox-iso", Name: "ubuntu-1204"},
+					refAWSEBSUbuntu1604: {Type: "amazon-ebs", Name: "ubuntu-1604"},
+				},
+				Builds: Builds{
+					&BuildBlock{
+						Timeout: time.Hour,
+						Sources: []SourceUseBlock{
+							{
+								SourceRef: refVBIsoUbuntu1204,
+								Timeout:   90 * time.Minute,
+							},
+							{
+								SourceRef: refAWSEBSUbuntu1604,
+							},
+						},
+					},
+				},
+			},
+			false, false,
+			[]*packer.CoreBuild{
+				&packer.CoreBuild{
+					Type:           "virtualbox-iso.ubuntu-1204",
+					BuilderType:    "virtualbox-iso",
+					Prepared:       true,
+					Builder:        emptyMockBuilder,
+					SensitiveVars:  []string{},
+					Timeout:        90 * time.Minute,
+					Provisioners:   []packer.CoreBuildProvisioner{},
+					PostProcessors: [][]packer.CoreBuildPostProcessor{},
+				},
+				&packer.CoreBuild{
+					Type:           "amazon-ebs.ubuntu-1604",
+					BuilderType:    "amazon-ebs",
+					Prepared:       true,
+					Builder:        emptyMockBuilder,
+					SensitiveVars:  []string{},
+					Timeout:        time.Hour,
+					Provisioners:   []packer.CoreBuildProvisioner{},
+					PostProcessors: [][]packer.CoreBuildPostProcessor{},
+				},
+			},
+			false,
+			nil,
+		},
+		{"source concurrency group",
+			defaultParser,
+			parseTestArgs{"testdata/build/concurrency_group.pkr.hcl", nil, nil},
+			&PackerConfig{
+				CorePackerVersionString: lockedVersion,
+				Basedir:                 filepath.Join("testdata", "build"),
+				Sources: map[SourceRef]SourceBlock{
+					refVBIsoUbuntu1204: {Type: "virtualbox-iso", Name: "ubuntu-1204"},
+				},
+				Builds: Builds{
+					&BuildBlock{
+						Sources: []SourceUseBlock{
+							{
+								SourceRef:        refVBIsoUbuntu1204,
+								ConcurrencyGroup: "vbox",
+							},
+						},
+					},
+				},
+			},
+			false, false,
+			[]*packer.CoreBuild{
+				&packer.CoreBuild{
+					Type:             "virtualbox-iso.ubuntu-1204",
+					BuilderType:      "virtualbox-iso",
+					ConcurrencyGroup: "vbox",
+					Prepared:         true,
+					Builder:          emptyMockBuilder,
+					SensitiveVars:    []string{},
+					Provisioners:     []packer.CoreBuildProvisioner{},
+					PostProcessors:   [][]packer.CoreBuildPostProcessor{},
+				},
+			},
+			false,
+			nil,
+		},
+		{"parallel post-processors",
+			defaultParser,
+			parseTestArgs{"testdata/build/post-processors_parallel.pkr.hcl", nil, nil},
+			&PackerConfig{
+				CorePackerVersionString: lockedVersion,
+				Basedir:                 filepath.Join("testdata", "build"),
+				Sources: map[SourceRef]SourceBlock{
+					refVBIsoUbuntu1204: {Type: "virtualbox-iso", Name: "ubuntu-1204"},
+				},
+				Builds: Builds{
+					&BuildBlock{
+						PostProcessorsParallel: true,
+						Sources: []SourceUseBlock{
+							{
+								SourceRef: refVBIsoUbuntu1204,
+							},
+						},
+						PostProcessorsLists: [][]*PostProcessorBlock{
+							{
+								{
+									PType: "amazon-import",
+								},
+							},
+							{
+								{
+									PType: "manifest",
+								},
+							},
+						},
+					},
+				},
+			},
+			false, false,
+			[]*packer.CoreBuild{
+				&packer.CoreBuild{
+					Type:                   "virtualbox-iso.ubuntu-1204",
+					BuilderType:            "virtualbox-iso",
+					Prepared:               true,
+					Builder:                emptyMockBuilder,
+					SensitiveVars:          []string{},
+					Provisioners:           []packer.CoreBuildProvisioner{},
+					PostProcessorsParallel: true,
+					PostProcessors: [][]packer.CoreBuildPostProcessor{
+						{
+							{
+								PType: "amazon-import",
+								PostProcessor: &HCL2PostProcessor{
+									PostProcessor: &MockPostProcessor{
+										Config: MockConfig{
+											NestedMockConfig: NestedMockConfig{Tags: []MockTag{}},
+											NestedSlice:      []NestedMockConfig{},
+										},
+									},
+								},
+							},
+						},
+						{
+							{
+								PType: "manifest",
+								PostProcessor: &HCL2PostProcessor{
+									PostProcessor: &MockPostProcessor{
+										Config: MockConfig{
+											NestedMockConfig: NestedMockConfig{Tags: []MockTag{}},
+											NestedSlice:      []NestedMockConfig{},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			false,
+			nil,
+		},
+		{"post-processor on_error",
+			defaultParser,
+			parseTestArgs{"testdata/build/post-processor_on_error.pkr.hcl", nil, nil},
+			&PackerConfig{
+				CorePackerVersionString: lockedVersion,
+				Basedir:                 filepath.Join("testdata", "build"),
+				Sources: map[SourceRef]SourceBlock{
+					refVBIsoUbuntu1204: {Type: "virtualbox-iso", Name: "ubuntu-1204"},
+				},
+				Builds: Builds{
+					&BuildBlock{
+						Sources: []SourceUseBlock{
+							{
+								SourceRef: refVBIsoUbuntu1204,
+							},
+						},
+						PostProcessorsLists: [][]*PostProcessorBlock{
+							{
+								{
+									PType:   "amazon-import",
+									OnError: "warn",
+								},
+								{
+									PType:   "manifest",
+									OnError: "ignore",
+								},
+							},
+							{
+								{
+									PType: "manifest",
+								},
+							},
+						},
+					},
+				},
+			},
+			false, false,
+			[]*packer.CoreBuild{
+				&packer.CoreBuild{
+					Type:          "virtualbox-iso.ubuntu-1204",
+					BuilderType:   "virtualbox-iso",
+					Prepared:      true,
+					Builder:       emptyMockBuilder,
+					SensitiveVars: []string{},
+					Provisioners:  []packer.CoreBuildProvisioner{},
+					PostProcessors: [][]packer.CoreBuildPostProcessor{
+						{
+							{
+								PType:   "amazon-import",
+								OnError: "warn",
+								PostProcessor: &HCL2PostProcessor{
+									PostProcessor: &MockPostProcessor{
+										Config: MockConfig{
+											NestedMockConfig: NestedMockConfig{Tags: []MockTag{}},
+											NestedSlice:      []NestedMockConfig{},
+										},
+									},
+								},
+							},
+							{
+								PType:   "manifest",
+								OnError: "ignore",
+								PostProcessor: &HCL2PostProcessor{
+									PostProcessor: &MockPostProcessor{
+										Config: MockConfig{
+											NestedMockConfig: NestedMockConfig{Tags: []MockTag{}},
+											NestedSlice:      []NestedMockConfig{},
+										},
+									},
+								},
+							},
+						},
+						{
+							{
+								PType: "manifest",
+								PostProcessor: &HCL2PostProcessor{
+									PostProcessor: &MockPostProcessor{
+										Config: MockConfig{
+											NestedMockConfig: NestedMockConfig{Tags: []MockTag{}},
+											NestedSlice:      []NestedMockConfig{},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			false,
+			nil,
+		},
+		{"post-processor invalid on_error",
+			defaultParser,
+			parseTestArgs{"testdata/build/post-processor_on_error_invalid.pkr.hcl", nil, nil},
+			&PackerConfig{
+				CorePackerVersionString: lockedVersion,
+				Basedir:                 filepath.Join("testdata", "build"),
+				Sources: map[SourceRef]SourceBlock{
+					refVBIsoUbuntu1204: {Type: "virtualbox-iso", Name: "ubuntu-1204"},
+				},
+				Builds: nil,
+			},
+			true, true,
+			[]*packer.CoreBuild{},
+			false,
+			nil,
+		},
+		{"source for_each and count",
+			defaultParser,
+			parseTestArgs{"testdata/build/for_each.pkr.hcl", nil, nil},
+			&PackerConfig{
+				CorePackerVersionString: lockedVersion,
+				Basedir:                 filepath.Join("testdata", "build"),
+				Sources: map[SourceRef]SourceBlock{
+					refVBIsoUbuntu1204:  {Type: "virtualbox-iso", Name: "ubuntu-1204"},
+					refAWSEBSUbuntu1604: {Type: "amazon-ebs", Name: "ubuntu-1604"},
+				},
+				Builds: Builds{
+					&BuildBlock{
+						Sources: []SourceUseBlock{
+							{
+								SourceRef:   refAWSEBSUbuntu1604,
+								InstanceKey: cty.StringVal("eu-west-1"),
+								EachValue:   cty.StringVal("ami-2"),
+							},
+							{
+								SourceRef:   refAWSEBSUbuntu1604,
+								InstanceKey: cty.StringVal("us-east-1"),
+								EachValue:   cty.StringVal("ami-1"),
+							},
+							{
+								SourceRef:   refVBIsoUbuntu1204,
+								InstanceKey: cty.NumberIntVal(0),
+							},
+							{
+								SourceRef:   refVBIsoUbuntu1204,
+								InstanceKey: cty.NumberIntVal(1),
+							},
+						},
+					},
+				},
+			},
+			false, false,
+			[]*packer.CoreBuild{
+				&packer.CoreBuild{
+					Type:           `amazon-ebs.ubuntu-1604["eu-west-1"]`,
+					BuilderType:    "amazon-ebs",
+					Prepared:       true,
+					Builder:        emptyMockBuilder,
+					SensitiveVars:  []string{},
+					Provisioners:   []packer.CoreBuildProvisioner{},
+					PostProcessors: [][]packer.CoreBuildPostProcessor{},
+				},
+				&packer.CoreBuild{
+					Type:           `amazon-ebs.ubuntu-1604["us-east-1"]`,
+					BuilderType:    "amazon-ebs",
+					Prepared:       true,
+					Builder:        emptyMockBuilder,
+					SensitiveVars:  []string{},
+					Provisioners:   []packer.CoreBuildProvisioner{},
+					PostProcessors: [][]packer.CoreBuildPostProcessor{},
+				},
+				&packer.CoreBuild{
+					Type:           `virtualbox-iso.ubuntu-1204[0]`,
+					BuilderType:    "virtualbox-iso",
+					Prepared:       true,
+					Builder:        emptyMockBuilder,
+					SensitiveVars:  []string{},
+					Provisioners:   []packer.CoreBuildProvisioner{},
+					PostProcessors: [][]packer.CoreBuildPostProcessor{},
+				},
+				&packer.CoreBuild{
+					Type:           `virtualbox-iso.ubuntu-1204[1]`,
+					BuilderType:    "virtualbox-iso",
+					Prepared:       true,
+					Builder:        emptyMockBuilder,
+					SensitiveVars:  []string{},
+					Provisioners:   []packer.CoreBuildProvisioner{},
+					PostProcessors: [][]packer.CoreBuildPostProcessor{},
+				},
+			},
+			false,
+			nil,
+		},
+		{"source with for_each and count",
+			defaultParser,
+			parseTestArgs{"testdata/build/for_each_and_count.pkr.hcl", nil, nil},
+			&PackerConfig{
+				CorePackerVersionString: lockedVersion,
+				Basedir:                 filepath.Join("testdata", "build"),
+				Sources: map[SourceRef]SourceBlock{
+					refVBIsoUbuntu1204: {Type: "virtualbox-iso", Name: "ubuntu-1204"},
+				},
+			},
+			true, true,
+			[]*packer.CoreBuild{},
+			false,
+			nil,
+		},
+		{"source with zero instances",
+			defaultParser,
+			parseTestArgs{"testdata/build/count_zero_partial.pkr.hcl", nil, nil},
+			&PackerConfig{
+				CorePackerVersionString: lockedVersion,
+				Basedir:                 filepath.Join("testdata", "build"),
+				Sources: map[SourceRef]SourceBlock{
+					refVBIsoUbuntu1204:  {Type: "virtualbox-iso", Name: "ubuntu-1204"},
+					refAWSEBSUbuntu1604: {Type: "amazon-ebs", Name: "ubuntu-1604"},
+				},
+				Builds: Builds{
+					&BuildBlock{
+						Sources: []SourceUseBlock{
+							{
+								SourceRef:   refVBIsoUbuntu1204,
+								InstanceKey: cty.NumberIntVal(0),
+							},
+						},
+					},
+				},
+			},
+			true, false,
+			[]*packer.CoreBuild{
+				&packer.CoreBuild{
+					Type:           `virtualbox-iso.ubuntu-1204[0]`,
+					BuilderType:    "virtualbox-iso",
+					Prepared:       true,
+					Builder:        emptyMockBuilder,
+					SensitiveVars:  []string{},
+					Provisioners:   []packer.CoreBuildProvisioner{},
+					PostProcessors: [][]packer.CoreBuildPostProcessor{},
+				},
+			},
+			false,
+			nil,
+		},
+		{"sources all with zero instances",
+			defaultParser,
+			parseTestArgs{"testdata/build/count_zero.pkr.hcl", nil, nil},
+			&PackerConfig{
+				CorePackerVersionString: lockedVersion,
+				Basedir:                 filepath.Join("testdata", "build"),
+				Sources: map[SourceRef]SourceBlock{
+					refVBIsoUbuntu1204: {Type: "virtualbox-iso", Name: "ubuntu-1204"},
+				},
+			},
+			true, true,
+			[]*packer.CoreBuild{},
+			false,
+			nil,
+		},
+		{"source with invalid for_each",
+			defaultParser,
+			parseTestArgs{"testdata/build/for_each_invalid.pkr.hcl", nil, nil},
+			&PackerConfig{
+				CorePackerVersionString: lockedVersion,
+				Basedir:                 filepath.Join("testdata", "build"),
+				Sources: map[SourceRef]SourceBlock{
+					refVBIsoUbuntu1204: {Type: "virtualbox-iso", Name: "ubuntu-1204"},
+				},
+			},
+			true, true,
+			[]*packer.CoreBuild{},
+			false,
+			nil,
+		},
 	}
 	testParse(t, tests)
 }
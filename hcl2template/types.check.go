@@ -0,0 +1,213 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package hcl2template
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer/packer"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const assertLabel = "assert"
+
+var checkBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "on_failure"},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: assertLabel},
+	},
+}
+
+// checkOnFailureValues are the accepted values of the on_failure argument of
+// a check block.
+var checkOnFailureValues = []string{"error", "warn"}
+
+// CheckBlock references an HCL 'check' block. Its assertions are evaluated
+// once each build completed:
+//
+//	check "artifacts" {
+//	  assert {
+//	    condition     = length(build.artifacts) > 0
+//	    error_message = "The build produced no artifact."
+//	  }
+//	}
+type CheckBlock struct {
+	// Name of the check, given as the block label
+	Name string
+
+	// OnFailure tells how a failed assertion is reported: "error" fails the
+	// build, "warn" only reports it. Defaults to "error".
+	OnFailure string
+
+	Asserts []*CheckRule
+
+	block *hcl.Block
+}
+
+func (p *Parser) decodeCheck(block *hcl.Block) (*CheckBlock, hcl.Diagnostics) {
+	check := &CheckBlock{
+		Name:  block.Labels[0],
+		block: block,
+	}
+	var diags hcl.Diagnostics
+
+	if !hclsyntax.ValidIdentifier(check.Name) {
+		return nil, append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid " + checkLabel + " name",
+			Detail:   badIdentifierDetail,
+			Subject:  &block.LabelRanges[0],
+		})
+	}
+
+	content, moreDiags := block.Body.Content(checkBlockSchema)
+	diags = append(diags, moreDiags...)
+	if moreDiags.HasErrors() {
+		return nil, diags
+	}
+
+	if attr, found := content.Attributes["on_failure"]; found {
+		moreDiags := gohcl.DecodeExpression(attr.Expr, nil, &check.OnFailure)
+		diags = append(diags, moreDiags...)
+		if moreDiags.HasErrors() {
+			return nil, diags
+		}
+		valid := false
+		for _, value := range checkOnFailureValues {
+			if check.OnFailure == value {
+				valid = true
+			}
+		}
+		if !valid {
+			return nil, append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid on_failure value",
+				Detail: fmt.Sprintf("on_failure must be one of %q, got %q.",
+					checkOnFailureValues, check.OnFailure),
+				Subject: attr.Expr.Range().Ptr(),
+			})
+		}
+	}
+
+	for _, block := range content.Blocks {
+		rule, moreDiags := decodeCheckRule(block)
+		diags = append(diags, moreDiags...)
+		if moreDiags.HasErrors() {
+			continue
+		}
+		check.Asserts = append(check.Asserts, rule)
+	}
+	if len(check.Asserts) == 0 && !diags.HasErrors() {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Missing " + assertLabel + " block",
+			Detail:   "A " + checkLabel + " block must contain at least one " + assertLabel + " block.",
+			Subject:  block.DefRange.Ptr(),
+		})
+	}
+
+	return check, diags
+}
+
+// getCoreBuildChecks returns the checks of the config, to run once a build
+// completed.
+func (cfg *PackerConfig) getCoreBuildChecks(ectx *hcl.EvalContext) []packer.CoreBuildCheck {
+	var res []packer.CoreBuildCheck
+	for _, check := range cfg.Checks {
+		res = append(res, packer.CoreBuildCheck{
+			Name:      check.Name,
+			OnFailure: check.OnFailure,
+			Check: &HCL2Check{
+				check:       check,
+				evalContext: ectx,
+			},
+		})
+	}
+	return res
+}
+
+// HCL2Check evaluates the assertions of a check block against the artifacts
+// of a build. The artifacts and the data generated by the build are
+// available in the `build` variable.
+type HCL2Check struct {
+	check       *CheckBlock
+	evalContext *hcl.EvalContext
+}
+
+func (c *HCL2Check) Check(artifacts []packersdk.Artifact) error {
+	buildValues := map[string]cty.Value{}
+	if val, found := c.evalContext.Variables[buildAccessor]; found && !val.IsNull() && val.IsKnown() {
+		buildValues = val.AsValueMap()
+	}
+
+	artifactsVal, generatedData, err := artifactsValues(artifacts)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range generatedData {
+		buildValues[k] = v
+	}
+	buildValues["generated_data"] = cty.ObjectVal(generatedData)
+	buildValues["artifacts"] = artifactsVal
+
+	ectx := c.evalContext.NewChild()
+	ectx.Variables = map[string]cty.Value{
+		buildAccessor: cty.ObjectVal(buildValues),
+	}
+
+	diags := checkRules("Assertion", c.check.Asserts, ectx)
+	if diags.HasErrors() {
+		return diags
+	}
+	return nil
+}
+
+// artifactsValues returns the artifacts of a build as a tuple of objects with
+// their id, builder_id, files and string, and the data generated by the build.
+func artifactsValues(artifacts []packersdk.Artifact) (cty.Value, map[string]cty.Value, error) {
+	generatedData := map[string]cty.Value{}
+	artifactValues := []cty.Value{}
+	for _, artifact := range artifacts {
+		if artifact == nil {
+			continue
+		}
+		if data, ok := artifact.State("generated_data").(map[interface{}]interface{}); ok {
+			for k, v := range data {
+				key, ok := k.(string)
+				if !ok {
+					return cty.NilVal, nil, fmt.Errorf("generated data key %v is a %T, not a string", k, k)
+				}
+				val, err := ConvertPluginConfigValueToHCLValue(v)
+				if err != nil {
+					return cty.NilVal, nil, err
+				}
+				generatedData[key] = val
+			}
+		}
+
+		files := cty.ListValEmpty(cty.String)
+		if len(artifact.Files()) > 0 {
+			values := make([]cty.Value, 0, len(artifact.Files()))
+			for _, file := range artifact.Files() {
+				values = append(values, cty.StringVal(file))
+			}
+			files = cty.ListVal(values)
+		}
+		artifactValues = append(artifactValues, cty.ObjectVal(map[string]cty.Value{
+			"id":         cty.StringVal(artifact.Id()),
+			"builder_id": cty.StringVal(artifact.BuilderId()),
+			"files":      files,
+			"string":     cty.StringVal(artifact.String()),
+		}))
+	}
+
+	return cty.TupleVal(artifactValues), generatedData, nil
+}
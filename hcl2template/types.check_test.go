@@ -0,0 +1,103 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package hcl2template
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer/packer"
+)
+
+func TestParse_check(t *testing.T) {
+	tests := []struct {
+		file        string
+		wantSummary string
+	}{
+		{"testdata/check/invalid_on_failure.pkr.hcl", "Invalid on_failure value"},
+		{"testdata/check/no_assert.pkr.hcl", "Missing assert block"},
+		{"testdata/check/duplicate.pkr.hcl", "Duplicate check block"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			cfg, diags := getBasicParser().Parse(tt.file, nil, nil)
+			if diags.HasErrors() {
+				t.Fatalf("Parse: %s", diags)
+			}
+			diags = cfg.Initialize(packer.InitializeOptions{})
+			if !diags.HasErrors() || diags.Errs()[0].(*hcl.Diagnostic).Summary != tt.wantSummary {
+				t.Fatalf("expected %q, got %s", tt.wantSummary, diags)
+			}
+		})
+	}
+}
+
+func TestHCL2Check(t *testing.T) {
+	cfg, diags := getBasicParser().Parse("testdata/check/basic.pkr.hcl", nil, nil)
+	if diags.HasErrors() {
+		t.Fatalf("Parse: %s", diags)
+	}
+	if diags := cfg.Initialize(packer.InitializeOptions{}); diags.HasErrors() {
+		t.Fatalf("Initialize: %s", diags)
+	}
+	builds, diags := cfg.GetBuilds(packer.GetBuildsOptions{})
+	if diags.HasErrors() {
+		t.Fatalf("GetBuilds: %s", diags)
+	}
+
+	checks := builds[0].Checks
+	if len(checks) != 2 ||
+		checks[0].Name != "artifacts" || checks[0].OnFailure != "" ||
+		checks[1].Name != "source_image" || checks[1].OnFailure != "warn" {
+		t.Fatalf("unexpected checks: %#v", checks)
+	}
+
+	artifact := func(sourceImage string, files ...string) packersdk.Artifact {
+		if files == nil {
+			files = []string{}
+		}
+		return &packersdk.MockArtifact{
+			IdValue:    "image",
+			FilesValue: files,
+			StateValues: map[string]interface{}{
+				"generated_data": map[interface{}]interface{}{
+					"SourceImage": sourceImage,
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		artifacts []packersdk.Artifact
+		wantErrs  []string
+	}{
+		{"passing", []packersdk.Artifact{artifact("ubuntu", "disk.img")}, []string{"", ""}},
+		// the generated data is unknown without artifact
+		{"no artifact", nil, []string{"The build produced no artifact.", "Unsupported attribute"}},
+		{"no file", []packersdk.Artifact{artifact("ubuntu")}, []string{"An artifact has no file.", ""}},
+		{"generated data", []packersdk.Artifact{artifact("debian", "disk.img")}, []string{"", "The build used debian instead of ubuntu."}},
+		{"non string generated data key", []packersdk.Artifact{&packersdk.MockArtifact{
+			FilesValue: []string{"disk.img"},
+			StateValues: map[string]interface{}{
+				"generated_data": map[interface{}]interface{}{1: "one"},
+			},
+		}}, []string{"generated data key 1 is a int, not a string", "generated data key 1 is a int, not a string"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, check := range checks {
+				err := check.Check.Check(tt.artifacts)
+				switch {
+				case tt.wantErrs[i] == "" && err != nil:
+					t.Fatalf("check %q: unexpected error: %s", check.Name, err)
+				case tt.wantErrs[i] != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErrs[i])):
+					t.Fatalf("check %q: expected %q, got %v", check.Name, tt.wantErrs[i], err)
+				}
+			}
+		})
+	}
+}
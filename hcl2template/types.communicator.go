@@ -0,0 +1,226 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package hcl2template
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// CommunicatorBlock references an HCL 'communicator' block. It allows to
+// define the settings of a communicator once, to use them from many sources:
+//
+//	communicator "ssh" "bastion" {
+//	  ssh_username     = "ubuntu"
+//	  ssh_bastion_host = "bastion.example.com"
+//	}
+//
+//	source "amazon-ebs" "example" {
+//	  communicator = communicator.ssh.bastion
+//	}
+type CommunicatorBlock struct {
+	// Type of communicator; ex: ssh
+	Type string
+	// Given name
+	Name string
+
+	block *hcl.Block
+	// attributes are the settings of the communicator
+	attributes hcl.Attributes
+}
+
+// CommunicatorRef is the address of a communicator block, for example
+// `communicator.ssh.bastion`.
+type CommunicatorRef struct {
+	Type string
+	Name string
+}
+
+func (r CommunicatorRef) String() string {
+	return fmt.Sprintf("%s.%s.%s", communicatorLabel, r.Type, r.Name)
+}
+
+func (c *CommunicatorBlock) Ref() CommunicatorRef {
+	return CommunicatorRef{
+		Type: c.Type,
+		Name: c.Name,
+	}
+}
+
+func (p *Parser) decodeCommunicator(block *hcl.Block) (*CommunicatorBlock, hcl.Diagnostics) {
+	communicator := &CommunicatorBlock{
+		Type:  block.Labels[0],
+		Name:  block.Labels[1],
+		block: block,
+	}
+	var diags hcl.Diagnostics
+
+	if !hclsyntax.ValidIdentifier(communicator.Type) {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid communicator type",
+			Detail:   badIdentifierDetail,
+			Subject:  &block.LabelRanges[0],
+		})
+	}
+	if !hclsyntax.ValidIdentifier(communicator.Name) {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid communicator name",
+			Detail:   badIdentifierDetail,
+			Subject:  &block.LabelRanges[1],
+		})
+	}
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	attrs, moreDiags := block.Body.JustAttributes()
+	diags = append(diags, moreDiags...)
+	if moreDiags.HasErrors() {
+		return nil, diags
+	}
+	if _, found := attrs["communicator"]; found {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Unsupported argument",
+			Detail: "The communicator type is set by the first label of a " +
+				communicatorLabel + " block.",
+			Subject: attrs["communicator"].NameRange.Ptr(),
+		})
+		return nil, diags
+	}
+	communicator.attributes = attrs
+
+	return communicator, diags
+}
+
+// expandCommunicator looks for a `communicator = communicator.TYPE.NAME`
+// argument in the body of a source and, when found, returns a body in which
+// the settings of the referenced communicator block were merged.
+//
+// Any other value of the communicator argument is left as is for the builder
+// to decode.
+func (cfg *PackerConfig) expandCommunicator(body hcl.Body) (hcl.Body, hcl.Diagnostics) {
+	content, rest, diags := body.PartialContent(&hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{
+			{Name: "communicator"},
+		},
+	})
+	if diags.HasErrors() {
+		return body, diags
+	}
+	attr, found := content.Attributes["communicator"]
+	if !found {
+		return body, diags
+	}
+	traversal, moreDiags := hcl.AbsTraversalForExpr(attr.Expr)
+	if moreDiags.HasErrors() || traversal.RootName() != communicatorLabel {
+		// Not a reference, like `communicator = "ssh"`.
+		return body, diags
+	}
+
+	ref, moreDiags := communicatorRefFromTraversal(traversal)
+	diags = append(diags, moreDiags...)
+	if moreDiags.HasErrors() {
+		return body, diags
+	}
+
+	communicator, found := cfg.Communicators[ref]
+	if !found {
+		available := make([]string, 0, len(cfg.Communicators))
+		for ref := range cfg.Communicators {
+			available = append(available, ref.String())
+		}
+		sort.Strings(available)
+		return body, append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Unknown " + communicatorLabel + " " + ref.String(),
+			Detail:   fmt.Sprintf("Known: %v", available),
+			Subject:  attr.Expr.Range().Ptr(),
+		})
+	}
+
+	// Settings cannot be set both in the communicator block and inline, as
+	// it would not be obvious which one wins.
+	names := make([]string, 0, len(communicator.attributes))
+	for name := range communicator.attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	schema := &hcl.BodySchema{}
+	for _, name := range names {
+		schema.Attributes = append(schema.Attributes, hcl.AttributeSchema{Name: name})
+	}
+	inline, _, moreDiags := rest.PartialContent(schema)
+	diags = append(diags, moreDiags...)
+	for _, name := range names {
+		inlineAttr, found := inline.Attributes[name]
+		if !found {
+			continue
+		}
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Conflicting " + communicatorLabel + " setting",
+			Detail: fmt.Sprintf("%q is set both in %s, declared at %s, and in this "+
+				"source. Remove one of them.",
+				name, ref, communicator.attributes[name].Range),
+			Subject: inlineAttr.Range.Ptr(),
+		})
+	}
+	if diags.HasErrors() {
+		return body, diags
+	}
+
+	// The reference is replaced with the type of the communicator, so that
+	// the builder can decode it.
+	typeBody := &hclsyntax.Body{
+		Attributes: hclsyntax.Attributes{
+			"communicator": &hclsyntax.Attribute{
+				Name: "communicator",
+				Expr: &hclsyntax.LiteralValueExpr{
+					Val:      cty.StringVal(communicator.Type),
+					SrcRange: attr.Expr.Range(),
+				},
+				SrcRange:  attr.Range,
+				NameRange: attr.NameRange,
+			},
+		},
+		SrcRange: attr.Range,
+		EndRange: attr.Range,
+	}
+
+	return hcl.MergeBodies([]hcl.Body{rest, communicator.block.Body, typeBody}), diags
+}
+
+// communicatorRefFromTraversal reads a `communicator.TYPE.NAME` reference.
+func communicatorRefFromTraversal(traversal hcl.Traversal) (CommunicatorRef, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+	if len(traversal) != 3 {
+		return CommunicatorRef{}, append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid " + communicatorLabel + " reference",
+			Detail:   "A communicator reference must be of the form communicator.TYPE.NAME.",
+			Subject:  traversal.SourceRange().Ptr(),
+		})
+	}
+	var ref CommunicatorRef
+	for i, dst := range []*string{&ref.Type, &ref.Name} {
+		attr, ok := traversal[i+1].(hcl.TraverseAttr)
+		if !ok {
+			return CommunicatorRef{}, append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid " + communicatorLabel + " reference",
+				Detail:   "A communicator reference must be of the form communicator.TYPE.NAME.",
+				Subject:  traversal[i+1].SourceRange().Ptr(),
+			})
+		}
+		*dst = attr.Name
+	}
+	return ref, diags
+}
@@ -0,0 +1,152 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package hcl2template
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/packer/builder/null"
+	"github.com/hashicorp/packer/packer"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestParse_communicator(t *testing.T) {
+	defaultParser := getBasicParser()
+
+	nullSourceRef := SourceRef{Type: "null", Name: "test"}
+	bastionRef := CommunicatorRef{Type: "ssh", Name: "bastion"}
+
+	tests := []parseTest{
+		{"communicator reference",
+			defaultParser,
+			parseTestArgs{"testdata/communicator/reference.pkr.hcl", nil, nil},
+			&PackerConfig{
+				CorePackerVersionString: lockedVersion,
+				Basedir:                 filepath.Join("testdata", "communicator"),
+				Sources: map[SourceRef]SourceBlock{
+					nullSourceRef: {Type: "null", Name: "test"},
+				},
+				Communicators: map[CommunicatorRef]CommunicatorBlock{
+					bastionRef: {Type: "ssh", Name: "bastion"},
+				},
+				Builds: Builds{
+					&BuildBlock{
+						Sources: []SourceUseBlock{
+							{SourceRef: nullSourceRef},
+						},
+					},
+				},
+			},
+			false, false,
+			[]*packer.CoreBuild{
+				&packer.CoreBuild{
+					Type:           "null.test",
+					BuilderType:    "null",
+					Builder:        &null.Builder{},
+					Provisioners:   []packer.CoreBuildProvisioner{},
+					PostProcessors: [][]packer.CoreBuildPostProcessor{},
+					SensitiveVars:  []string{},
+					Prepared:       true,
+				},
+			},
+			false,
+			nil,
+		},
+		{"unknown communicator reference",
+			defaultParser,
+			parseTestArgs{"testdata/communicator/unknown.pkr.hcl", nil, nil},
+			&PackerConfig{
+				CorePackerVersionString: lockedVersion,
+				Basedir:                 filepath.Join("testdata", "communicator"),
+				Sources: map[SourceRef]SourceBlock{
+					nullSourceRef: {Type: "null", Name: "test"},
+				},
+				Communicators: map[CommunicatorRef]CommunicatorBlock{
+					bastionRef: {Type: "ssh", Name: "bastion"},
+				},
+				Builds: Builds{
+					&BuildBlock{
+						Sources: []SourceUseBlock{
+							{SourceRef: nullSourceRef},
+						},
+					},
+				},
+			},
+			true, true,
+			nil,
+			false,
+			nil,
+		},
+		{"communicator setting also set inline",
+			defaultParser,
+			parseTestArgs{"testdata/communicator/conflict.pkr.hcl", nil, nil},
+			&PackerConfig{
+				CorePackerVersionString: lockedVersion,
+				Basedir:                 filepath.Join("testdata", "communicator"),
+				Sources: map[SourceRef]SourceBlock{
+					nullSourceRef: {Type: "null", Name: "test"},
+				},
+				Communicators: map[CommunicatorRef]CommunicatorBlock{
+					bastionRef: {Type: "ssh", Name: "bastion"},
+				},
+				Builds: Builds{
+					&BuildBlock{
+						Sources: []SourceUseBlock{
+							{SourceRef: nullSourceRef},
+						},
+					},
+				},
+			},
+			true, true,
+			nil,
+			false,
+			nil,
+		},
+		{"duplicate communicator",
+			defaultParser,
+			parseTestArgs{"testdata/communicator/duplicate.pkr.hcl", nil, nil},
+			&PackerConfig{
+				CorePackerVersionString: lockedVersion,
+				Basedir:                 filepath.Join("testdata", "communicator"),
+				Communicators: map[CommunicatorRef]CommunicatorBlock{
+					bastionRef: {Type: "ssh", Name: "bastion"},
+				},
+			},
+			true, true,
+			nil,
+			false,
+			nil,
+		},
+	}
+	testParse(t, tests)
+}
+
+func TestParse_communicator_merged(t *testing.T) {
+	cfg, diags := getBasicParser().Parse("testdata/communicator/reference.pkr.hcl", nil, nil)
+	if diags.HasErrors() {
+		t.Fatalf("Parse: %s", diags)
+	}
+	if diags := cfg.Initialize(packer.InitializeOptions{}); diags.HasErrors() {
+		t.Fatalf("Initialize: %s", diags)
+	}
+	builds, diags := cfg.GetBuilds(packer.GetBuildsOptions{})
+	if diags.HasErrors() {
+		t.Fatalf("GetBuilds: %s", diags)
+	}
+	if len(builds) != 1 {
+		t.Fatalf("expected one build, got %d", len(builds))
+	}
+
+	config := builds[0].HCLConfig
+	for attr, want := range map[string]cty.Value{
+		"communicator":     cty.StringVal("ssh"),
+		"ssh_host":         cty.StringVal("10.0.0.5"),
+		"ssh_bastion_host": cty.StringVal("bastion.example.com"),
+	} {
+		if got := config.GetAttr(attr); !got.RawEquals(want) {
+			t.Errorf("%s: got %#v, want %#v", attr, got, want)
+		}
+	}
+}
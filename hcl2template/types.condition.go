@@ -0,0 +1,221 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package hcl2template
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+const (
+	preconditionLabel  = "precondition"
+	postconditionLabel = "postcondition"
+
+	// selfAccessor gives access to the result of a block from its
+	// postconditions, like the output of a data source.
+	selfAccessor = "self"
+)
+
+// conditionsSchema is the schema of the condition blocks that can be set in
+// source, build, data and locals blocks.
+var conditionsSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: preconditionLabel},
+		{Type: postconditionLabel},
+	},
+}
+
+var checkRuleBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name:     "condition",
+			Required: true,
+		},
+		{
+			Name:     "error_message",
+			Required: true,
+		},
+	},
+}
+
+// CheckRule represents a 'precondition' or a 'postcondition' block:
+//
+//	data "http" "version" {
+//	  url = "https://example.com/version"
+//
+//	  postcondition {
+//	    condition     = self.status_code == 200
+//	    error_message = "The version could not be fetched."
+//	  }
+//	}
+type CheckRule struct {
+	// Condition is an expression that must return true for the check to
+	// pass.
+	Condition hcl.Expression
+
+	// ErrorMessage is an expression returning the message to show when the
+	// condition is false. It is evaluated in the same context as Condition.
+	ErrorMessage hcl.Expression
+
+	DeclRange hcl.Range
+}
+
+// Conditions are the checks of a block. Preconditions are checked before the
+// block is evaluated, postconditions after, and can access its result with
+// `self`.
+type Conditions struct {
+	Preconditions  []*CheckRule
+	Postconditions []*CheckRule
+}
+
+// decodeConditions decodes the precondition and postcondition blocks found in
+// blocks, other blocks are ignored.
+func decodeConditions(blocks hcl.Blocks) (Conditions, hcl.Diagnostics) {
+	var conditions Conditions
+	var diags hcl.Diagnostics
+
+	for _, block := range blocks {
+		switch block.Type {
+		case preconditionLabel:
+			rule, moreDiags := decodeCheckRule(block)
+			diags = append(diags, moreDiags...)
+			if !moreDiags.HasErrors() {
+				conditions.Preconditions = append(conditions.Preconditions, rule)
+			}
+		case postconditionLabel:
+			rule, moreDiags := decodeCheckRule(block)
+			diags = append(diags, moreDiags...)
+			if !moreDiags.HasErrors() {
+				conditions.Postconditions = append(conditions.Postconditions, rule)
+			}
+		}
+	}
+
+	return conditions, diags
+}
+
+func decodeCheckRule(block *hcl.Block) (*CheckRule, hcl.Diagnostics) {
+	content, diags := block.Body.Content(checkRuleBlockSchema)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	return &CheckRule{
+		Condition:    content.Attributes["condition"].Expr,
+		ErrorMessage: content.Attributes["error_message"].Expr,
+		DeclRange:    block.DefRange,
+	}, diags
+}
+
+// Empty tells whether there are no conditions to check.
+func (c Conditions) Empty() bool {
+	return len(c.Preconditions) == 0 && len(c.Postconditions) == 0
+}
+
+// preconditionReferences returns the references of the preconditions, which
+// have to be evaluated before them.
+func (c Conditions) preconditionReferences() []hcl.Traversal {
+	var refs []hcl.Traversal
+	for _, rule := range c.Preconditions {
+		refs = append(refs, rule.Condition.Variables()...)
+		refs = append(refs, rule.ErrorMessage.Variables()...)
+	}
+	return refs
+}
+
+// checkPreconditions checks the preconditions in ectx.
+func (c Conditions) checkPreconditions(ectx *hcl.EvalContext) hcl.Diagnostics {
+	return checkRules("Precondition", c.Preconditions, ectx)
+}
+
+// checkPostconditions checks the postconditions in ectx, with self set to the
+// result of the block.
+func (c Conditions) checkPostconditions(ectx *hcl.EvalContext, self cty.Value) hcl.Diagnostics {
+	if len(c.Postconditions) == 0 {
+		return nil
+	}
+	ectx = ectx.NewChild()
+	ectx.Variables = map[string]cty.Value{
+		selfAccessor: self,
+	}
+	return checkRules("Postcondition", c.Postconditions, ectx)
+}
+
+func checkRules(kind string, rules []*CheckRule, ectx *hcl.EvalContext) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+
+	for _, rule := range rules {
+		errInvalidCondition := fmt.Sprintf("Invalid %s result", kind)
+
+		result, moreDiags := rule.Condition.Value(ectx)
+		diags = append(diags, moreDiags...)
+		if moreDiags.HasErrors() {
+			continue
+		}
+		if !result.IsKnown() {
+			log.Printf("[TRACE] checkRules: %s at %s is unknown, so skipping it for now", kind, rule.DeclRange)
+			continue
+		}
+		if result.IsNull() {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity:    hcl.DiagError,
+				Summary:     errInvalidCondition,
+				Detail:      "Condition expression must return either true or false, not null.",
+				Subject:     rule.Condition.Range().Ptr(),
+				Expression:  rule.Condition,
+				EvalContext: ectx,
+			})
+			continue
+		}
+		var err error
+		result, err = convert.Convert(result, cty.Bool)
+		if err != nil {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity:    hcl.DiagError,
+				Summary:     errInvalidCondition,
+				Detail:      fmt.Sprintf("Invalid condition result value: %s.", err),
+				Subject:     rule.Condition.Range().Ptr(),
+				Expression:  rule.Condition,
+				EvalContext: ectx,
+			})
+			continue
+		}
+		// The result of a condition over sensitive values is sensitive too,
+		// but telling whether it passed reveals nothing worth hiding.
+		result, _ = result.UnmarkDeep()
+		if result.True() {
+			continue
+		}
+
+		message := "The condition was not met."
+		val, moreDiags := rule.ErrorMessage.Value(ectx)
+		switch {
+		case moreDiags.HasErrors():
+			diags = append(diags, moreDiags...)
+		case !val.IsKnown() || val.IsNull():
+		case val.ContainsMarked():
+			message = "The error message included a sensitive value, so it will not be displayed."
+		default:
+			if val, err := convert.Convert(val, cty.String); err == nil {
+				message = val.AsString()
+			}
+		}
+
+		diags = append(diags, &hcl.Diagnostic{
+			Severity:    hcl.DiagError,
+			Summary:     fmt.Sprintf("%s failed", kind),
+			Detail:      fmt.Sprintf("%s\n\nThis was checked by the %s at %s.", message, strings.ToLower(kind), rule.DeclRange.String()),
+			Subject:     rule.DeclRange.Ptr(),
+			Expression:  rule.Condition,
+			EvalContext: ectx,
+		})
+	}
+
+	return diags
+}
@@ -0,0 +1,76 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package hcl2template
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/packer/packer"
+)
+
+func TestParse_conditions(t *testing.T) {
+	tests := []struct {
+		fail        string
+		wantSummary string
+		wantDetail  string
+	}{
+		{"", "", ""},
+		{"data_pre", "Precondition failed", "Data precondition."},
+		{"data_post", "Postcondition failed", "Data postcondition."},
+		{"locals_pre", "Precondition failed", "Locals precondition."},
+		{"locals_post", "Postcondition failed", "Locals postcondition for version 1."},
+		{"source_pre", "Precondition failed", "Source precondition."},
+		{"source_post", "Postcondition failed", "Source postcondition."},
+		{"build_pre", "Precondition failed", "Build precondition."},
+		{"build_post", "Postcondition failed", "Build postcondition."},
+	}
+	for _, tt := range tests {
+		for _, sequential := range []bool{false, true} {
+			tt, sequential := tt, sequential
+			t.Run(fmt.Sprintf("%s/sequential=%t", tt.fail, sequential), func(t *testing.T) {
+				cfg, diags := getBasicParser().Parse("testdata/conditions", nil, map[string]string{"fail": tt.fail})
+				if diags.HasErrors() {
+					t.Fatalf("Parse: %s", diags)
+				}
+				diags = cfg.Initialize(packer.InitializeOptions{UseSequential: sequential})
+				if !diags.HasErrors() {
+					_, diags = cfg.GetBuilds(packer.GetBuildsOptions{})
+				}
+
+				if tt.wantSummary == "" {
+					if diags.HasErrors() {
+						t.Fatalf("unexpected diags: %s", diags)
+					}
+					return
+				}
+				if !diags.HasErrors() {
+					t.Fatalf("expected %q, got no error", tt.wantSummary)
+				}
+				diag := diags.Errs()[0].(*hcl.Diagnostic)
+				if diag.Summary != tt.wantSummary || !strings.HasPrefix(diag.Detail, tt.wantDetail) {
+					t.Fatalf("unexpected diag: %s", diags)
+				}
+				if diag.Subject == nil || !strings.HasSuffix(diag.Subject.Filename, "conditions.pkr.hcl") {
+					t.Fatalf("diag should point at the condition block, got %v", diag.Subject)
+				}
+			})
+		}
+	}
+}
+
+func TestParse_localsUnexpectedBlock(t *testing.T) {
+	_, diags := getBasicParser().Parse("testdata/variables/unexpected_locals_block.pkr.hcl", nil, nil)
+	if !diags.HasErrors() {
+		t.Fatal("expected an error for the bogus block")
+	}
+	if len(diags.Errs()) != 1 {
+		t.Fatalf("only the bogus block should be rejected, got %s", diags)
+	}
+	if diag := diags.Errs()[0].(*hcl.Diagnostic); diag.Summary != `Unexpected "bogus" block` {
+		t.Fatalf("unexpected diag: %s", diags)
+	}
+}
@@ -18,9 +18,15 @@ type DatasourceBlock struct {
 	Type         string
 	DSName       string
 	Dependencies []refString
+	// variables are the input variables referenced by the datasource, they
+	// are evaluated beforehand.
+	variables []refString
 
 	value cty.Value
 	block *hcl.Block
+	// body is the body of the block, without its conditions.
+	body       hcl.Body
+	conditions Conditions
 }
 
 type DatasourceRef struct {
@@ -113,7 +119,7 @@ func (cfg *PackerConfig) startDatasource(ds DatasourceBlock) (packersdk.Datasour
 
 	var decoded cty.Value
 	var moreDiags hcl.Diagnostics
-	body := block.Body
+	body := ds.body
 	decoded, moreDiags = decodeHCL2Spec(body, cfg.EvalContext(DatasourceContext, nil), datasource)
 
 	diags = append(diags, moreDiags...)
@@ -162,5 +168,11 @@ func (p *Parser) decodeDataBlock(block *hcl.Block) (*DatasourceBlock, hcl.Diagno
 		})
 	}
 
+	content, rest, moreDiags := block.Body.PartialContent(conditionsSchema)
+	diags = append(diags, moreDiags...)
+	r.body = rest
+	r.conditions, moreDiags = decodeConditions(content.Blocks)
+	diags = append(diags, moreDiags...)
+
 	return r, diags
 }
@@ -0,0 +1,291 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package hcl2template
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/packer/packer"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// ModuleBlock references an HCL 'module' block. A module loads the config
+// files of another directory as a child configuration, with its own variables,
+// and adds its builds to the ones of the current configuration:
+//
+//	module "base" {
+//	  source = "./modules/base"
+//
+//	  # inputs of the module
+//	  image_name = "base-${var.version}"
+//	}
+//
+// The builds of the module are named after it, like `module.base.build.x`.
+type ModuleBlock struct {
+	// Name of the module, given as the block label
+	Name string
+	// Source is the path of the directory of the module, relative to the
+	// directory of the configuration. Only local paths are supported.
+	Source string
+
+	// Config is the configuration loaded from Source, it is set once the
+	// module is initialized.
+	Config *PackerConfig
+
+	// inputs are the values to set to the variables of the module.
+	inputs      hcl.Attributes
+	sourceRange hcl.Range
+	block       *hcl.Block
+}
+
+func (m *ModuleBlock) String() string {
+	return moduleLabel + "." + m.Name
+}
+
+func (p *Parser) decodeModule(block *hcl.Block) (*ModuleBlock, hcl.Diagnostics) {
+	module := &ModuleBlock{
+		Name:  block.Labels[0],
+		block: block,
+	}
+	var diags hcl.Diagnostics
+
+	if !hclsyntax.ValidIdentifier(module.Name) {
+		return nil, append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid module name",
+			Detail:   badIdentifierDetail,
+			Subject:  &block.LabelRanges[0],
+		})
+	}
+
+	attrs, moreDiags := block.Body.JustAttributes()
+	diags = append(diags, moreDiags...)
+	if moreDiags.HasErrors() {
+		return nil, diags
+	}
+
+	source, found := attrs["source"]
+	if !found {
+		return nil, append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Missing required argument",
+			Detail:   "The argument \"source\" is required, but no definition was found.",
+			Subject:  block.DefRange.Ptr(),
+		})
+	}
+	delete(attrs, "source")
+
+	// The source is needed before variables can be evaluated, so it has to
+	// be a literal value.
+	moreDiags = gohcl.DecodeExpression(source.Expr, nil, &module.Source)
+	diags = append(diags, moreDiags...)
+	if moreDiags.HasErrors() {
+		return nil, diags
+	}
+	module.sourceRange = source.Expr.Range()
+
+	if !strings.HasPrefix(module.Source, "./") && !strings.HasPrefix(module.Source, "../") {
+		return nil, append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Unsupported module source",
+			Detail: fmt.Sprintf("The source of a module must be a local path starting "+
+				"with ./ or ../, got %q.", module.Source),
+			Subject: &module.sourceRange,
+		})
+	}
+
+	module.inputs = attrs
+
+	return module, diags
+}
+
+// initializeModules loads and initializes the configuration of the module
+// blocks, after setting the variables of each module from its inputs.
+func (cfg *PackerConfig) initializeModules(opts packer.InitializeOptions) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+
+	if len(cfg.Modules) == 0 {
+		return diags
+	}
+
+	// The directories of the configurations including this one, to detect
+	// modules that include themselves.
+	absBasedir, err := filepath.Abs(cfg.Basedir)
+	if err != nil {
+		return append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Failed to resolve the configuration directory",
+			Detail:   err.Error(),
+		})
+	}
+	parentDirs := append(append([]string{}, cfg.moduleDirs...), absBasedir)
+
+	names := make([]string, 0, len(cfg.Modules))
+	for name := range cfg.Modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		module := cfg.Modules[name]
+
+		dir := filepath.Join(cfg.Basedir, module.Source)
+		absDir, err := filepath.Abs(dir)
+		if err != nil {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Failed to resolve module source",
+				Detail:   err.Error(),
+				Subject:  &module.sourceRange,
+			})
+			continue
+		}
+		cycle := false
+		for _, parentDir := range parentDirs {
+			if parentDir == absDir {
+				cycle = true
+			}
+		}
+		if cycle {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Module cycle",
+				Detail:   fmt.Sprintf("The module %q includes itself through %q.", module.Name, module.Source),
+				Subject:  &module.sourceRange,
+			})
+			continue
+		}
+
+		// The variables of modules can be set from the environment too,
+		// module inputs take precedence.
+		child, moreDiags := cfg.parser.parse(dir, nil, nil, cfg.env, false)
+		diags = append(diags, moreDiags...)
+		if moreDiags.HasErrors() {
+			continue
+		}
+		child.modulePrefix = module.String()
+		if cfg.modulePrefix != "" {
+			child.modulePrefix = cfg.modulePrefix + "." + child.modulePrefix
+		}
+		child.moduleDirs = parentDirs
+
+		moreDiags = cfg.setModuleInputs(module, child)
+		diags = append(diags, moreDiags...)
+		if moreDiags.HasErrors() {
+			continue
+		}
+
+		diags = append(diags, child.Initialize(opts)...)
+		module.Config = child
+	}
+
+	return diags
+}
+
+// setModuleInputs sets the values of the inputs of a module to the variables
+// of its configuration. These values take precedence over the defaults of the
+// variables.
+func (cfg *PackerConfig) setModuleInputs(module *ModuleBlock, child *PackerConfig) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+
+	ectx := cfg.EvalContext(LocalContext, nil)
+	for name, attr := range module.inputs {
+		variable, found := child.InputVariables[name]
+		if !found {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Unsupported argument",
+				Detail: fmt.Sprintf("An argument named %q is not expected here: "+
+					"the module %q has no variable named %q.", name, module.Name, name),
+				Subject: attr.NameRange.Ptr(),
+			})
+			continue
+		}
+
+		val, moreDiags := attr.Expr.Value(ectx)
+		diags = append(diags, moreDiags...)
+		if moreDiags.HasErrors() {
+			continue
+		}
+		if val.ContainsMarked() {
+			// A variable set from a sensitive value is sensitive too, its
+			// value is marked again when used.
+			variable.Sensitive = true
+			val = unmarkSensitive(val)
+		}
+
+		if variable.Type != cty.NilType {
+			var err error
+			val, err = convert.Convert(val, variable.Type)
+			if err != nil {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid value for module argument",
+					Detail: fmt.Sprintf("The value for %s is not compatible with the "+
+						"variable's type constraint: %s.", name, err),
+					Subject: attr.Expr.Range().Ptr(),
+				})
+				continue
+			}
+		}
+
+		variable.Values = append(variable.Values, VariableAssignment{
+			From:  "module",
+			Value: val,
+			Expr:  attr.Expr,
+		})
+	}
+
+	return diags
+}
+
+// buildName returns the name of the builds of a build block, which is
+// prefixed by the modules the block is in, if any.
+func (cfg *PackerConfig) buildName(build *BuildBlock) string {
+	switch {
+	case cfg.modulePrefix == "":
+		return build.Name
+	case build.Name == "":
+		return cfg.modulePrefix
+	default:
+		return cfg.modulePrefix + "." + buildLabel + "." + build.Name
+	}
+}
+
+// NamedBuild is a build block of a config or of one of its modules.
+type NamedBuild struct {
+	// Name is the name of the builds of the block, prefixed by the modules
+	// the block is in, if any.
+	Name  string
+	Build *BuildBlock
+}
+
+// AllBuilds returns the build blocks of the config, followed by the ones of
+// its modules, sorted by module name, in the order GetBuilds starts them.
+// Modules are only loaded once the config is initialized.
+func (cfg *PackerConfig) AllBuilds() []NamedBuild {
+	res := make([]NamedBuild, 0, len(cfg.Builds))
+	for _, build := range cfg.Builds {
+		res = append(res, NamedBuild{Name: cfg.buildName(build), Build: build})
+	}
+
+	names := make([]string, 0, len(cfg.Modules))
+	for name := range cfg.Modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if child := cfg.Modules[name].Config; child != nil {
+			res = append(res, child.AllBuilds()...)
+		}
+	}
+	return res
+}
@@ -0,0 +1,183 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package hcl2template
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/packer/packer"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestParse_module(t *testing.T) {
+	defaultParser := getBasicParser()
+
+	tests := []parseTest{
+		{"module builds",
+			defaultParser,
+			parseTestArgs{"testdata/modules/basic", nil, nil},
+			&PackerConfig{
+				CorePackerVersionString: lockedVersion,
+				Basedir:                 filepath.Join("testdata", "modules", "basic"),
+				InputVariables: Variables{
+					"version": &Variable{
+						Name:   "version",
+						Type:   cty.String,
+						Values: []VariableAssignment{{From: "default", Value: cty.StringVal("1.0")}},
+					},
+				},
+				Sources: map[SourceRef]SourceBlock{
+					refVBIsoUbuntu1204: {Type: "virtualbox-iso", Name: "ubuntu-1204"},
+				},
+				Modules: map[string]*ModuleBlock{
+					"base": {Name: "base", Source: "./modules/base"},
+				},
+				Builds: Builds{
+					&BuildBlock{
+						Sources: []SourceUseBlock{
+							{SourceRef: refVBIsoUbuntu1204},
+						},
+					},
+				},
+			},
+			false, false,
+			[]*packer.CoreBuild{
+				&packer.CoreBuild{
+					Type:           "virtualbox-iso.ubuntu-1204",
+					BuilderType:    "virtualbox-iso",
+					Prepared:       true,
+					Builder:        emptyMockBuilder,
+					SensitiveVars:  []string{},
+					Provisioners:   []packer.CoreBuildProvisioner{},
+					PostProcessors: [][]packer.CoreBuildPostProcessor{},
+				},
+				&packer.CoreBuild{
+					BuildName:      "module.base.build.x",
+					Type:           "amazon-ebs.ubuntu-1604",
+					BuilderType:    "amazon-ebs",
+					Prepared:       true,
+					Builder:        emptyMockBuilder,
+					SensitiveVars:  []string{},
+					Provisioners:   []packer.CoreBuildProvisioner{},
+					PostProcessors: [][]packer.CoreBuildPostProcessor{},
+				},
+			},
+			false,
+			nil,
+		},
+		{"module including itself",
+			defaultParser,
+			parseTestArgs{"testdata/modules/cycle", nil, nil},
+			&PackerConfig{
+				CorePackerVersionString: lockedVersion,
+				Basedir:                 filepath.Join("testdata", "modules", "cycle"),
+				Modules: map[string]*ModuleBlock{
+					"self": {Name: "self", Source: "./"},
+				},
+			},
+			true, true,
+			nil,
+			false,
+			nil,
+		},
+		{"module with an unknown input",
+			defaultParser,
+			parseTestArgs{"testdata/modules/unknown_input", nil, nil},
+			&PackerConfig{
+				CorePackerVersionString: lockedVersion,
+				Basedir:                 filepath.Join("testdata", "modules", "unknown_input"),
+				Modules: map[string]*ModuleBlock{
+					"base": {Name: "base", Source: "../basic/modules/base"},
+				},
+			},
+			true, true,
+			nil,
+			false,
+			nil,
+		},
+		{"module with a remote source",
+			defaultParser,
+			parseTestArgs{"testdata/modules/remote", nil, nil},
+			&PackerConfig{
+				CorePackerVersionString: lockedVersion,
+				Basedir:                 filepath.Join("testdata", "modules", "remote"),
+			},
+			true, true,
+			nil,
+			false,
+			nil,
+		},
+	}
+	testParse(t, tests)
+}
+
+func TestParse_module_inputs(t *testing.T) {
+	cfg, diags := getBasicParser().Parse("testdata/modules/basic", nil, map[string]string{"version": "2.0"})
+	if diags.HasErrors() {
+		t.Fatalf("Parse: %s", diags)
+	}
+	if diags := cfg.Initialize(packer.InitializeOptions{}); diags.HasErrors() {
+		t.Fatalf("Initialize: %s", diags)
+	}
+
+	module := cfg.Modules["base"].Config
+	want := cty.StringVal("base-2.0")
+	if got := module.InputVariables["image_name"].Value(); !got.RawEquals(want) {
+		t.Fatalf("unexpected image_name: got %#v, want %#v", got, want)
+	}
+
+	builds, diags := cfg.GetBuilds(packer.GetBuildsOptions{Only: []string{"module.base.*"}})
+	if diags.HasErrors() {
+		t.Fatalf("GetBuilds: %s", diags)
+	}
+	if len(builds) != 1 || builds[0].Name() != "module.base.build.x.amazon-ebs.ubuntu-1604" {
+		t.Fatalf("unexpected builds: %v", builds)
+	}
+
+	// paths are relative to the module directory
+	want = cty.StringVal("base-2.0-" + filepath.ToSlash(filepath.Join("testdata", "modules", "basic", "modules", "base")))
+	if got := builds[0].HCLConfig.GetAttr("string"); !got.RawEquals(want) {
+		t.Fatalf("unexpected string: got %#v, want %#v", got, want)
+	}
+}
+
+func TestParse_module_env(t *testing.T) {
+	env := []string{"PKR_VAR_owner=team", "PKR_VAR_image_name=from-env"}
+	cfg, diags := getBasicParser().parse("testdata/modules/basic", nil, nil, env, false)
+	if diags.HasErrors() {
+		t.Fatalf("parse: %s", diags)
+	}
+	if diags := cfg.Initialize(packer.InitializeOptions{}); diags.HasErrors() {
+		t.Fatalf("Initialize: %s", diags)
+	}
+
+	module := cfg.Modules["base"].Config
+	if got, want := module.InputVariables["owner"].Value(), cty.StringVal("team"); !got.RawEquals(want) {
+		t.Fatalf("unexpected owner: got %#v, want %#v", got, want)
+	}
+	// module inputs take precedence over the environment
+	if got, want := module.InputVariables["image_name"].Value(), cty.StringVal("base-1.0"); !got.RawEquals(want) {
+		t.Fatalf("unexpected image_name: got %#v, want %#v", got, want)
+	}
+}
+
+func TestPackerConfig_AllBuilds(t *testing.T) {
+	cfg, diags := getBasicParser().Parse("testdata/modules/basic", nil, nil)
+	if diags.HasErrors() {
+		t.Fatalf("Parse: %s", diags)
+	}
+	if diags := cfg.Initialize(packer.InitializeOptions{}); diags.HasErrors() {
+		t.Fatalf("Initialize: %s", diags)
+	}
+
+	names := []string{}
+	for _, build := range cfg.Inspect().Builds {
+		names = append(names, build.Name)
+	}
+	if diff := cmp.Diff([]string{"", "module.base.build.x"}, names); diff != "" {
+		t.Fatalf("unexpected inspected builds: %s", diff)
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package hcl2template
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer/packer"
+	"github.com/zclconf/go-cty/cty"
+)
+
+var outputBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "value", Required: true},
+		{Name: "description"},
+		{Name: "sensitive"},
+	},
+}
+
+// OutputBlock references an HCL 'output' block. Outputs are evaluated once
+// the builds completed, the artifacts of each build are available by build
+// name:
+//
+//	output "image_id" {
+//	  value = build["amazon-ebs.ubuntu"].artifacts[0].id
+//	}
+type OutputBlock struct {
+	// Name of the output, given as the block label
+	Name        string
+	Description string
+	// Sensitive outputs are not displayed by Packer.
+	Sensitive bool
+
+	Expr hcl.Expression
+
+	block *hcl.Block
+}
+
+func (p *Parser) decodeOutput(block *hcl.Block) (*OutputBlock, hcl.Diagnostics) {
+	output := &OutputBlock{
+		Name:  block.Labels[0],
+		block: block,
+	}
+	var diags hcl.Diagnostics
+
+	if !hclsyntax.ValidIdentifier(output.Name) {
+		return nil, append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid " + outputLabel + " name",
+			Detail:   badIdentifierDetail,
+			Subject:  &block.LabelRanges[0],
+		})
+	}
+
+	content, moreDiags := block.Body.Content(outputBlockSchema)
+	diags = append(diags, moreDiags...)
+	if moreDiags.HasErrors() {
+		return nil, diags
+	}
+
+	output.Expr = content.Attributes["value"].Expr
+
+	if attr, found := content.Attributes["description"]; found {
+		diags = append(diags, gohcl.DecodeExpression(attr.Expr, nil, &output.Description)...)
+	}
+	if attr, found := content.Attributes["sensitive"]; found {
+		diags = append(diags, gohcl.DecodeExpression(attr.Expr, nil, &output.Sensitive)...)
+	}
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	return output, diags
+}
+
+// EvaluateOutputs evaluates the output blocks of the config. The `build`
+// variable is an object with the artifacts and the generated data of each
+// successful build, by build name.
+func (cfg *PackerConfig) EvaluateOutputs(artifacts map[string][]packersdk.Artifact) ([]packer.Output, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+	if len(cfg.Outputs) == 0 {
+		return nil, diags
+	}
+
+	builds := map[string]cty.Value{}
+	for name, buildArtifacts := range artifacts {
+		artifactsVal, generatedData, err := artifactsValues(buildArtifacts)
+		if err != nil {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  fmt.Sprintf("Failed to read the artifacts of %s", name),
+				Detail:   err.Error(),
+			})
+			continue
+		}
+		builds[name] = cty.ObjectVal(map[string]cty.Value{
+			"artifacts":      artifactsVal,
+			"generated_data": cty.ObjectVal(generatedData),
+		})
+	}
+
+	ectx := cfg.EvalContext(BuildContext, map[string]cty.Value{
+		buildAccessor: cty.ObjectVal(builds),
+	})
+
+	var res []packer.Output
+	for _, output := range cfg.Outputs {
+		val, moreDiags := output.Expr.Value(ectx)
+		diags = append(diags, moreDiags...)
+		if moreDiags.HasErrors() {
+			continue
+		}
+		// An output derived from a sensitive value is sensitive too.
+		val, marks := val.UnmarkDeep()
+		res = append(res, packer.Output{
+			Name:        output.Name,
+			Description: output.Description,
+			Value:       val,
+			Sensitive:   output.Sensitive || len(marks) > 0,
+		})
+	}
+
+	return res, diags
+}
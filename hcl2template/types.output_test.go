@@ -0,0 +1,84 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package hcl2template
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/hcl/v2"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer/packer"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestParse_output(t *testing.T) {
+	tests := []struct {
+		file        string
+		wantSummary string
+	}{
+		{"testdata/output/duplicate.pkr.hcl", "Duplicate output block"},
+		{"testdata/output/invalid_sensitive.pkr.hcl", "Unsuitable value type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			cfg, diags := getBasicParser().Parse(tt.file, nil, nil)
+			if diags.HasErrors() {
+				t.Fatalf("Parse: %s", diags)
+			}
+			diags = cfg.Initialize(packer.InitializeOptions{})
+			if !diags.HasErrors() || diags.Errs()[0].(*hcl.Diagnostic).Summary != tt.wantSummary {
+				t.Fatalf("expected %q, got %s", tt.wantSummary, diags)
+			}
+		})
+	}
+}
+
+func TestPackerConfig_EvaluateOutputs(t *testing.T) {
+	cfg, diags := getBasicParser().Parse("testdata/output/basic.pkr.hcl", nil, nil)
+	if diags.HasErrors() {
+		t.Fatalf("Parse: %s", diags)
+	}
+	if diags := cfg.Initialize(packer.InitializeOptions{}); diags.HasErrors() {
+		t.Fatalf("Initialize: %s", diags)
+	}
+
+	outputs, diags := cfg.EvaluateOutputs(map[string][]packersdk.Artifact{
+		"null.test": {
+			&packersdk.MockArtifact{
+				IdValue: "ami-1234",
+				StateValues: map[string]interface{}{
+					"generated_data": map[interface{}]interface{}{
+						"SourceImage": "ubuntu",
+					},
+				},
+			},
+		},
+	})
+	if diags.HasErrors() {
+		t.Fatalf("EvaluateOutputs: %s", diags)
+	}
+
+	expected := []packer.Output{
+		{
+			Name:        "image_id",
+			Description: "The id of the image.",
+			Value:       cty.StringVal("eu-west-1:ami-1234"),
+		},
+		{
+			Name:      "source_image",
+			Value:     cty.StringVal("ubuntu"),
+			Sensitive: true,
+		},
+	}
+	if diff := cmp.Diff(expected, outputs, cmp.Comparer(cty.Value.RawEquals)); diff != "" {
+		t.Fatalf("unexpected outputs: %s", diff)
+	}
+
+	// the build failed: its artifacts are missing
+	_, diags = cfg.EvaluateOutputs(nil)
+	if !diags.HasErrors() {
+		t.Fatal("expected an error without artifacts")
+	}
+}
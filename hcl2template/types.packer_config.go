@@ -5,6 +5,7 @@ package hcl2template
 
 import (
 	"fmt"
+	"log"
 	"sort"
 	"strings"
 
@@ -38,6 +39,21 @@ type PackerConfig struct {
 	// Available Source blocks
 	Sources map[SourceRef]SourceBlock
 
+	// Available Communicator blocks
+	Communicators map[CommunicatorRef]CommunicatorBlock
+
+	// Checks are the check blocks of the config, their assertions are
+	// evaluated once each build completed.
+	Checks []*CheckBlock
+
+	// Outputs are the output blocks of the config, they are evaluated once
+	// the builds completed.
+	Outputs []*OutputBlock
+
+	// Modules are the module blocks of the config, by name. Their builds
+	// are added to the builds of this config.
+	Modules map[string]*ModuleBlock
+
 	// InputVariables and LocalVariables are the list of defined input and
 	// local variables. They are of the same type but are not used in the same
 	// way. Local variables will not be decoded from any config file, env var,
@@ -49,6 +65,9 @@ type PackerConfig struct {
 
 	LocalBlocks []*LocalBlock
 
+	// localsConditions are the conditions set in locals blocks.
+	localsConditions []*localsConditions
+
 	ValidationOptions
 
 	// Builds is the list of Build blocks defined in the config files.
@@ -62,6 +81,24 @@ type PackerConfig struct {
 
 	parser *Parser
 	files  []*hcl.File
+	// env is the environment the values of the variables are read from.
+	env []string
+
+	// usedReferences are the input and local variables referenced in the
+	// config, like `var.foo` or `local.bar`, or `var` when the whole object
+	// is referenced.
+	usedReferences map[string]bool
+
+	// vaultSecretFunc is the vault_secret function of the config, so that
+	// its secrets are cached for the whole run.
+	vaultSecretFunc *function.Function
+
+	// modulePrefix is set when the config is the one of a module, it
+	// prefixes the names of its builds, like `module.base`.
+	modulePrefix string
+	// moduleDirs are the absolute paths of the configs that include this
+	// one as a module.
+	moduleDirs []string
 
 	// Fields passed as command line flags
 	except  []glob.Glob
@@ -73,6 +110,11 @@ type PackerConfig struct {
 
 type ValidationOptions struct {
 	WarnOnUndeclaredVar bool
+	// WarnOnUnused reports the input and local variables that are declared
+	// but never referenced.
+	WarnOnUnused bool
+	// Strict reports the unused variables as errors instead of warnings.
+	Strict bool
 }
 
 const (
@@ -83,6 +125,8 @@ const (
 	buildAccessor          = "build"
 	packerAccessor         = "packer"
 	dataAccessor           = "data"
+	eachAccessor           = "each"
+	countAccessor          = "count"
 )
 
 type BlockContext int
@@ -95,6 +139,22 @@ const (
 	NilContext
 )
 
+// functions returns the functions that can be called in the config: the
+// builtin ones, and the ones provided by plugins.
+func (cfg *PackerConfig) functions() map[string]function.Function {
+	funcs := Functions(cfg.Basedir)
+	if cfg.vaultSecretFunc != nil {
+		funcs["vault_secret"] = *cfg.vaultSecretFunc
+	}
+	if cfg.parser == nil || cfg.parser.PluginConfig == nil {
+		return funcs
+	}
+	for name, fn := range cfg.parser.PluginConfig.Functions {
+		funcs[name] = fn
+	}
+	return funcs
+}
+
 // EvalContext returns the *hcl.EvalContext that will be passed to an hcl
 // decoder in order to tell what is the actual value of a var or a local and
 // the list of defined functions.
@@ -102,7 +162,7 @@ func (cfg *PackerConfig) EvalContext(ctx BlockContext, variables map[string]cty.
 	inputVariables := cfg.InputVariables.Values()
 	localVariables := cfg.LocalVariables.Values()
 	ectx := &hcl.EvalContext{
-		Functions: Functions(cfg.Basedir),
+		Functions: cfg.functions(),
 		Variables: map[string]cty.Value{
 			inputVariablesAccessor: cty.ObjectVal(inputVariables),
 			localsAccessor:         cty.ObjectVal(localVariables),
@@ -185,13 +245,14 @@ func (c *PackerConfig) decodeInputVariables(f *hcl.File) hcl.Diagnostics {
 }
 
 // parseLocalVariableBlocks looks in the AST for 'local' and 'locals' blocks and
-// returns them all.
-func parseLocalVariableBlocks(f *hcl.File) ([]*LocalBlock, hcl.Diagnostics) {
+// returns them all, with the conditions of the 'locals' blocks.
+func parseLocalVariableBlocks(f *hcl.File) ([]*LocalBlock, []*localsConditions, hcl.Diagnostics) {
 	var diags hcl.Diagnostics
 
 	content, _ := f.Body.Content(configSchema)
 
 	var locals []*LocalBlock
+	var allConditions []*localsConditions
 
 	for _, block := range content.Blocks {
 		switch block.Type {
@@ -199,22 +260,57 @@ func parseLocalVariableBlocks(f *hcl.File) ([]*LocalBlock, hcl.Diagnostics) {
 			block, moreDiags := decodeLocalBlock(block)
 			diags = append(diags, moreDiags...)
 			if moreDiags.HasErrors() {
-				return locals, diags
+				return locals, allConditions, diags
 			}
 			locals = append(locals, block)
 		case localsLabel:
-			attrs, moreDiags := block.Body.JustAttributes()
+			content, rest, moreDiags := block.Body.PartialContent(conditionsSchema)
+			diags = append(diags, moreDiags...)
+			decoded, moreDiags := decodeConditions(content.Blocks)
 			diags = append(diags, moreDiags...)
+			var conditions *localsConditions
+			if !decoded.Empty() {
+				conditions = &localsConditions{Conditions: decoded}
+				allConditions = append(allConditions, conditions)
+			}
+
+			var attrs hcl.Attributes
+			if body, ok := block.Body.(*hclsyntax.Body); ok {
+				// JustAttributes rejects the condition blocks of a native
+				// syntax body, even once they were read.
+				attrs = hcl.Attributes{}
+				for name, attr := range body.Attributes {
+					attrs[name] = attr.AsHCLAttribute()
+				}
+				for _, block := range body.Blocks {
+					if block.Type == preconditionLabel || block.Type == postconditionLabel {
+						continue
+					}
+					diags = append(diags, &hcl.Diagnostic{
+						Severity: hcl.DiagError,
+						Summary:  fmt.Sprintf("Unexpected %q block", block.Type),
+						Detail:   "Blocks are not allowed here.",
+						Subject:  &block.TypeRange,
+					})
+				}
+			} else {
+				attrs, moreDiags = rest.JustAttributes()
+				diags = append(diags, moreDiags...)
+			}
 			for name, attr := range attrs {
 				locals = append(locals, &LocalBlock{
-					LocalName: name,
-					Expr:      attr.Expr,
+					LocalName:  name,
+					Expr:       attr.Expr,
+					conditions: conditions,
 				})
+				if conditions != nil {
+					conditions.names = append(conditions.names, name)
+				}
 			}
 		}
 	}
 
-	return locals, diags
+	return locals, allConditions, diags
 }
 
 func (c *PackerConfig) localByName(local string) (*LocalBlock, error) {
@@ -243,7 +339,7 @@ func (c *PackerConfig) evaluateLocalVariables(locals []*LocalBlock) hcl.Diagnost
 	for _, local := range c.LocalBlocks {
 		// Note: when looking at the expressions, we only need to care about
 		// attributes, as HCL2 expressions are not allowed in a block's labels.
-		vars := FilterTraversalsByType(local.Expr.Variables(), "local")
+		vars := FilterTraversalsByType(local.references(), "local")
 
 		var localDeps []refString
 		for _, v := range vars {
@@ -352,6 +448,25 @@ func (c *PackerConfig) recursivelyEvaluateLocalVariable(local *LocalBlock, depth
 func (cfg *PackerConfig) evaluateLocalVariable(local *LocalBlock) (*Variable, hcl.Diagnostics) {
 	var diags hcl.Diagnostics
 
+	if local.conditions != nil {
+		if !local.conditions.checked {
+			local.conditions.checked = true
+			moreDiags := local.conditions.checkPreconditions(cfg.EvalContext(LocalContext, nil))
+			diags = append(diags, moreDiags...)
+			local.conditions.failed = moreDiags.HasErrors()
+		}
+		if local.conditions.failed {
+			local.evaluated = true
+			return nil, append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Local variable not evaluated",
+				Detail: fmt.Sprintf("%s was not evaluated because a precondition "+
+					"of its locals block failed.", local.Name()),
+				Subject: local.Expr.Range().Ptr(),
+			})
+		}
+	}
+
 	value, moreDiags := local.Expr.Value(cfg.EvalContext(LocalContext, nil))
 
 	local.evaluated = true
@@ -360,6 +475,7 @@ func (cfg *PackerConfig) evaluateLocalVariable(local *LocalBlock) (*Variable, hc
 	if moreDiags.HasErrors() {
 		return nil, diags
 	}
+	filterSensitiveFromLogs(value)
 	return &Variable{
 		Name:      local.LocalName,
 		Sensitive: local.Sensitive,
@@ -372,6 +488,38 @@ func (cfg *PackerConfig) evaluateLocalVariable(local *LocalBlock) (*Variable, hc
 	}, diags
 }
 
+// checkLocalsConditions checks the conditions of the locals blocks, once
+// their locals were evaluated. The preconditions of a locals block that
+// declares no locals are checked here too.
+func (cfg *PackerConfig) checkLocalsConditions() hcl.Diagnostics {
+	var diags hcl.Diagnostics
+
+	for _, conditions := range cfg.localsConditions {
+		if !conditions.checked {
+			conditions.checked = true
+			moreDiags := conditions.checkPreconditions(cfg.EvalContext(LocalContext, nil))
+			diags = append(diags, moreDiags...)
+			conditions.failed = moreDiags.HasErrors()
+		}
+		if conditions.failed {
+			continue
+		}
+
+		self := map[string]cty.Value{}
+		for _, name := range conditions.names {
+			local, found := cfg.LocalVariables[name]
+			if !found {
+				// the local failed to evaluate
+				continue
+			}
+			self[name] = local.markedValue()
+		}
+		diags = append(diags, conditions.checkPostconditions(cfg.EvalContext(LocalContext, nil), cty.ObjectVal(self))...)
+	}
+
+	return diags
+}
+
 func (cfg *PackerConfig) evaluateDatasources(skipExecution bool) hcl.Diagnostics {
 	var diags hcl.Diagnostics
 
@@ -455,6 +603,11 @@ func (cfg *PackerConfig) recursivelyEvaluateDatasources(ref DatasourceRef, depen
 	// If we've gotten here, then it means ref doesn't seem to have any further
 	// dependencies we need to evaluate first. Evaluate it, with the cfg's full
 	// data source context.
+	moreDiags = ds.conditions.checkPreconditions(cfg.EvalContext(DatasourceContext, nil))
+	diags = append(diags, moreDiags...)
+	if moreDiags.HasErrors() {
+		return dependencies, diags
+	}
 	datasource, startDiags := cfg.startDatasource(ds)
 	if startDiags.HasErrors() {
 		diags = append(diags, startDiags...)
@@ -468,7 +621,7 @@ func (cfg *PackerConfig) recursivelyEvaluateDatasources(ref DatasourceRef, depen
 		return dependencies, diags
 	}
 
-	opts, _ := decodeHCL2Spec(ds.block.Body, cfg.EvalContext(DatasourceContext, nil), datasource)
+	opts, _ := decodeHCL2Spec(ds.body, cfg.EvalContext(DatasourceContext, nil), datasource)
 	sp := packer.CheckpointReporter.AddSpan(ref.Type, "datasource", opts)
 	realValue, err := datasource.Execute()
 	sp.End(err)
@@ -481,6 +634,12 @@ func (cfg *PackerConfig) recursivelyEvaluateDatasources(ref DatasourceRef, depen
 		return dependencies, diags
 	}
 
+	moreDiags = ds.conditions.checkPostconditions(cfg.EvalContext(DatasourceContext, nil), realValue)
+	diags = append(diags, moreDiags...)
+	if moreDiags.HasErrors() {
+		return dependencies, diags
+	}
+
 	ds.value = realValue
 	cfg.Datasources[ref] = ds
 	// remove ref from the dependencies map.
@@ -494,6 +653,11 @@ func (cfg *PackerConfig) evaluateDatasource(ds DatasourceBlock, skipExecution bo
 	// If we've gotten here, then it means ref doesn't seem to have any further
 	// dependencies we need to evaluate first. Evaluate it, with the cfg's full
 	// data source context.
+	moreDiags := ds.conditions.checkPreconditions(cfg.EvalContext(DatasourceContext, nil))
+	diags = append(diags, moreDiags...)
+	if moreDiags.HasErrors() {
+		return diags
+	}
 	datasource, startDiags := cfg.startDatasource(ds)
 	if startDiags.HasErrors() {
 		diags = append(diags, startDiags...)
@@ -507,7 +671,7 @@ func (cfg *PackerConfig) evaluateDatasource(ds DatasourceBlock, skipExecution bo
 		return diags
 	}
 
-	opts, _ := decodeHCL2Spec(ds.block.Body, cfg.EvalContext(DatasourceContext, nil), datasource)
+	opts, _ := decodeHCL2Spec(ds.body, cfg.EvalContext(DatasourceContext, nil), datasource)
 	sp := packer.CheckpointReporter.AddSpan(ds.Ref().Type, "datasource", opts)
 	realValue, err := datasource.Execute()
 	sp.End(err)
@@ -520,6 +684,12 @@ func (cfg *PackerConfig) evaluateDatasource(ds DatasourceBlock, skipExecution bo
 		return diags
 	}
 
+	moreDiags = ds.conditions.checkPostconditions(cfg.EvalContext(DatasourceContext, nil), realValue)
+	diags = append(diags, moreDiags...)
+	if moreDiags.HasErrors() {
+		return diags
+	}
+
 	ds.value = realValue
 	cfg.Datasources[ds.Ref()] = ds
 
@@ -532,7 +702,7 @@ func (cfg *PackerConfig) getCoreBuildProvisioners(source SourceUseBlock, blocks
 	var diags hcl.Diagnostics
 	res := []packer.CoreBuildProvisioner{}
 	for _, pb := range blocks {
-		if pb.OnlyExcept.Skip(source.String()) {
+		if pb.OnlyExcept.Skip(source.names()...) {
 			continue
 		}
 
@@ -597,7 +767,7 @@ func (cfg *PackerConfig) getCoreBuildPostProcessors(source SourceUseBlock, block
 	for _, blocks := range blocksList {
 		pps := []packer.CoreBuildPostProcessor{}
 		for _, ppb := range blocks {
-			if ppb.OnlyExcept.Skip(source.String()) {
+			if ppb.OnlyExcept.Skip(source.names()...) {
 				continue
 			}
 
@@ -632,6 +802,7 @@ func (cfg *PackerConfig) getCoreBuildPostProcessors(source SourceUseBlock, block
 				PType:             ppb.PType,
 				HCLConfig:         flatPostProcessorCfg,
 				KeepInputArtifact: ppb.KeepInputArtifact,
+				OnError:           ppb.OnError,
 			})
 		}
 		if len(pps) > 0 {
@@ -661,7 +832,8 @@ func (cfg *PackerConfig) GetHCPPackerRegistryBlock() (*HCPPackerRegistryBlock, h
 		}
 	}
 	// We start by looking in the build blocks
-	for _, build := range cfg.Builds {
+	for _, named := range cfg.AllBuilds() {
+		build := named.Build
 		if build.HCPPackerRegistry != nil {
 			if block != nil {
 				// error multiple build block
@@ -694,23 +866,64 @@ func (cfg *PackerConfig) GetHCPPackerRegistryBlock() (*HCPPackerRegistryBlock, h
 // blocks. All Builders, Provisioners and Post Processors will be started and
 // configured.
 func (cfg *PackerConfig) GetBuilds(opts packer.GetBuildsOptions) ([]*packer.CoreBuild, hcl.Diagnostics) {
-	res := []*packer.CoreBuild{}
 	var diags hcl.Diagnostics
-	possibleBuildNames := []string{}
-
-	cfg.debug = opts.Debug
-	cfg.force = opts.Force
-	cfg.onError = opts.OnError
 
-	if len(cfg.Builds) == 0 {
-		return res, append(diags, &hcl.Diagnostic{
+	if len(cfg.Builds) == 0 && len(cfg.Modules) == 0 {
+		return []*packer.CoreBuild{}, append(diags, &hcl.Diagnostic{
 			Summary:  "Missing build block",
 			Detail:   "A build block with one or more sources is required for executing a build.",
 			Severity: hcl.DiagError,
 		})
 	}
 
+	possibleBuildNames := []string{}
+	res, diags := cfg.getBuilds(&opts, &possibleBuildNames)
+	if res == nil && diags.HasErrors() {
+		return nil, diags
+	}
+
+	if len(opts.Only) > opts.OnlyMatches {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagWarning,
+			Summary:  "an 'only' option was passed, but not all matches were found for the given build.",
+			Detail: fmt.Sprintf("Possible build names: %v.\n"+
+				"These could also be matched with a glob pattern like: 'happycloud.*'", possibleBuildNames),
+		})
+	}
+	if len(opts.Except) > opts.ExceptMatches {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagWarning,
+			Summary:  "an 'except' option was passed, but did not match any build.",
+			Detail: fmt.Sprintf("Possible build names: %v.\n"+
+				"These could also be matched with a glob pattern like: 'happycloud.*'", possibleBuildNames),
+		})
+	}
+	return res, diags
+}
+
+// getBuilds returns the builds of the config, followed by the builds of its
+// modules, sorted by module name. The names of the builds are added to
+// possibleBuildNames, and the matches of the -only and -except options are
+// counted in opts.
+func (cfg *PackerConfig) getBuilds(opts *packer.GetBuildsOptions, possibleBuildNames *[]string) ([]*packer.CoreBuild, hcl.Diagnostics) {
+	res := []*packer.CoreBuild{}
+	var diags hcl.Diagnostics
+
+	cfg.debug = opts.Debug
+	cfg.force = opts.Force
+	cfg.onError = opts.OnError
+
 	for _, build := range cfg.Builds {
+		// The conditions of a build block are only checked when at least
+		// one of its builds is selected.
+		buildEctx := cfg.EvalContext(BuildContext, map[string]cty.Value{
+			buildAccessor: cty.ObjectVal(map[string]cty.Value{
+				"name": cty.StringVal(build.Name),
+			}),
+		})
+		buildChecked, buildFailed := false, false
+		var prepared []cty.Value
+
 		for _, srcUsage := range build.Sources {
 			src, found := cfg.Sources[srcUsage.SourceRef]
 			if !found {
@@ -724,8 +937,9 @@ func (cfg *PackerConfig) GetBuilds(opts packer.GetBuildsOptions) ([]*packer.Core
 			}
 
 			pcb := &packer.CoreBuild{
-				BuildName: build.Name,
-				Type:      srcUsage.String(),
+				BuildName:        cfg.buildName(build),
+				Type:             srcUsage.String(),
+				ConcurrencyGroup: srcUsage.ConcurrencyGroup,
 			}
 
 			pcb.SetDebug(cfg.debug)
@@ -734,7 +948,13 @@ func (cfg *PackerConfig) GetBuilds(opts packer.GetBuildsOptions) ([]*packer.Core
 
 			// Apply the -only and -except command-line options to exclude matching builds.
 			buildName := pcb.Name()
-			possibleBuildNames = append(possibleBuildNames, buildName)
+			// Instances of a source with for_each or count can also be
+			// selected by the name they share.
+			buildNames := []string{buildName}
+			if suffix := srcUsage.instanceSuffix(); suffix != "" {
+				buildNames = append(buildNames, strings.TrimSuffix(buildName, suffix))
+			}
+			*possibleBuildNames = append(*possibleBuildNames, buildName)
 			// -only
 			if len(opts.Only) > 0 {
 				onlyGlobs, diags := convertFilterOption(opts.Only, "only")
@@ -744,7 +964,7 @@ func (cfg *PackerConfig) GetBuilds(opts packer.GetBuildsOptions) ([]*packer.Core
 				cfg.only = onlyGlobs
 				include := false
 				for _, onlyGlob := range onlyGlobs {
-					if onlyGlob.Match(buildName) {
+					if matchesAny(onlyGlob, buildNames) {
 						include = true
 						break
 					}
@@ -764,7 +984,7 @@ func (cfg *PackerConfig) GetBuilds(opts packer.GetBuildsOptions) ([]*packer.Core
 				cfg.except = exceptGlobs
 				exclude := false
 				for _, exceptGlob := range exceptGlobs {
-					if exceptGlob.Match(buildName) {
+					if matchesAny(exceptGlob, buildNames) {
 						exclude = true
 						break
 					}
@@ -775,14 +995,41 @@ func (cfg *PackerConfig) GetBuilds(opts packer.GetBuildsOptions) ([]*packer.Core
 				}
 			}
 
+			if !buildChecked {
+				buildChecked = true
+				moreDiags := build.conditions.checkPreconditions(buildEctx)
+				diags = append(diags, moreDiags...)
+				buildFailed = moreDiags.HasErrors()
+			}
+			if buildFailed {
+				continue
+			}
+
+			srcVariables := srcUsage.instanceValues()
+			srcVariables[sourcesAccessor] = cty.ObjectVal(srcUsage.ctyValues())
+			moreDiags := srcUsage.conditions.checkPreconditions(cfg.EvalContext(BuildContext, srcVariables))
+			diags = append(diags, moreDiags...)
+			if moreDiags.HasErrors() {
+				continue
+			}
+
 			builder, moreDiags, generatedVars := cfg.startBuilder(srcUsage, cfg.EvalContext(BuildContext, nil))
 			diags = append(diags, moreDiags...)
 			if moreDiags.HasErrors() {
 				continue
 			}
 
-			decoded, _ := decodeHCL2Spec(srcUsage.Body, cfg.EvalContext(BuildContext, nil), builder)
+			decoded, _ := decodeHCL2Spec(srcUsage.Body, cfg.EvalContext(BuildContext, srcUsage.instanceValues()), builder)
 			pcb.HCLConfig = decoded
+
+			if decoded == cty.NilVal {
+				decoded = cty.DynamicVal
+			}
+			moreDiags = srcUsage.conditions.checkPostconditions(cfg.EvalContext(BuildContext, srcVariables), decoded)
+			diags = append(diags, moreDiags...)
+			if moreDiags.HasErrors() {
+				continue
+			}
 			pcb.BuilderType = srcUsage.Type
 
 			// If the builder has provided a list of to-be-generated variables that
@@ -796,10 +1043,9 @@ func (cfg *PackerConfig) GetBuilds(opts packer.GetBuildsOptions) ([]*packer.Core
 			}
 			unknownBuildValues["name"] = cty.StringVal(build.Name)
 
-			variables := map[string]cty.Value{
-				sourcesAccessor: cty.ObjectVal(srcUsage.ctyValues()),
-				buildAccessor:   cty.ObjectVal(unknownBuildValues),
-			}
+			variables := srcUsage.instanceValues()
+			variables[sourcesAccessor] = cty.ObjectVal(srcUsage.ctyValues())
+			variables[buildAccessor] = cty.ObjectVal(unknownBuildValues)
 
 			provisioners, moreDiags := cfg.getCoreBuildProvisioners(srcUsage, build.ProvisionerBlocks, cfg.EvalContext(BuildContext, variables))
 			diags = append(diags, moreDiags...)
@@ -813,7 +1059,7 @@ func (cfg *PackerConfig) GetBuilds(opts packer.GetBuildsOptions) ([]*packer.Core
 			}
 
 			if build.ErrorCleanupProvisionerBlock != nil &&
-				!build.ErrorCleanupProvisionerBlock.OnlyExcept.Skip(srcUsage.String()) {
+				!build.ErrorCleanupProvisionerBlock.OnlyExcept.Skip(srcUsage.names()...) {
 				errorCleanupProv, moreDiags := cfg.getCoreBuildProvisioner(srcUsage, build.ErrorCleanupProvisionerBlock, cfg.EvalContext(BuildContext, variables))
 				diags = append(diags, moreDiags...)
 				if moreDiags.HasErrors() {
@@ -822,11 +1068,24 @@ func (cfg *PackerConfig) GetBuilds(opts packer.GetBuildsOptions) ([]*packer.Core
 				pcb.CleanupProvisioner = errorCleanupProv
 			}
 
+			pcb.Checks = cfg.getCoreBuildChecks(cfg.EvalContext(BuildContext, variables))
+
 			pcb.Builder = builder
 			pcb.Provisioners = provisioners
 			pcb.PostProcessors = pps
+			pcb.PostProcessorsParallel = build.PostProcessorsParallel
 			pcb.Prepared = true
 
+			if srcUsage.Retry != nil {
+				pcb.Retry = srcUsage.Retry.buildRetry()
+			} else {
+				pcb.Retry = build.Retry.buildRetry()
+			}
+			pcb.Timeout = build.Timeout
+			if srcUsage.Timeout != 0 {
+				pcb.Timeout = srcUsage.Timeout
+			}
+
 			pcb.SensitiveVars = make([]string, 0, len(cfg.InputVariables))
 
 			for key, variable := range cfg.InputVariables {
@@ -849,24 +1108,40 @@ func (cfg *PackerConfig) GetBuilds(opts packer.GetBuildsOptions) ([]*packer.Core
 			}
 
 			res = append(res, pcb)
+			prepared = append(prepared, cty.StringVal(pcb.Name()))
+		}
+
+		if buildChecked && !buildFailed {
+			builds := cty.ListValEmpty(cty.String)
+			if len(prepared) > 0 {
+				builds = cty.ListVal(prepared)
+			}
+			diags = append(diags, build.conditions.checkPostconditions(buildEctx, cty.ObjectVal(map[string]cty.Value{
+				"name":   cty.StringVal(build.Name),
+				"builds": builds,
+			}))...)
 		}
 	}
-	if len(opts.Only) > opts.OnlyMatches {
-		diags = append(diags, &hcl.Diagnostic{
-			Severity: hcl.DiagWarning,
-			Summary:  "an 'only' option was passed, but not all matches were found for the given build.",
-			Detail: fmt.Sprintf("Possible build names: %v.\n"+
-				"These could also be matched with a glob pattern like: 'happycloud.*'", possibleBuildNames),
-		})
+
+	names := make([]string, 0, len(cfg.Modules))
+	for name := range cfg.Modules {
+		names = append(names, name)
 	}
-	if len(opts.Except) > opts.ExceptMatches {
-		diags = append(diags, &hcl.Diagnostic{
-			Severity: hcl.DiagWarning,
-			Summary:  "an 'except' option was passed, but did not match any build.",
-			Detail: fmt.Sprintf("Possible build names: %v.\n"+
-				"These could also be matched with a glob pattern like: 'happycloud.*'", possibleBuildNames),
-		})
+	sort.Strings(names)
+	for _, name := range names {
+		module := cfg.Modules[name]
+		if module.Config == nil {
+			// the module failed to initialize
+			continue
+		}
+		builds, moreDiags := module.Config.getBuilds(opts, possibleBuildNames)
+		diags = append(diags, moreDiags...)
+		if builds == nil && moreDiags.HasErrors() {
+			return nil, diags
+		}
+		res = append(res, builds...)
 	}
+
 	return res, diags
 }
 
@@ -890,6 +1165,29 @@ with in HCL2 mode.
 `)
 
 func (p *PackerConfig) EvaluateExpression(line string) (out string, exit bool, diags hcl.Diagnostics) {
+	return p.evaluateExpression(line, NilContext, nil)
+}
+
+// EvaluateDebugExpression evaluates an expression typed in the debug console of
+// a paused build. The `build` variable describes the paused build.
+func (p *PackerConfig) EvaluateDebugExpression(line string, session *packer.DebugSession) (out string, exit bool, diags hcl.Diagnostics) {
+	generatedData := map[string]cty.Value{}
+	for k, v := range session.GeneratedData {
+		val, err := ConvertPluginConfigValueToHCLValue(v)
+		if err != nil {
+			log.Printf("[WARN] ignoring generated data %q in the debug console: %s", k, err)
+			continue
+		}
+		generatedData[k] = val
+	}
+	return p.evaluateExpression(line, NilContext, map[string]cty.Value{
+		buildAccessor: session.BuildValue(generatedData),
+	})
+}
+
+// evaluateExpression evaluates a line of the console in the given context, with
+// the given additional variables.
+func (p *PackerConfig) evaluateExpression(line string, ctx BlockContext, variables map[string]cty.Value) (out string, exit bool, diags hcl.Diagnostics) {
 	switch {
 	case line == "":
 		return "", false, nil
@@ -900,7 +1198,7 @@ func (p *PackerConfig) EvaluateExpression(line string) (out string, exit bool, d
 	case line == "variables":
 		return p.printVariables(), false, nil
 	default:
-		return p.handleEval(line)
+		return p.handleEval(line, ctx, variables)
 	}
 }
 
@@ -928,8 +1226,9 @@ func (p *PackerConfig) printVariables() string {
 func (p *PackerConfig) printBuilds() string {
 	out := &strings.Builder{}
 	out.WriteString("> builds:\n")
-	for i, build := range p.Builds {
-		name := build.Name
+	for i, named := range p.AllBuilds() {
+		build := named.Build
+		name := named.Name
 		if name == "" {
 			name = fmt.Sprintf("<unnamed build %d>", i)
 		}
@@ -973,7 +1272,7 @@ func (p *PackerConfig) printBuilds() string {
 	return out.String()
 }
 
-func (p *PackerConfig) handleEval(line string) (out string, exit bool, diags hcl.Diagnostics) {
+func (p *PackerConfig) handleEval(line string, ctx BlockContext, variables map[string]cty.Value) (out string, exit bool, diags hcl.Diagnostics) {
 
 	// Parse the given line as an expression
 	expr, parseDiags := hclsyntax.ParseExpression([]byte(line), "<console-input>", hcl.Pos{Line: 1, Column: 1})
@@ -982,7 +1281,7 @@ func (p *PackerConfig) handleEval(line string) (out string, exit bool, diags hcl
 		return "", false, diags
 	}
 
-	val, valueDiags := expr.Value(p.EvalContext(NilContext, nil))
+	val, valueDiags := expr.Value(p.EvalContext(ctx, variables))
 	diags = append(diags, valueDiags...)
 	if valueDiags.HasErrors() {
 		return "", false, diags
@@ -999,6 +1298,13 @@ func (p *PackerConfig) FixConfig(_ packer.FixConfigOptions) (diags hcl.Diagnosti
 func (p *PackerConfig) InspectConfig(opts packer.InspectConfigOptions) int {
 
 	ui := opts.Ui
+	if opts.VarSources {
+		return p.inspectVariableSources(ui, opts.JSON)
+	}
+	if opts.JSON {
+		return sayJSON(ui, p.Inspect())
+	}
+
 	ui.Say("Packer Inspect: HCL2 mode\n")
 	ui.Say(p.printVariables())
 	ui.Say(p.printBuilds())
@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/hashicorp/go-version"
 	"github.com/hashicorp/packer-plugin-sdk/template/config"
 	"github.com/hashicorp/packer/hcl2template/addrs"
@@ -15,6 +16,7 @@ import (
 	hcl2template "github.com/hashicorp/packer/hcl2template/internal"
 	"github.com/hashicorp/packer/packer"
 	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
 )
 
 var (
@@ -436,6 +438,8 @@ func TestParser_ValidateFilterOption(t *testing.T) {
 	}{
 		{"*foo*", false},
 		{"foo[]bar", true},
+		{`amazon-ebs.base["us-east-1"]`, false},
+		{"*.base[0]", false},
 	}
 
 	for _, test := range tests {
@@ -662,3 +666,46 @@ func mustVersionConstraints(vs version.Constraints, err error) version.Constrain
 	}
 	return vs
 }
+
+func TestPackerConfig_pluginFunctions(t *testing.T) {
+	greet := function.New(&function.Spec{
+		Params: []function.Parameter{
+			{Name: "name", Type: cty.String},
+		},
+		Type: function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			return cty.StringVal("hello " + args[0].AsString()), nil
+		},
+	})
+	parser := getBasicParser(func(p *Parser) {
+		p.PluginConfig.Functions = map[string]function.Function{
+			"provider::example::greet": greet,
+		}
+	})
+
+	cfg, diags := parser.Parse("testdata/functions/plugin.pkr.hcl", nil, nil)
+	if diags.HasErrors() {
+		t.Fatalf("Parse: %s", diags)
+	}
+	if diags := cfg.Initialize(packer.InitializeOptions{}); diags.HasErrors() {
+		t.Fatalf("Initialize: %s", diags)
+	}
+
+	expected := map[string]cty.Value{
+		"greeting": cty.StringVal("hello packer"),
+		"shouting": cty.StringVal("HELLO PACKER"),
+	}
+	if diff := cmp.Diff(expected, cfg.LocalVariables.Values(), cmp.Comparer(cty.Value.RawEquals)); diff != "" {
+		t.Fatalf("unexpected locals: %s", diff)
+	}
+
+	// Without the plugin, the function does not exist.
+	cfg, diags = getBasicParser().Parse("testdata/functions/plugin.pkr.hcl", nil, nil)
+	if diags.HasErrors() {
+		t.Fatalf("Parse: %s", diags)
+	}
+	diags = cfg.Initialize(packer.InitializeOptions{})
+	if !diags.HasErrors() || diags[0].Summary != "Call to unknown function" {
+		t.Fatalf("expected an unknown function error, got %s", diags)
+	}
+}
@@ -121,8 +121,9 @@ func (ds *DatasourceBlock) RegisterDependency(rs refString) error {
 	switch rs.MType {
 	case "data", "local":
 		ds.Dependencies = append(ds.Dependencies, rs)
-	// NOOP: vars are always evaluated beforehand for datasources
+	// vars are always evaluated beforehand for datasources
 	case "var":
+		ds.variables = append(ds.variables, rs)
 	default:
 		return fmt.Errorf("unsupported dependency type %q; datasources can only depend on local, var or data.", rs.MType)
 	}
@@ -134,8 +135,9 @@ func (loc *LocalBlock) RegisterDependency(rs refString) error {
 	switch rs.MType {
 	case "data", "local":
 		loc.dependencies = append(loc.dependencies, rs)
-	// NOOP: vars are always evaluated beforehand for locals
+	// vars are always evaluated beforehand for locals
 	case "var":
+		loc.variables = append(loc.variables, rs)
 	default:
 		return fmt.Errorf("unsupported dependency type %q; locals can only depend on local, var or data.", rs.MType)
 	}
@@ -7,12 +7,14 @@ import (
 	"fmt"
 	"sort"
 	"strconv"
+	"time"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/gohcl"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 	hcl2shim "github.com/hashicorp/packer/hcl2template/shim"
 	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/gocty"
 )
 
 // SourceBlock references an HCL 'source' block to be used in a build for
@@ -45,6 +47,36 @@ type SourceUseBlock struct {
 	// content
 	// Body can be expanded by a dynamic tag.
 	Body hcl.Body
+
+	// Retry overrides the retry block of the build block for this source.
+	Retry *RetryBlock
+
+	// Timeout overrides the timeout of the build block for this source.
+	Timeout time.Duration
+
+	// ConcurrencyGroup can be used to limit how many builds of the same group
+	// can run concurrently with the -concurrency flag of a build.
+	ConcurrencyGroup string
+
+	// InstanceKey is set when the source block has a for_each or a count
+	// argument. It is the each.key of the instance, or its count.index.
+	InstanceKey cty.Value
+	// EachValue is the each.value of an instance created with for_each.
+	EachValue cty.Value
+
+	// conditions are the conditions set in the source block, or in the
+	// source block of the build.
+	conditions Conditions
+}
+
+var sourceUseSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "for_each"},
+		{Name: "count"},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: buildRetryLabel},
+	},
 }
 
 func (b *SourceUseBlock) name() string {
@@ -55,7 +87,52 @@ func (b *SourceUseBlock) name() string {
 }
 
 func (b *SourceUseBlock) String() string {
-	return fmt.Sprintf("%s.%s", b.Type, b.name())
+	return fmt.Sprintf("%s.%s%s", b.Type, b.name(), b.instanceSuffix())
+}
+
+// names returns the names a source usage can be selected with: its full
+// name, and for an instance created with for_each or count, the name shared
+// by all of the instances.
+func (b *SourceUseBlock) names() []string {
+	if b.InstanceKey == cty.NilVal {
+		return []string{b.String()}
+	}
+	return []string{b.String(), fmt.Sprintf("%s.%s", b.Type, b.name())}
+}
+
+// instanceSuffix returns the index of an instance created with for_each or
+// count, like `["us-east-1"]` or `[0]`.
+func (b *SourceUseBlock) instanceSuffix() string {
+	switch {
+	case b.InstanceKey == cty.NilVal:
+		return ""
+	case b.InstanceKey.Type() == cty.Number:
+		return fmt.Sprintf("[%s]", b.InstanceKey.AsBigFloat().Text('f', -1))
+	default:
+		return fmt.Sprintf("[%q]", b.InstanceKey.AsString())
+	}
+}
+
+// instanceValues returns the each or count variables of an instance created
+// with for_each or count.
+func (b *SourceUseBlock) instanceValues() map[string]cty.Value {
+	switch {
+	case b.InstanceKey == cty.NilVal:
+		return map[string]cty.Value{}
+	case b.EachValue == cty.NilVal:
+		return map[string]cty.Value{
+			countAccessor: cty.ObjectVal(map[string]cty.Value{
+				"index": b.InstanceKey,
+			}),
+		}
+	default:
+		return map[string]cty.Value{
+			eachAccessor: cty.ObjectVal(map[string]cty.Value{
+				"key":   b.InstanceKey,
+				"value": b.EachValue,
+			}),
+		}
+	}
 }
 
 // EvalContext adds the values of the source to the passed eval context.
@@ -73,20 +150,222 @@ func (b *SourceUseBlock) ctyValues() map[string]cty.Value {
 //	    name = "local_name"
 //	  }
 //	}
-func (p *Parser) decodeBuildSource(block *hcl.Block) (SourceUseBlock, hcl.Diagnostics) {
+//
+// A source block with a for_each or a count argument is expanded into one
+// SourceUseBlock per instance.
+func (p *Parser) decodeBuildSource(block *hcl.Block, ectx *hcl.EvalContext) ([]SourceUseBlock, hcl.Diagnostics) {
 	ref := sourceRefFromString(block.Labels[0])
 	out := SourceUseBlock{SourceRef: ref}
-	var b struct {
-		Name string   `hcl:"name,optional"`
-		Rest hcl.Body `hcl:",remain"`
+
+	content, rest, diags := block.Body.PartialContent(sourceUseSchema)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	for _, block := range content.Blocks {
+		switch block.Type {
+		case buildRetryLabel:
+			if out.Retry != nil {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Only one " + buildRetryLabel + " block is allowed",
+					Subject:  block.DefRange.Ptr(),
+				})
+				continue
+			}
+			retry, moreDiags := decodeRetryBlock(block, ectx)
+			diags = append(diags, moreDiags...)
+			if moreDiags.HasErrors() {
+				continue
+			}
+			out.Retry = retry
+		}
+	}
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	instances, moreDiags := decodeSourceInstances(content.Attributes, ectx)
+	diags = append(diags, moreDiags...)
+	if moreDiags.HasErrors() {
+		return nil, diags
+	}
+	if instances == nil {
+		// no for_each nor count
+		instances = []SourceUseBlock{{}}
+	}
+
+	res := make([]SourceUseBlock, 0, len(instances))
+	for _, instance := range instances {
+		use := out
+		use.InstanceKey = instance.InstanceKey
+		use.EachValue = instance.EachValue
+
+		// each and count can be used to give a name to an instance.
+		var nameCtx *hcl.EvalContext
+		if instance.InstanceKey != cty.NilVal {
+			nameCtx = &hcl.EvalContext{Variables: use.instanceValues()}
+		}
+
+		var b struct {
+			Name             string   `hcl:"name,optional"`
+			Timeout          string   `hcl:"timeout,optional"`
+			ConcurrencyGroup string   `hcl:"concurrency_group,optional"`
+			Rest             hcl.Body `hcl:",remain"`
+		}
+		moreDiags := gohcl.DecodeBody(rest, nameCtx, &b)
+		diags = append(diags, moreDiags...)
+		if moreDiags.HasErrors() {
+			return nil, diags
+		}
+		use.LocalName = b.Name
+		use.ConcurrencyGroup = b.ConcurrencyGroup
+		use.Body = b.Rest
+
+		if b.Timeout != "" {
+			timeout, err := time.ParseDuration(b.Timeout)
+			if err != nil {
+				return nil, append(diags, &hcl.Diagnostic{
+					Summary:  "Failed to parse timeout duration",
+					Severity: hcl.DiagError,
+					Detail:   err.Error(),
+					Subject:  block.DefRange.Ptr(),
+				})
+			}
+			use.Timeout = timeout
+		}
+		res = append(res, use)
 	}
-	diags := gohcl.DecodeBody(block.Body, nil, &b)
+	return res, diags
+}
+
+// decodeSourceInstances evaluates the for_each or count argument of a source
+// block of a build, and returns the instances to create. It returns nil when
+// none of them is set.
+func decodeSourceInstances(attrs hcl.Attributes, ectx *hcl.EvalContext) ([]SourceUseBlock, hcl.Diagnostics) {
+	forEach, hasForEach := attrs["for_each"]
+	count, hasCount := attrs["count"]
+
+	switch {
+	case hasForEach && hasCount:
+		return nil, hcl.Diagnostics{&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  `Invalid combination of "count" and "for_each"`,
+			Detail:   `The "count" and "for_each" arguments are mutually-exclusive, only one should be used.`,
+			Subject:  count.NameRange.Ptr(),
+		}}
+	case hasCount:
+		instances, diags := decodeSourceCount(count, ectx)
+		return instances, append(diags, noSourceInstancesDiags(count, instances, diags)...)
+	case hasForEach:
+		instances, diags := decodeSourceForEach(forEach, ectx)
+		return instances, append(diags, noSourceInstancesDiags(forEach, instances, diags)...)
+	}
+	return nil, nil
+}
+
+// noSourceInstancesDiags warns when attr expanded a source block to zero
+// instances, since nothing will then be built from it.
+func noSourceInstancesDiags(attr *hcl.Attribute, instances []SourceUseBlock, diags hcl.Diagnostics) hcl.Diagnostics {
+	if diags.HasErrors() || len(instances) > 0 {
+		return nil
+	}
+	return hcl.Diagnostics{&hcl.Diagnostic{
+		Severity: hcl.DiagWarning,
+		Summary:  "No instances of source",
+		Detail: fmt.Sprintf("The %s argument expands this source block to zero "+
+			"instances, so nothing is built from it.", attr.Name),
+		Subject: attr.Expr.Range().Ptr(),
+	}}
+}
+
+func decodeSourceCount(attr *hcl.Attribute, ectx *hcl.EvalContext) ([]SourceUseBlock, hcl.Diagnostics) {
+	val, diags := attr.Expr.Value(ectx)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	if val.ContainsMarked() {
+		return nil, append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid count argument",
+			Detail:   "Sensitive values, or values derived from sensitive values, cannot be used as count arguments.",
+			Subject:  attr.Expr.Range().Ptr(),
+		})
+	}
+
+	var count int
+	if err := gocty.FromCtyValue(val, &count); err != nil || count < 0 {
+		detail := "The count argument must be a whole number, zero or greater."
+		if err != nil {
+			detail = fmt.Sprintf("%s %s.", detail, err)
+		}
+		return nil, append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid count argument",
+			Detail:   detail,
+			Subject:  attr.Expr.Range().Ptr(),
+		})
+	}
+
+	res := make([]SourceUseBlock, 0, count)
+	for i := 0; i < count; i++ {
+		res = append(res, SourceUseBlock{InstanceKey: cty.NumberIntVal(int64(i))})
+	}
+	return res, diags
+}
+
+func decodeSourceForEach(attr *hcl.Attribute, ectx *hcl.EvalContext) ([]SourceUseBlock, hcl.Diagnostics) {
+	val, diags := attr.Expr.Value(ectx)
 	if diags.HasErrors() {
-		return out, diags
+		return nil, diags
+	}
+
+	invalid := func(detail string) hcl.Diagnostics {
+		return append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid for_each argument",
+			Detail:   detail,
+			Subject:  attr.Expr.Range().Ptr(),
+		})
+	}
+
+	ty := val.Type()
+	switch {
+	case val.IsNull():
+		return nil, invalid("The given for_each argument value is null. A map, or a set or list of strings is allowed.")
+	case !val.IsWhollyKnown():
+		return nil, invalid("The for_each value must be known when the template is parsed.")
+	case val.ContainsMarked():
+		return nil, invalid("Sensitive values, or values derived from sensitive values, cannot be used as for_each arguments.")
+	case ty.IsMapType(), ty.IsObjectType():
+		res := []SourceUseBlock{}
+		for it := val.ElementIterator(); it.Next(); {
+			key, value := it.Element()
+			res = append(res, SourceUseBlock{InstanceKey: key, EachValue: value})
+		}
+		return res, diags
+	case ty.IsSetType(), ty.IsListType(), ty.IsTupleType():
+	default:
+		return nil, invalid(fmt.Sprintf("The given for_each argument value is a %s. A map, or a set or list of strings is allowed.",
+			ty.FriendlyName()))
+	}
+
+	// The key of each element of a set or list of strings is the element
+	// itself.
+	res := []SourceUseBlock{}
+	seen := map[string]bool{}
+	for it := val.ElementIterator(); it.Next(); {
+		_, value := it.Element()
+		if value.IsNull() || !value.Type().Equals(cty.String) {
+			return nil, invalid("The given for_each argument value must only contain strings.")
+		}
+		key := value.AsString()
+		if seen[key] {
+			return nil, invalid(fmt.Sprintf("The given for_each argument value contains %q more than once.", key))
+		}
+		seen[key] = true
+		res = append(res, SourceUseBlock{InstanceKey: value, EachValue: value})
 	}
-	out.LocalName = b.Name
-	out.Body = b.Rest
-	return out, nil
+	return res, diags
 }
 
 func (p *Parser) decodeSource(block *hcl.Block) (SourceBlock, hcl.Diagnostics) {
@@ -116,6 +395,9 @@ func (cfg *PackerConfig) startBuilder(source SourceUseBlock, ectx *hcl.EvalConte
 	body := source.Body
 	// Add known values to source accessor in eval context.
 	ectx.Variables[sourcesAccessor] = cty.ObjectVal(source.ctyValues())
+	for k, v := range source.instanceValues() {
+		ectx.Variables[k] = v
+	}
 
 	decoded, moreDiags := decodeHCL2Spec(body, ectx, builder)
 	diags = append(diags, moreDiags...)
@@ -36,17 +36,48 @@ type LocalBlock struct {
 	// Only `local`/`locals` will be referenced here as we execute all the
 	// same component types at once.
 	dependencies []refString
+	// variables are the input variables referenced by the local, they are
+	// evaluated beforehand.
+	variables []refString
 	// evaluated toggles to true if it has been evaluated.
 	//
 	// We use this to determine if we're ready to get the value of the
 	// expression.
 	evaluated bool
+	// conditions are the conditions of the locals block declaring this
+	// local, if any.
+	conditions *localsConditions
 }
 
 func (l LocalBlock) Name() string {
 	return fmt.Sprintf("local.%s", l.LocalName)
 }
 
+// references returns the references of the local, and of the preconditions
+// of its locals block, as they are checked before the local is evaluated.
+func (l *LocalBlock) references() []hcl.Traversal {
+	refs := l.Expr.Variables()
+	if l.conditions != nil {
+		refs = append(refs, l.conditions.preconditionReferences()...)
+	}
+	return refs
+}
+
+// localsConditions are the conditions of a locals block, shared by the
+// locals it declares.
+type localsConditions struct {
+	Conditions
+
+	// names of the locals declared in the block, they are available in
+	// postconditions through self.
+	names []string
+
+	// checked is set once the preconditions were checked, and failed if
+	// they did not pass.
+	checked bool
+	failed  bool
+}
+
 // VariableAssignment represents a way a variable was set: the expression
 // setting it and the value of that expression. It helps pinpoint were
 // something was set in diagnostics.
@@ -106,10 +137,14 @@ func (v *Variable) validateValue(val VariableAssignment) (diags hcl.Diagnostics)
 		return nil
 	}
 
+	value := val.Value
+	if v.Sensitive {
+		value = markSensitive(value)
+	}
 	hclCtx := &hcl.EvalContext{
 		Variables: map[string]cty.Value{
 			"var": cty.ObjectVal(map[string]cty.Value{
-				v.Name: val.Value,
+				v.Name: value,
 			}),
 		},
 		Functions: Functions(""),
@@ -152,6 +187,7 @@ func (v *Variable) validateValue(val VariableAssignment) (diags hcl.Diagnostics)
 			continue
 		}
 
+		result, _ = result.UnmarkDeep()
 		if result.False() {
 			subj := validation.DeclRange.Ptr()
 			if val.Expr != nil {
@@ -178,6 +214,15 @@ func (v *Variable) Value() cty.Value {
 	return val.Value
 }
 
+// markedValue returns the value of the Variable, marked as sensitive if the
+// Variable is sensitive.
+func (v *Variable) markedValue() cty.Value {
+	if v.Sensitive {
+		return markSensitive(v.Value())
+	}
+	return v.Value()
+}
+
 // ValidateValue tells if the selected value for the Variable is valid according
 // to its validation settings.
 func (v *Variable) ValidateValue() hcl.Diagnostics {
@@ -208,15 +253,19 @@ func (variables Variables) Keys() []string {
 func (variables Variables) Values() map[string]cty.Value {
 	res := map[string]cty.Value{}
 	for k, v := range variables {
-		value := v.Value()
-		res[k] = value
+		res[k] = v.markedValue()
 	}
 	return res
 }
 
-func (variables Variables) ValidateValues() hcl.Diagnostics {
+// ValidateValues validates the values of the variables. Variables without a
+// value are errors unless allowUnset is set.
+func (variables Variables) ValidateValues(allowUnset bool) hcl.Diagnostics {
 	var diags hcl.Diagnostics
 	for _, v := range variables {
+		if allowUnset && len(v.Values) == 0 {
+			continue
+		}
 		diags = append(diags, v.ValidateValue()...)
 	}
 	return diags
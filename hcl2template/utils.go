@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/gobwas/glob"
@@ -99,12 +100,21 @@ func GetHCL2Files(filename, hclSuffix, jsonSuffix string) (hclFiles, jsonFiles [
 	return hclFiles, jsonFiles, diags
 }
 
+// instanceIndexRegexp matches the index ending the name of an instance of a
+// source block with for_each or count, like `["us-east-1"]` or `[0]`.
+var instanceIndexRegexp = regexp.MustCompile(`\[("(?:[^"\\]|\\.)*"|[0-9]+)\]$`)
+
 // Convert -only and -except globs to glob.Glob instances.
 func convertFilterOption(patterns []string, optionName string) ([]glob.Glob, hcl.Diagnostics) {
 	var globs []glob.Glob
 	var diags hcl.Diagnostics
 
 	for _, pattern := range patterns {
+		// The index of an instance is matched literally, and not as a
+		// character class.
+		if loc := instanceIndexRegexp.FindStringIndex(pattern); loc != nil {
+			pattern = pattern[:loc[0]] + glob.QuoteMeta(pattern[loc[0]:])
+		}
 		g, err := glob.Compile(pattern)
 		if err != nil {
 			diags = append(diags, &hcl.Diagnostic{
@@ -118,7 +128,22 @@ func convertFilterOption(patterns []string, optionName string) ([]glob.Glob, hcl
 	return globs, diags
 }
 
+// matchesAny tells whether g matches one of names.
+func matchesAny(g glob.Glob, names []string) bool {
+	for _, name := range names {
+		if g.Match(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// PrintableCtyValue returns a human readable representation of v. Values that
+// are, or contain, sensitive values are not shown.
 func PrintableCtyValue(v cty.Value) string {
+	if v.ContainsMarked() {
+		return sensitiveValueDisplay
+	}
 	if !v.IsWhollyKnown() {
 		return "<unknown>"
 	}
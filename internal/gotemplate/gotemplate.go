@@ -0,0 +1,243 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+// Package gotemplate renders files with the Go text/template syntax, for files
+// whose own syntax clashes with HCL templates, like shell scripts using ${}.
+//
+// Templates can only use the helpers of Funcs, none of which reads files or
+// the environment.
+package gotemplate
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Render renders src, the content of the template name, with vars. Using a
+// missing variable is an error.
+func Render(name, src string, vars map[string]interface{}) (string, error) {
+	tmpl, err := template.New(name).
+		Option("missingkey=error").
+		Funcs(Funcs()).
+		Parse(src)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, vars); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Funcs returns the helpers available in templates. They follow the names and
+// the argument order of their sprig counterparts, so that pipelines like
+// {{ .name | trimPrefix "v" | upper }} work, and have no side effect.
+func Funcs() template.FuncMap {
+	return template.FuncMap{
+		// Defaults and conditions.
+		"default": func(def interface{}, val ...interface{}) interface{} {
+			if len(val) == 0 || isEmpty(val[0]) {
+				return def
+			}
+			return val[0]
+		},
+		"empty": isEmpty,
+		"coalesce": func(vals ...interface{}) interface{} {
+			for _, val := range vals {
+				if !isEmpty(val) {
+					return val
+				}
+			}
+			return nil
+		},
+		"ternary": func(ifTrue, ifFalse interface{}, cond bool) interface{} {
+			if cond {
+				return ifTrue
+			}
+			return ifFalse
+		},
+
+		// Strings.
+		"upper": strings.ToUpper,
+		"lower": strings.ToLower,
+		"title": func(s string) string {
+			runes := []rune(s)
+			for i, r := range runes {
+				if i == 0 || unicode.IsSpace(runes[i-1]) {
+					runes[i] = unicode.ToTitle(r)
+				}
+			}
+			return string(runes)
+		},
+		"trim":       strings.TrimSpace,
+		"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+		"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+		"replace":    func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+		"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+		"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+		"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+		"repeat":     func(count int, s string) string { return strings.Repeat(s, count) },
+		"splitList":  func(sep, s string) []string { return strings.Split(s, sep) },
+		"join": func(sep string, list interface{}) (string, error) {
+			elems, err := toList(list)
+			if err != nil {
+				return "", err
+			}
+			strs := make([]string, 0, len(elems))
+			for _, elem := range elems {
+				strs = append(strs, fmt.Sprint(elem))
+			}
+			return strings.Join(strs, sep), nil
+		},
+		"quote":   func(s interface{}) string { return fmt.Sprintf("%q", fmt.Sprint(s)) },
+		"squote":  func(s interface{}) string { return "'" + fmt.Sprint(s) + "'" },
+		"indent":  indent,
+		"nindent": func(n int, s string) string { return "\n" + indent(n, s) },
+
+		// Encodings.
+		"toJson": func(v interface{}) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+		"toPrettyJson": func(v interface{}) (string, error) {
+			b, err := json.MarshalIndent(v, "", "  ")
+			return string(b), err
+		},
+		"b64enc": func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+		"b64dec": func(s string) (string, error) {
+			b, err := base64.StdEncoding.DecodeString(s)
+			return string(b), err
+		},
+		"sha256sum": func(s string) string {
+			sum := sha256.Sum256([]byte(s))
+			return hex.EncodeToString(sum[:])
+		},
+
+		// Collections.
+		"list": func(vals ...interface{}) []interface{} { return vals },
+		"dict": func(pairs ...interface{}) (map[string]interface{}, error) {
+			if len(pairs)%2 != 0 {
+				return nil, fmt.Errorf("dict expects pairs of keys and values, got %d arguments", len(pairs))
+			}
+			m := make(map[string]interface{}, len(pairs)/2)
+			for i := 0; i < len(pairs); i += 2 {
+				key, ok := pairs[i].(string)
+				if !ok {
+					return nil, fmt.Errorf("dict keys must be strings, got %T", pairs[i])
+				}
+				m[key] = pairs[i+1]
+			}
+			return m, nil
+		},
+		"keys": func(m map[string]interface{}) []string {
+			keys := make([]string, 0, len(m))
+			for k := range m {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			return keys
+		},
+	}
+}
+
+func indent(n int, s string) string {
+	pad := strings.Repeat(" ", n)
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+}
+
+// isEmpty tells whether val is a zero value, or an empty collection.
+func isEmpty(val interface{}) bool {
+	if val == nil {
+		return true
+	}
+	v := reflect.ValueOf(val)
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
+
+func toList(list interface{}) ([]interface{}, error) {
+	v := reflect.ValueOf(list)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("expected a list, got %T", list)
+	}
+	elems := make([]interface{}, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		elems = append(elems, v.Index(i).Interface())
+	}
+	return elems, nil
+}
+
+// RenderDir renders all the files of srcDir with vars, and writes them with the
+// same relative paths and permissions into destDir, which is created if
+// needed. Existing files are overwritten, other files of destDir are left as
+// is. Files that are not valid UTF-8, like images, are copied as is.
+func RenderDir(srcDir, destDir string, vars map[string]interface{}) error {
+	return filepath.WalkDir(srcDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+		dest := filepath.Join(destDir, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(dest, 0755)
+		case !info.Mode().IsRegular():
+			return nil
+		}
+
+		return renderFile(filepath.ToSlash(rel), path, dest, info.Mode().Perm(), vars)
+	})
+}
+
+// RenderFile renders the file src with vars, and writes it to dst with the
+// permissions perm. A file that is not valid UTF-8, like an image, is copied
+// as is.
+func RenderFile(src, dst string, perm os.FileMode, vars map[string]interface{}) error {
+	return renderFile(filepath.Base(src), src, dst, perm, vars)
+}
+
+func renderFile(name, src, dst string, perm os.FileMode, vars map[string]interface{}) error {
+	content, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	if utf8.Valid(content) {
+		out, err := Render(name, string(content), vars)
+		if err != nil {
+			return err
+		}
+		content = []byte(out)
+	}
+	if err := os.WriteFile(dst, content, perm); err != nil {
+		return err
+	}
+	// WriteFile doesn't change the mode of existing files.
+	return os.Chmod(dst, perm)
+}
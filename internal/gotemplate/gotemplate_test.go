@@ -0,0 +1,95 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package gotemplate
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFuncs(t *testing.T) {
+	vars := map[string]interface{}{
+		"name":  "v1.2.3",
+		"empty": "",
+		"tags":  []interface{}{"a", "b"},
+		"size":  int64(20),
+		"meta":  map[string]interface{}{"b": 2, "a": 1},
+	}
+
+	tests := []struct {
+		Template string
+		Want     string
+	}{
+		{`{{ .name | trimPrefix "v" | upper }}`, `1.2.3`},
+		{`{{ .empty | default "none" }}`, `none`},
+		{`{{ coalesce .empty .name }}`, `v1.2.3`},
+		{`{{ ternary "big" "small" (gt .size 10) }}`, `big`},
+		{`{{ join "," .tags }}`, `a,b`},
+		{`{{ splitList "." .name | len }}`, `3`},
+		{`{{ "hello world" | title }}`, `Hello World`},
+		{`{{ .name | replace "." "-" }}`, `v1-2-3`},
+		{`{{ .name | quote }} {{ .name | squote }}`, `"v1.2.3" 'v1.2.3'`},
+		{`{{ "a\nb" | indent 2 }}`, `  a
+  b`},
+		{`{{ .tags | toJson }}`, `["a","b"]`},
+		{`{{ .name | b64enc | b64dec }}`, `v1.2.3`},
+		{`{{ "abc" | sha256sum }}`, `ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad`},
+		{`{{ keys .meta | join "," }}`, `a,b`},
+		{`{{ (dict "k" "v").k }} {{ index (list 1 2) 1 }}`, `v 2`},
+		{`{{ if contains "2" .name }}yes{{ end }}`, `yes`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Template, func(t *testing.T) {
+			got, err := Render("test", test.Template, vars)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got != test.Want {
+				t.Errorf("wrong result\ngot:  %q\nwant: %q", got, test.Want)
+			}
+		})
+	}
+}
+
+func TestRenderDir(t *testing.T) {
+	dest := filepath.Join(t.TempDir(), "http")
+	vars := map[string]interface{}{
+		"hostname": "builder",
+	}
+
+	if err := RenderDir(filepath.Join("testdata", "http"), dest, vars); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for path, want := range map[string]string{
+		"preseed.cfg": "d-i netcfg/get_hostname string builder\n" +
+			"d-i preseed/late_command string in-target sh -c 'echo ${USER}'\n",
+		"scripts/setup.sh": "#!/bin/sh\necho \"BUILDER: ${1}\"\n",
+		"logo.png":         "\x89PNG\r\n\x1a\n\xff{{ .nope }}",
+	} {
+		content, err := os.ReadFile(filepath.Join(dest, filepath.FromSlash(path)))
+		if err != nil {
+			t.Fatalf("failed to read rendered file: %s", err)
+		}
+		if string(content) != want {
+			t.Errorf("wrong content for %s\ngot:  %q\nwant: %q", path, content, want)
+		}
+	}
+
+	info, err := os.Stat(filepath.Join(dest, "scripts", "setup.sh"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm()&0100 == 0 {
+		t.Errorf("executable bit of setup.sh was not kept: %s", info.Mode())
+	}
+
+	err = RenderDir(filepath.Join("testdata", "http"), dest, map[string]interface{}{})
+	if err == nil || !strings.Contains(err.Error(), `map has no entry for key "hostname"`) {
+		t.Errorf("expected an error for missing variables, got %v", err)
+	}
+}
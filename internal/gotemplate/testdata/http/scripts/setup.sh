@@ -0,0 +1,2 @@
+#!/bin/sh
+echo "{{ .hostname | upper }}: ${1}"
@@ -93,7 +93,8 @@ func (h *HCLRegistry) VersionStatusSummary() {
 
 func NewHCLRegistry(config *hcl2template.PackerConfig, ui sdkpacker.Ui) (*HCLRegistry, hcl.Diagnostics) {
 	var diags hcl.Diagnostics
-	if len(config.Builds) > 1 {
+	builds := config.AllBuilds()
+	if len(builds) > 1 {
 		diags = append(diags, &hcl.Diagnostic{
 			Severity: hcl.DiagError,
 			Summary:  "Multiple " + buildLabel + " blocks",
@@ -119,9 +120,9 @@ func NewHCLRegistry(config *hcl2template.PackerConfig, ui sdkpacker.Ui) (*HCLReg
 
 	// we must use the old strategy when there is only a single build block because
 	// we used to rely on the parent build block for setting some default data
-	if len(config.Builds) == 1 && config.HCPPackerRegistry == nil {
+	if len(builds) == 1 && config.HCPPackerRegistry == nil {
 		withHCLBucketConfiguration = func(bucket *Bucket) hcl.Diagnostics {
-			bb := config.Builds[0]
+			bb := builds[0].Build
 			bucket.ReadFromHCLBuildBlock(bb)
 			// If at this point the bucket.Name is still empty,
 			// last try is to use the build.Name if present
@@ -177,8 +178,8 @@ func (h *HCLRegistry) registerAllComponents() hcl.Diagnostics {
 	conflictSources := map[string]struct{}{}
 
 	// we currently support only one build block but it will change in the near future
-	for _, build := range h.configuration.Builds {
-		for _, source := range build.Sources {
+	for _, named := range h.configuration.AllBuilds() {
+		for _, source := range named.Build.Sources {
 			// If we encounter the same source twice, we'll defer
 			// its addition to later, using both the build name
 			// and the source type as the name used for HCP Packer.
@@ -204,15 +205,16 @@ func (h *HCLRegistry) registerAllComponents() hcl.Diagnostics {
 	//
 	// If that happens, we then use a combination of both the build name, and
 	// the source type.
-	for _, build := range h.configuration.Builds {
+	for _, named := range h.configuration.AllBuilds() {
+		build := named.Build
 		for _, source := range build.Sources {
 			if _, ok := conflictSources[source.String()]; !ok {
 				continue
 			}
 
 			buildName := source.String()
-			if build.Name != "" {
-				buildName = fmt.Sprintf("%s.%s", build.Name, buildName)
+			if named.Name != "" {
+				buildName = fmt.Sprintf("%s.%s", named.Name, buildName)
 			}
 
 			if _, ok := h.buildNames[buildName]; ok {
@@ -39,8 +39,8 @@ func IsHCPEnabled(cfg packer.Handler) bool {
 
 	switch config := cfg.(type) {
 	case *hcl2template.PackerConfig:
-		for _, build := range config.Builds {
-			if build.HCPPackerRegistry != nil {
+		for _, named := range config.AllBuilds() {
+			if named.Build.HCPPackerRegistry != nil {
 				mode = HCPConfigEnabled
 			}
 		}
@@ -0,0 +1,169 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package lsp
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/packer/hcl2template"
+	"github.com/hashicorp/packer/packer"
+)
+
+// isConfigFile tells whether path is an HCL2 config or variables file.
+func isConfigFile(path string) bool {
+	for _, ext := range []string{".pkr.hcl", ".pkr.json", ".pkrvars.hcl", ".pkrvars.json"} {
+		if strings.HasSuffix(path, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// configFiles returns the config files of dir, on disk or opened in the
+// editor, sorted.
+func (s *Server) configFiles(dir string) []string {
+	files := map[string]bool{}
+	if entries, err := os.ReadDir(dir); err == nil {
+		for _, entry := range entries {
+			path := filepath.Join(dir, entry.Name())
+			if !entry.IsDir() && isConfigFile(path) {
+				files[path] = true
+			}
+		}
+	}
+	for path := range s.docs {
+		if filepath.Dir(path) == dir && isConfigFile(path) {
+			files[path] = true
+		}
+	}
+
+	res := make([]string, 0, len(files))
+	for path := range files {
+		res = append(res, path)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// validate parses and initializes the config of dir like `packer validate`,
+// without executing data sources. The opened documents are used instead of
+// their content on disk.
+func (s *Server) validate(dir string) hcl.Diagnostics {
+	parser := &hcl2template.Parser{
+		CorePackerVersion:       s.CorePackerVersion,
+		CorePackerVersionString: s.CorePackerVersionString,
+		Parser:                  hclparse.NewParser(),
+		PluginConfig:            s.PluginConfig,
+	}
+
+	// The files parsed here are cached by the parser, so they are not read
+	// from disk when parsing the config.
+	var diags hcl.Diagnostics
+	for _, path := range s.configFiles(dir) {
+		src, found := s.docs[path]
+		if !found {
+			continue
+		}
+		var moreDiags hcl.Diagnostics
+		if strings.HasSuffix(path, ".json") {
+			_, moreDiags = parser.ParseJSON(src, path)
+		} else {
+			_, moreDiags = parser.ParseHCL(src, path)
+		}
+		diags = append(diags, moreDiags...)
+	}
+
+	cfg, moreDiags := parser.Parse(dir, nil, nil)
+	diags = append(diags, moreDiags...)
+	if cfg == nil || diags.HasErrors() {
+		return diags
+	}
+
+	moreDiags = cfg.DetectPluginBinaries()
+	diags = append(diags, moreDiags...)
+	if moreDiags.HasErrors() {
+		return diags
+	}
+
+	moreDiags = cfg.Initialize(packer.InitializeOptions{
+		SkipDatasourcesExecution: true,
+	})
+	diags = append(diags, moreDiags...)
+	if moreDiags.HasErrors() {
+		return diags
+	}
+
+	_, moreDiags = cfg.GetBuilds(packer.GetBuildsOptions{})
+	return append(diags, moreDiags...)
+}
+
+// publishDiagnostics validates the config of the directory of path, and
+// publishes the diagnostics of each of its files. Files without diagnostics
+// are published too, to clear their previous diagnostics.
+func (s *Server) publishDiagnostics(path string) error {
+	if !isConfigFile(path) {
+		return nil
+	}
+	dir := filepath.Dir(path)
+
+	byFile := map[string][]Diagnostic{
+		path: {},
+	}
+	for _, file := range s.configFiles(dir) {
+		byFile[file] = []Diagnostic{}
+	}
+
+	for _, diag := range s.validate(dir) {
+		filename := path
+		rng := Range{}
+		if diag.Subject != nil {
+			filename = diag.Subject.Filename
+			rng = toRange(*diag.Subject)
+		}
+		if _, found := byFile[filename]; !found {
+			if _, err := os.Stat(filename); err != nil {
+				// Values from the environment or the command line have
+				// no file.
+				filename = path
+				rng = Range{}
+			}
+		}
+
+		severity := SeverityError
+		if diag.Severity == hcl.DiagWarning {
+			severity = SeverityWarning
+		}
+		message := diag.Summary
+		if diag.Detail != "" {
+			message += ": " + diag.Detail
+		}
+		byFile[filename] = append(byFile[filename], Diagnostic{
+			Range:    rng,
+			Severity: severity,
+			Source:   "packer",
+			Message:  message,
+		})
+	}
+
+	files := make([]string, 0, len(byFile))
+	for file := range byFile {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		err := s.conn.notify("textDocument/publishDiagnostics", &PublishDiagnosticsParams{
+			URI:         pathToURI(file),
+			Diagnostics: byFile[file],
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
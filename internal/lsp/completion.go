@@ -0,0 +1,321 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package lsp
+
+import (
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/packer/hcl2template"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// blockAt is a block containing the position to complete.
+type blockAt struct {
+	Type   string
+	Labels []string
+}
+
+// completion returns the functions that can be called when pos is in the
+// value of an argument, else the arguments and blocks that can be set in the
+// block containing pos.
+func (s *Server) completion(path string, pos Position) *CompletionList {
+	src, err := s.content(path)
+	if err != nil || !strings.HasSuffix(path, ".pkr.hcl") {
+		return nil
+	}
+	offset := byteOffset(src, pos)
+
+	lineStart := strings.LastIndexByte(string(src[:offset]), '\n') + 1
+	if strings.Contains(string(src[lineStart:offset]), "=") {
+		return s.functionCompletion(filepath.Dir(path))
+	}
+
+	// The word being typed is blanked so that the document parses.
+	blanked := make([]byte, len(src))
+	copy(blanked, src)
+	for i := lineStart; i < offset; i++ {
+		blanked[i] = ' '
+	}
+	file, _ := hclsyntax.ParseConfig(blanked, path, hcl.InitialPos)
+	if file == nil {
+		return nil
+	}
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil
+	}
+
+	var blocks []blockAt
+	for body != nil {
+		var inner *hclsyntax.Body
+		for _, block := range body.Blocks {
+			if offset >= block.OpenBraceRange.End.Byte && offset <= block.CloseBraceRange.Start.Byte {
+				blocks = append(blocks, blockAt{Type: block.Type, Labels: block.Labels})
+				inner = block.Body
+				break
+			}
+		}
+		body = inner
+	}
+
+	if spec := s.blockSpec(blocks); spec != nil {
+		return specCompletion(spec)
+	}
+
+	types := make([]string, 0, len(blocks))
+	for _, block := range blocks {
+		types = append(types, block.Type)
+	}
+	schema := hcl2template.BlockSchema(types...)
+	if schema == nil {
+		return &CompletionList{Items: []CompletionItem{}}
+	}
+	return schemaCompletion(schema)
+}
+
+// functionCompletion returns the functions that can be called in the config
+// of dir.
+func (s *Server) functionCompletion(dir string) *CompletionList {
+	list := &CompletionList{Items: []CompletionItem{}}
+	for name, fn := range s.functions(dir) {
+		list.Items = append(list.Items, CompletionItem{
+			Label:  name,
+			Kind:   CompletionKindFunction,
+			Detail: fn.Description(),
+		})
+	}
+	sortItems(list.Items)
+	return list
+}
+
+// functions returns the builtin functions and the ones provided by the
+// installed plugins.
+func (s *Server) functions(dir string) map[string]function.Function {
+	funcs := hcl2template.Functions(dir)
+	if s.PluginConfig != nil {
+		for name, fn := range s.PluginConfig.Functions {
+			funcs[name] = fn
+		}
+	}
+	return funcs
+}
+
+// blockSpec returns the spec of the body of the innermost of blocks when it
+// configures a plugin component, or a block nested in one.
+func (s *Server) blockSpec(blocks []blockAt) hcldec.Spec {
+	for i := range blocks {
+		kind, typ := componentAt(blocks[:i+1])
+		if kind == "" {
+			continue
+		}
+		var spec hcldec.Spec = s.componentSpec(kind, typ)
+		for _, nested := range blocks[i+1:] {
+			if spec == nil {
+				return nil
+			}
+			spec = nestedSpec(spec, nested.Type)
+		}
+		if spec == nil {
+			return nil
+		}
+		return spec
+	}
+	return nil
+}
+
+// componentAt returns the kind and the type of the component configured by
+// the last of blocks, if any.
+func componentAt(blocks []blockAt) (string, string) {
+	last := blocks[len(blocks)-1]
+	if len(last.Labels) == 0 {
+		return "", ""
+	}
+	types := make([]string, 0, len(blocks))
+	for _, block := range blocks {
+		types = append(types, block.Type)
+	}
+
+	switch strings.Join(types, ".") {
+	case "source":
+		return "source", last.Labels[0]
+	case "data":
+		return "data", last.Labels[0]
+	case "build.source":
+		// source "source.type.name" { ... }
+		parts := strings.Split(last.Labels[0], ".")
+		if len(parts) == 3 && parts[0] == "source" {
+			return "source", parts[1]
+		}
+	case "build.provisioner", "build.error-cleanup-provisioner":
+		return "provisioner", last.Labels[0]
+	case "build.post-processor", "build.post-processors.post-processor":
+		return "post-processor", last.Labels[0]
+	}
+	return "", ""
+}
+
+// componentSpec returns the ConfigSpec of a plugin component, or nil when it
+// is not installed.
+func (s *Server) componentSpec(kind, typ string) hcldec.ObjectSpec {
+	key := kind + "." + typ
+	if spec, found := s.specs[key]; found {
+		return spec
+	}
+	if s.PluginConfig == nil {
+		return nil
+	}
+
+	var spec hcldec.ObjectSpec
+	var err error
+	switch kind {
+	case "source":
+		if s.PluginConfig.Builders != nil && s.PluginConfig.Builders.Has(typ) {
+			var builder interface{ ConfigSpec() hcldec.ObjectSpec }
+			builder, err = s.PluginConfig.Builders.Start(typ)
+			if err == nil {
+				spec = builder.ConfigSpec()
+			}
+		}
+	case "data":
+		if s.PluginConfig.DataSources != nil && s.PluginConfig.DataSources.Has(typ) {
+			var datasource interface{ ConfigSpec() hcldec.ObjectSpec }
+			datasource, err = s.PluginConfig.DataSources.Start(typ)
+			if err == nil {
+				spec = datasource.ConfigSpec()
+			}
+		}
+	case "provisioner":
+		if s.PluginConfig.Provisioners != nil && s.PluginConfig.Provisioners.Has(typ) {
+			var provisioner interface{ ConfigSpec() hcldec.ObjectSpec }
+			provisioner, err = s.PluginConfig.Provisioners.Start(typ)
+			if err == nil {
+				spec = provisioner.ConfigSpec()
+			}
+		}
+	case "post-processor":
+		if s.PluginConfig.PostProcessors != nil && s.PluginConfig.PostProcessors.Has(typ) {
+			var postProcessor interface{ ConfigSpec() hcldec.ObjectSpec }
+			postProcessor, err = s.PluginConfig.PostProcessors.Start(typ)
+			if err == nil {
+				spec = postProcessor.ConfigSpec()
+			}
+		}
+	}
+	if err != nil {
+		log.Printf("[WARN] lsp: failed to start %s %q: %s", kind, typ, err)
+	}
+
+	s.specs[key] = spec
+	return spec
+}
+
+// nestedSpec returns the spec of the body of the blocks of type typ of spec.
+func nestedSpec(spec hcldec.Spec, typ string) hcldec.Spec {
+	obj, ok := spec.(hcldec.ObjectSpec)
+	if !ok {
+		return nil
+	}
+	for _, child := range obj {
+		switch child := child.(type) {
+		case *hcldec.BlockSpec:
+			if child.TypeName == typ {
+				return child.Nested
+			}
+		case *hcldec.BlockListSpec:
+			if child.TypeName == typ {
+				return child.Nested
+			}
+		case *hcldec.BlockTupleSpec:
+			if child.TypeName == typ {
+				return child.Nested
+			}
+		case *hcldec.BlockSetSpec:
+			if child.TypeName == typ {
+				return child.Nested
+			}
+		case *hcldec.BlockMapSpec:
+			if child.TypeName == typ {
+				return child.Nested
+			}
+		case *hcldec.BlockObjectSpec:
+			if child.TypeName == typ {
+				return child.Nested
+			}
+		case *hcldec.BlockAttrsSpec:
+			if child.TypeName == typ {
+				// Any argument can be set in such a block.
+				return hcldec.ObjectSpec{}
+			}
+		}
+	}
+	return nil
+}
+
+// specCompletion returns the arguments and blocks of spec.
+func specCompletion(spec hcldec.Spec) *CompletionList {
+	list := &CompletionList{Items: []CompletionItem{}}
+	obj, ok := spec.(hcldec.ObjectSpec)
+	if !ok {
+		return list
+	}
+	for _, child := range obj {
+		switch child := child.(type) {
+		case *hcldec.AttrSpec:
+			list.Items = append(list.Items, attributeItem(child.Name, child.Required))
+		case *hcldec.BlockSpec:
+			list.Items = append(list.Items, blockItem(child.TypeName))
+		case *hcldec.BlockListSpec:
+			list.Items = append(list.Items, blockItem(child.TypeName))
+		case *hcldec.BlockTupleSpec:
+			list.Items = append(list.Items, blockItem(child.TypeName))
+		case *hcldec.BlockSetSpec:
+			list.Items = append(list.Items, blockItem(child.TypeName))
+		case *hcldec.BlockMapSpec:
+			list.Items = append(list.Items, blockItem(child.TypeName))
+		case *hcldec.BlockObjectSpec:
+			list.Items = append(list.Items, blockItem(child.TypeName))
+		case *hcldec.BlockAttrsSpec:
+			list.Items = append(list.Items, blockItem(child.TypeName))
+		}
+	}
+	sortItems(list.Items)
+	return list
+}
+
+// schemaCompletion returns the arguments and blocks of schema.
+func schemaCompletion(schema *hcl.BodySchema) *CompletionList {
+	list := &CompletionList{Items: []CompletionItem{}}
+	for _, attr := range schema.Attributes {
+		list.Items = append(list.Items, attributeItem(attr.Name, attr.Required))
+	}
+	for _, block := range schema.Blocks {
+		list.Items = append(list.Items, blockItem(block.Type))
+	}
+	sortItems(list.Items)
+	return list
+}
+
+func attributeItem(name string, required bool) CompletionItem {
+	detail := "optional argument"
+	if required {
+		detail = "required argument"
+	}
+	return CompletionItem{Label: name, Kind: CompletionKindProperty, Detail: detail}
+}
+
+func blockItem(typ string) CompletionItem {
+	return CompletionItem{Label: typ, Kind: CompletionKindKeyword, Detail: "block"}
+}
+
+func sortItems(items []CompletionItem) {
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Label < items[j].Label
+	})
+}
@@ -0,0 +1,253 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package lsp
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+const functionsDocURL = "https://developer.hashicorp.com/packer/docs/templates/hcl_templates/functions"
+
+// byteOffset returns the offset in src of pos.
+func byteOffset(src []byte, pos Position) int {
+	offset := 0
+	for line := 0; line < pos.Line && offset < len(src); offset++ {
+		if src[offset] == '\n' {
+			line++
+		}
+	}
+	for char := 0; char < pos.Character && offset < len(src) && src[offset] != '\n'; {
+		r, size := utf8.DecodeRune(src[offset:])
+		if n := utf16.RuneLen(r); n > 0 {
+			char += n
+		} else {
+			char++
+		}
+		offset += size
+	}
+	return offset
+}
+
+// toRange converts an HCL range. HCL columns count characters, which match
+// UTF-16 code units for most configs.
+func toRange(rng hcl.Range) Range {
+	pos := func(pos hcl.Pos) Position {
+		p := Position{Line: pos.Line - 1, Character: pos.Column - 1}
+		if p.Line < 0 {
+			p.Line = 0
+		}
+		if p.Character < 0 {
+			p.Character = 0
+		}
+		return p
+	}
+	return Range{Start: pos(rng.Start), End: pos(rng.End)}
+}
+
+// contains tells whether offset is in rng, or right after it as when the
+// cursor is at the end of a word.
+func contains(rng hcl.Range, offset int) bool {
+	return offset >= rng.Start.Byte && offset <= rng.End.Byte
+}
+
+// parseDocument parses the native syntax document at path. Documents with
+// syntax errors are partially parsed.
+func (s *Server) parseDocument(path string) (*hclsyntax.Body, []byte) {
+	src, err := s.content(path)
+	if err != nil || !strings.HasSuffix(path, ".hcl") {
+		return nil, nil
+	}
+	file, _ := hclsyntax.ParseConfig(src, path, hcl.InitialPos)
+	if file == nil {
+		return nil, nil
+	}
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil, nil
+	}
+	return body, src
+}
+
+// nodesAt returns the nodes of body containing offset, from the innermost
+// one.
+func nodesAt(body *hclsyntax.Body, offset int) []hclsyntax.Node {
+	var nodes []hclsyntax.Node
+	_ = hclsyntax.VisitAll(body, func(node hclsyntax.Node) hcl.Diagnostics {
+		if contains(node.Range(), offset) {
+			nodes = append([]hclsyntax.Node{node}, nodes...)
+		}
+		return nil
+	})
+	return nodes
+}
+
+// reference returns the reference of a traversal, like `var.name` or
+// `data.type.name`, without the attributes accessed on the referenced
+// object.
+func reference(traversal hcl.Traversal) string {
+	parts := []string{traversal.RootName()}
+	length := 2
+	switch parts[0] {
+	case "data", "source":
+		length = 3
+	}
+	for _, step := range traversal[1:] {
+		if len(parts) == length {
+			break
+		}
+		switch step := step.(type) {
+		case hcl.TraverseAttr:
+			parts = append(parts, step.Name)
+		case hcl.TraverseIndex:
+			if step.Key.Type() != cty.String || !step.Key.IsKnown() || step.Key.IsNull() {
+				return ""
+			}
+			parts = append(parts, step.Key.AsString())
+		default:
+			return ""
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// definitions returns the ranges of the declarations of the variables,
+// locals, data sources and sources of the config of dir, by reference.
+func (s *Server) definitions(dir string) map[string]hcl.Range {
+	defs := map[string]hcl.Range{}
+	for _, path := range s.configFiles(dir) {
+		if !strings.HasSuffix(path, ".pkr.hcl") {
+			continue
+		}
+		body, _ := s.parseDocument(path)
+		if body == nil {
+			continue
+		}
+		for _, block := range body.Blocks {
+			switch {
+			case block.Type == "variable" && len(block.Labels) == 1:
+				defs["var."+block.Labels[0]] = block.DefRange()
+			case block.Type == "local" && len(block.Labels) == 1:
+				defs["local."+block.Labels[0]] = block.DefRange()
+			case block.Type == "data" && len(block.Labels) == 2,
+				block.Type == "source" && len(block.Labels) == 2:
+				defs[strings.Join(append([]string{block.Type}, block.Labels...), ".")] = block.DefRange()
+			case block.Type == "variables":
+				for name, attr := range block.Body.Attributes {
+					defs["var."+name] = attr.NameRange
+				}
+			case block.Type == "locals":
+				for name, attr := range block.Body.Attributes {
+					defs["local."+name] = attr.NameRange
+				}
+			}
+		}
+	}
+	return defs
+}
+
+// definition returns the location of the declaration of the variable,
+// local, data source or source referenced at pos.
+func (s *Server) definition(path string, pos Position) []Location {
+	body, src := s.parseDocument(path)
+	if body == nil {
+		return nil
+	}
+
+	ref := ""
+	for _, node := range nodesAt(body, byteOffset(src, pos)) {
+		switch node := node.(type) {
+		case *hclsyntax.ScopeTraversalExpr:
+			ref = reference(node.Traversal)
+		case *hclsyntax.TemplateExpr:
+			// The sources of a build are strings, like "source.type.name".
+			if len(node.Parts) != 1 {
+				continue
+			}
+			lit, ok := node.Parts[0].(*hclsyntax.LiteralValueExpr)
+			if ok && lit.Val.Type() == cty.String {
+				ref = lit.Val.AsString()
+			}
+		}
+		if ref != "" {
+			break
+		}
+	}
+
+	rng, found := s.definitions(filepath.Dir(path))[ref]
+	if !found {
+		return []Location{}
+	}
+	return []Location{{
+		URI:   pathToURI(rng.Filename),
+		Range: toRange(rng),
+	}}
+}
+
+// hover returns the documentation of the function called at pos.
+func (s *Server) hover(path string, pos Position) *Hover {
+	body, src := s.parseDocument(path)
+	if body == nil {
+		return nil
+	}
+
+	offset := byteOffset(src, pos)
+	for _, node := range nodesAt(body, offset) {
+		call, ok := node.(*hclsyntax.FunctionCallExpr)
+		if !ok || !contains(call.NameRange, offset) {
+			continue
+		}
+		fn, found := s.functions(filepath.Dir(path))[call.Name]
+		if !found {
+			return nil
+		}
+		rng := toRange(call.NameRange)
+		return &Hover{
+			Contents: MarkupContent{
+				Kind:  "markdown",
+				Value: functionDoc(call.Name, fn),
+			},
+			Range: &rng,
+		}
+	}
+	return nil
+}
+
+// functionDoc returns the signature and the description of a function, as
+// markdown.
+func functionDoc(name string, fn function.Function) string {
+	params := fn.Params()
+	if varParam := fn.VarParam(); varParam != nil {
+		param := *varParam
+		param.Name = "..." + param.Name
+		params = append(params, param)
+	}
+
+	signature := make([]string, 0, len(params))
+	for _, param := range params {
+		signature = append(signature, fmt.Sprintf("%s %s", param.Name, typeexpr.TypeString(param.Type)))
+	}
+
+	doc := &strings.Builder{}
+	fmt.Fprintf(doc, "```hcl\n%s(%s)\n```\n", name, strings.Join(signature, ", "))
+	if description := fn.Description(); description != "" {
+		fmt.Fprintf(doc, "\n%s\n", description)
+	}
+	for _, param := range params {
+		if param.Description != "" {
+			fmt.Fprintf(doc, "\n- `%s`: %s", param.Name, param.Description)
+		}
+	}
+	fmt.Fprintf(doc, "\n\n[Documentation](%s)", functionsDocURL)
+	return doc.String()
+}
@@ -0,0 +1,236 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+// Package lsp implements a language server for Packer HCL2 configs. It talks
+// the Language Server Protocol over a stream, usually stdio, and provides
+// diagnostics, go-to-definition, hover documentation of functions and
+// completion.
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/packer/packer"
+)
+
+// Server is a language server for the HCL2 configs of Packer. The configs
+// are evaluated like `packer validate` does, without executing data
+// sources.
+type Server struct {
+	// PluginConfig gives the installed plugins, used to validate configs and
+	// to complete the arguments of components with their ConfigSpec.
+	PluginConfig *packer.PluginConfig
+
+	CorePackerVersion       *version.Version
+	CorePackerVersionString string
+
+	conn *conn
+	// docs are the contents of the opened documents, by path.
+	docs map[string][]byte
+	// specs caches the ConfigSpec of the plugin components, by kind and
+	// type, like `source.amazon-ebs`.
+	specs    map[string]hcldec.ObjectSpec
+	shutdown bool
+}
+
+// errExit is returned by the handlers when the client asks the server to
+// exit.
+var errExit = errors.New("exit")
+
+// Serve answers the requests read from in until the client asks the server
+// to exit, or ctx is done.
+func (s *Server) Serve(ctx context.Context, in io.Reader, out io.Writer) error {
+	s.conn = newConn(in, out)
+	s.docs = map[string][]byte{}
+	s.specs = map[string]hcldec.ObjectSpec{}
+
+	reqs := make(chan *request)
+	errs := make(chan error, 1)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			req, err := s.conn.read()
+			if err != nil {
+				if rerr, ok := err.(*responseError); ok {
+					_ = s.conn.reply(nil, nil, rerr)
+					continue
+				}
+				errs <- err
+				return
+			}
+			select {
+			case reqs <- req:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-errs:
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		case req := <-reqs:
+			result, err := s.handle(req)
+			if err == errExit {
+				if !s.shutdown {
+					return fmt.Errorf("exit requested before shutdown")
+				}
+				return nil
+			}
+			if req.ID == nil {
+				if err != nil {
+					log.Printf("[WARN] lsp: %s: %s", req.Method, err)
+				}
+				continue
+			}
+			if err := s.conn.reply(req.ID, result, err); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *Server) handle(req *request) (interface{}, error) {
+	log.Printf("[TRACE] lsp: handling %s", req.Method)
+
+	switch req.Method {
+	case "initialize":
+		return &InitializeResult{
+			Capabilities: ServerCapabilities{
+				TextDocumentSync:   1,
+				DefinitionProvider: true,
+				HoverProvider:      true,
+				CompletionProvider: &CompletionOptions{},
+			},
+			ServerInfo: ServerInfo{
+				Name:    "packer",
+				Version: s.CorePackerVersionString,
+			},
+		}, nil
+	case "initialized":
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "exit":
+		return nil, errExit
+
+	case "textDocument/didOpen":
+		var params DidOpenTextDocumentParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		path := uriToPath(params.TextDocument.URI)
+		s.docs[path] = []byte(params.TextDocument.Text)
+		return nil, s.publishDiagnostics(path)
+	case "textDocument/didChange":
+		var params DidChangeTextDocumentParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		if len(params.ContentChanges) == 0 {
+			return nil, nil
+		}
+		path := uriToPath(params.TextDocument.URI)
+		s.docs[path] = []byte(params.ContentChanges[len(params.ContentChanges)-1].Text)
+		return nil, s.publishDiagnostics(path)
+	case "textDocument/didSave":
+		var params DidSaveTextDocumentParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		return nil, s.publishDiagnostics(uriToPath(params.TextDocument.URI))
+	case "textDocument/didClose":
+		var params DidCloseTextDocumentParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		path := uriToPath(params.TextDocument.URI)
+		delete(s.docs, path)
+		return nil, s.publishDiagnostics(path)
+
+	case "textDocument/definition":
+		var params TextDocumentPositionParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		return s.definition(uriToPath(params.TextDocument.URI), params.Position), nil
+	case "textDocument/hover":
+		var params TextDocumentPositionParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		return s.hover(uriToPath(params.TextDocument.URI), params.Position), nil
+	case "textDocument/completion":
+		var params TextDocumentPositionParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		return s.completion(uriToPath(params.TextDocument.URI), params.Position), nil
+	}
+
+	if strings.HasPrefix(req.Method, "$/") {
+		// Optional notifications, like $/cancelRequest, can be ignored.
+		return nil, nil
+	}
+	return nil, &responseError{
+		Code:    codeMethodNotFound,
+		Message: fmt.Sprintf("method %q is not supported", req.Method),
+	}
+}
+
+func unmarshalParams(req *request, v interface{}) error {
+	if err := json.Unmarshal(req.Params, v); err != nil {
+		return &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+// content returns the content of the document at path, from the editor when
+// it is opened, else from the disk.
+func (s *Server) content(path string) ([]byte, error) {
+	if src, found := s.docs[path]; found {
+		return src, nil
+	}
+	return os.ReadFile(path)
+}
+
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	path := u.Path
+	if runtime.GOOS == "windows" {
+		// file:///C:/dir/file.pkr.hcl
+		path = strings.TrimPrefix(path, "/")
+	}
+	return filepath.FromSlash(path)
+}
+
+func pathToURI(path string) string {
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
@@ -0,0 +1,298 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/packer/packer"
+)
+
+const testConfig = `variable "region" {
+  type    = string
+  default = "eu-west-1"
+}
+
+locals {
+  name = upper(var.region)
+}
+
+source "test" "example" {
+  artifact_id = local.name
+}
+
+build {
+  sources = ["source.test.example"]
+}
+`
+
+// client talks to a Server in tests.
+type client struct {
+	t   *testing.T
+	w   io.Writer
+	r   *textproto.Reader
+	ids int
+}
+
+func (c *client) send(method string, id *int, params interface{}) {
+	c.t.Helper()
+	msg := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  method,
+		"params":  params,
+	}
+	if id != nil {
+		msg["id"] = *id
+	}
+	body, err := json.Marshal(msg)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	if _, err := fmt.Fprintf(c.w, "Content-Length: %d\r\n\r\n%s", len(body), body); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// receive returns the next message sent by the server.
+func (c *client) receive() map[string]json.RawMessage {
+	c.t.Helper()
+	header, err := c.r.ReadMIMEHeader()
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(c.r.R, body); err != nil {
+		c.t.Fatal(err)
+	}
+	msg := map[string]json.RawMessage{}
+	if err := json.Unmarshal(body, &msg); err != nil {
+		c.t.Fatal(err)
+	}
+	return msg
+}
+
+// call sends a request and decodes the result of its response into result.
+func (c *client) call(method string, params, result interface{}) {
+	c.t.Helper()
+	c.ids++
+	id := c.ids
+	c.send(method, &id, params)
+	for {
+		msg := c.receive()
+		if _, found := msg["method"]; found {
+			// Notification
+			continue
+		}
+		if errMsg, found := msg["error"]; found {
+			c.t.Fatalf("%s: %s", method, errMsg)
+		}
+		if err := json.Unmarshal(msg["result"], result); err != nil {
+			c.t.Fatal(err)
+		}
+		return
+	}
+}
+
+func startServer(t *testing.T) (*client, func()) {
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+
+	server := &Server{
+		PluginConfig:            packer.TestCoreConfig(t).Components.PluginConfig,
+		CorePackerVersion:       version.Must(version.NewSemver("1.0.0")),
+		CorePackerVersionString: "1.0.0",
+	}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.Serve(context.Background(), inR, outW)
+		outW.Close()
+	}()
+
+	c := &client{
+		t: t,
+		w: inW,
+		r: textproto.NewReader(bufio.NewReader(outR)),
+	}
+	stop := func() {
+		var res interface{}
+		c.call("shutdown", nil, &res)
+		c.send("exit", nil, nil)
+		if err := <-errs; err != nil {
+			t.Errorf("Serve: %s", err)
+		}
+	}
+	return c, stop
+}
+
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "build.pkr.hcl")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestServer_initialize(t *testing.T) {
+	c, stop := startServer(t)
+	defer stop()
+
+	var res InitializeResult
+	c.call("initialize", map[string]interface{}{}, &res)
+	if !res.Capabilities.DefinitionProvider || !res.Capabilities.HoverProvider || res.Capabilities.CompletionProvider == nil {
+		t.Errorf("unexpected capabilities: %#v", res.Capabilities)
+	}
+	if res.ServerInfo.Version != "1.0.0" {
+		t.Errorf("unexpected version %q", res.ServerInfo.Version)
+	}
+}
+
+func TestServer_diagnostics(t *testing.T) {
+	c, stop := startServer(t)
+	defer stop()
+
+	path := writeConfig(t, testConfig)
+	uri := pathToURI(path)
+	invalid := strings.Replace(testConfig, "local.name", "local.nope", 1)
+	c.send("textDocument/didOpen", nil, DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{URI: uri, LanguageID: "hcl", Text: invalid},
+	})
+
+	var params PublishDiagnosticsParams
+	msg := c.receive()
+	if err := json.Unmarshal(msg["params"], &params); err != nil {
+		t.Fatal(err)
+	}
+	if params.URI != uri {
+		t.Fatalf("unexpected uri %q", params.URI)
+	}
+	if len(params.Diagnostics) != 1 {
+		t.Fatalf("expected one diagnostic, got %#v", params.Diagnostics)
+	}
+	diag := params.Diagnostics[0]
+	if diag.Severity != SeverityError || !strings.Contains(diag.Message, "Unsupported attribute") {
+		t.Errorf("unexpected diagnostic %#v", diag)
+	}
+	if diag.Range.Start.Line != 10 {
+		t.Errorf("expected diagnostic on line 10, got %d", diag.Range.Start.Line)
+	}
+
+	c.send("textDocument/didChange", nil, DidChangeTextDocumentParams{
+		TextDocument:   TextDocumentIdentifier{URI: uri},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: testConfig}},
+	})
+	msg = c.receive()
+	if err := json.Unmarshal(msg["params"], &params); err != nil {
+		t.Fatal(err)
+	}
+	if len(params.Diagnostics) != 0 {
+		t.Errorf("expected diagnostics to be cleared, got %#v", params.Diagnostics)
+	}
+}
+
+func TestServer_definition(t *testing.T) {
+	c, stop := startServer(t)
+	defer stop()
+
+	path := writeConfig(t, testConfig)
+	uri := pathToURI(path)
+
+	tests := []struct {
+		name     string
+		position Position
+		want     []Location
+	}{
+		{"variable", Position{Line: 6, Character: 20}, []Location{{
+			URI:   uri,
+			Range: Range{Start: Position{Line: 0, Character: 0}, End: Position{Line: 0, Character: 17}},
+		}}},
+		{"local", Position{Line: 10, Character: 22}, []Location{{
+			URI:   uri,
+			Range: Range{Start: Position{Line: 6, Character: 2}, End: Position{Line: 6, Character: 6}},
+		}}},
+		{"source", Position{Line: 14, Character: 20}, []Location{{
+			URI:   uri,
+			Range: Range{Start: Position{Line: 9, Character: 0}, End: Position{Line: 9, Character: 23}},
+		}}},
+		{"nothing", Position{Line: 1, Character: 4}, []Location{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []Location
+			c.call("textDocument/definition", TextDocumentPositionParams{
+				TextDocument: TextDocumentIdentifier{URI: uri},
+				Position:     tt.position,
+			}, &got)
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServer_hover(t *testing.T) {
+	c, stop := startServer(t)
+	defer stop()
+
+	uri := pathToURI(writeConfig(t, testConfig))
+
+	var got Hover
+	c.call("textDocument/hover", TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+		Position:     Position{Line: 6, Character: 10},
+	}, &got)
+	if !strings.HasPrefix(got.Contents.Value, "```hcl\nupper(str string)\n```") {
+		t.Errorf("unexpected hover %q", got.Contents.Value)
+	}
+}
+
+func TestServer_completion(t *testing.T) {
+	c, stop := startServer(t)
+	defer stop()
+
+	uri := pathToURI(writeConfig(t, testConfig))
+
+	tests := []struct {
+		name     string
+		position Position
+		contains []string
+	}{
+		{"top level", Position{Line: 4, Character: 0}, []string{"build", "source", "variable", "locals"}},
+		{"builder", Position{Line: 10, Character: 2}, []string{"artifact_id", "run_err_result"}},
+		{"build", Position{Line: 14, Character: 2}, []string{"sources", "provisioner", "post-processor"}},
+		{"functions", Position{Line: 6, Character: 9}, []string{"upper", "timestamp"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got CompletionList
+			c.call("textDocument/completion", TextDocumentPositionParams{
+				TextDocument: TextDocumentIdentifier{URI: uri},
+				Position:     tt.position,
+			}, &got)
+			labels := map[string]bool{}
+			for _, item := range got.Items {
+				labels[item.Label] = true
+			}
+			for _, label := range tt.contains {
+				if !labels[label] {
+					t.Errorf("%q not found in %v", label, got.Items)
+				}
+			}
+		})
+	}
+}
@@ -46,7 +46,7 @@ func realMain() int {
 	wrapConfig.CookieKey = "PACKER_WRAP_COOKIE"
 	wrapConfig.CookieValue = "49C22B1A-3A93-4C98-97FA-E07D18C787B5"
 
-	if inPlugin() || panicwrap.Wrapped(&wrapConfig) {
+	if inPlugin() || inLanguageServer() || panicwrap.Wrapped(&wrapConfig) {
 		// Call the real main
 		return wrappedMain()
 	}
@@ -143,6 +143,14 @@ func wrappedMain() int {
 	}
 
 	packersdk.LogSecretFilter.SetOutput(os.Stderr)
+	if inLanguageServer() {
+		// Without panicwrap, logs are only written when requested.
+		logWriter, err := logOutput()
+		if err != nil || logWriter == nil {
+			logWriter = io.Discard
+		}
+		packersdk.LogSecretFilter.SetOutput(logWriter)
+	}
 	log.SetOutput(&packersdk.LogSecretFilter)
 
 	inPlugin := inPlugin()
@@ -218,7 +226,7 @@ func wrappedMain() int {
 			PB:          &packersdk.NoopProgressTracker{},
 		}
 		ui = basicUi
-		if !inPlugin {
+		if !inPlugin && !inLanguageServer() {
 			currentPID := os.Getpid()
 			backgrounded, err := checkProcess(currentPID)
 			if err != nil {
@@ -434,6 +442,13 @@ func inPlugin() bool {
 	return os.Getenv(pluginsdk.MagicCookieKey) == pluginsdk.MagicCookieValue
 }
 
+// inLanguageServer tells whether packer runs as a language server. Its stdout
+// carries the messages of the protocol, they must not go through the line
+// buffered output of panicwrap.
+func inLanguageServer() bool {
+	return len(os.Args) > 1 && os.Args[1] == "lsp"
+}
+
 func init() {
 	// Seed the random number generator
 	rand.Seed(time.Now().UTC().UnixNano())
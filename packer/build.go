@@ -5,14 +5,19 @@ package packer
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"regexp"
+	"strconv"
 	"sync"
+	"time"
 
 	hcpPackerModels "github.com/hashicorp/hcp-sdk-go/clients/cloud-packer-service/stable/2023-01-01/models"
 	"github.com/hashicorp/packer-plugin-sdk/common"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 	"github.com/hashicorp/packer-plugin-sdk/packerbuilderdata"
+	"github.com/hashicorp/packer-plugin-sdk/retry"
 	"github.com/hashicorp/packer/version"
 	"github.com/zclconf/go-cty/cty"
 )
@@ -44,6 +49,28 @@ type CoreBuild struct {
 	Variables          map[string]string
 	SensitiveVars      []string
 
+	// Retry tells if and how the build should be run again when it fails.
+	Retry BuildRetry
+
+	// Timeout bounds the time the whole build, retries included, can take.
+	// Zero means no timeout.
+	Timeout time.Duration
+
+	// PostProcessorsParallel runs the post-processor chains concurrently.
+	PostProcessorsParallel bool
+
+	// ConcurrencyGroup is the name used to limit the number of builds of the
+	// same group that run at the same time. The builder type is used when
+	// empty.
+	ConcurrencyGroup string
+
+	// Checks are run once the build completed successfully.
+	Checks []CoreBuildCheck
+
+	// DebugConsole, when set, is started at breakpoint provisioners and,
+	// with -on-error=ask, when a provisioner fails.
+	DebugConsole DebugConsole
+
 	// Indicates whether the build is already initialized before calling Prepare(..)
 	Prepared bool
 
@@ -52,10 +79,84 @@ type CoreBuild struct {
 	onError       string
 	l             sync.Mutex
 	prepareCalled bool
+	attempts      int
+	warnings      []error
 
 	SBOMs []SBOM
 }
 
+// BuildRetry configures how many times a CoreBuild is attempted before giving
+// up, and which errors are worth another attempt.
+type BuildRetry struct {
+	// Attempts is the total number of times the build can be run. Zero or one
+	// means the build is not retried.
+	Attempts int
+	// Backoff is the time to wait before the first retry. It is doubled after
+	// each subsequent failed attempt.
+	Backoff time.Duration
+	// RetryOn is a list of patterns matched against the error of a failed
+	// attempt. When empty, any error triggers a retry.
+	RetryOn []*regexp.Regexp
+}
+
+// TimeoutError is returned when a build does not complete in time. The build
+// was cancelled, so cleanup steps ran before it was returned.
+type TimeoutError struct {
+	Timeout time.Duration
+	// Err is the error the build returned after being cancelled.
+	Err error
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("timed out after %s", e.Timeout)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// shouldRetry tells whether err matches one of the RetryOn patterns.
+func (r BuildRetry) shouldRetry(err error) bool {
+	if len(r.RetryOn) == 0 {
+		return true
+	}
+	for _, re := range r.RetryOn {
+		if re.MatchString(err.Error()) {
+			return true
+		}
+	}
+	return false
+}
+
+// BuildCheck asserts facts about the artifacts of a completed build.
+type BuildCheck interface {
+	// Check returns an error describing the failed assertions, if any.
+	Check(artifacts []packersdk.Artifact) error
+}
+
+// CoreBuildCheck is a check run once a build completed.
+type CoreBuildCheck struct {
+	Name  string
+	Check BuildCheck
+	// OnFailure tells how a failed check is reported: "warn" reports it as a
+	// warning of the build, anything else fails the build.
+	OnFailure string
+}
+
+// CheckError is returned by a build when one of its checks failed.
+type CheckError struct {
+	Name string
+	Err  error
+}
+
+func (e *CheckError) Error() string {
+	return fmt.Sprintf("check %q failed: %s", e.Name, e.Err)
+}
+
+func (e *CheckError) Unwrap() error {
+	return e.Err
+}
+
 type SBOM struct {
 	Name           string
 	Format         hcpPackerModels.HashicorpCloudPacker20230101SbomFormat
@@ -119,6 +220,10 @@ type CoreBuildPostProcessor struct {
 	// deserialised directly from the JSON template
 	config            map[string]interface{}
 	KeepInputArtifact *bool
+	// OnError tells what to do when the post-processor fails: fail the
+	// build ("fail" or empty), or only stop its chain while reporting a
+	// warning ("warn") or not ("ignore").
+	OnError string
 }
 
 // CoreBuildProvisioner keeps track of the provisioner and the configuration of
@@ -233,12 +338,155 @@ func (b *CoreBuild) Prepare() (warn []string, err error) {
 	return
 }
 
+// Attempts returns the number of times the build was run during the last call
+// to Run.
+func (b *CoreBuild) Attempts() int {
+	return b.attempts
+}
+
+// Warnings returns the errors of the post-processors that were allowed to
+// fail with on_error = "warn" during the last attempt of Run, and the checks
+// that failed with on_failure = "warn".
+func (b *CoreBuild) Warnings() []error {
+	return b.warnings
+}
+
 // Runs the actual build. Prepare must be called prior to running this.
+//
+// When the build is configured to be retried, the artifacts of a failed
+// attempt are destroyed before the build is run again, until it succeeds or
+// the attempts are exhausted.
+//
+// When the build has a timeout, the context passed to the builder is cancelled
+// once it is reached and a *TimeoutError is returned.
+//
+// Once the build succeeded, its checks are run against its artifacts.
 func (b *CoreBuild) Run(ctx context.Context, originalUi packersdk.Ui) ([]packersdk.Artifact, error) {
 	if !b.prepareCalled {
 		panic("Prepare must be called first")
 	}
 
+	artifacts, err := b.runWithTimeout(ctx, originalUi)
+	if err != nil {
+		return artifacts, err
+	}
+	return artifacts, b.runChecks(originalUi, artifacts)
+}
+
+// runWithTimeout runs the build, cancelling it once its timeout is reached.
+func (b *CoreBuild) runWithTimeout(ctx context.Context, originalUi packersdk.Ui) ([]packersdk.Artifact, error) {
+	if b.Timeout <= 0 {
+		return b.runWithRetries(ctx, originalUi)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, b.Timeout)
+	defer cancel()
+
+	artifacts, err := b.runWithRetries(timeoutCtx, originalUi)
+	if err != nil && ctx.Err() == nil && errors.Is(timeoutCtx.Err(), context.DeadlineExceeded) {
+		log.Printf("Build '%s' timed out after %s", b.Name(), b.Timeout)
+		return artifacts, &TimeoutError{Timeout: b.Timeout, Err: err}
+	}
+	return artifacts, err
+}
+
+// runChecks runs the checks of the build against its artifacts. Checks
+// failing with on_failure = "warn" are recorded as warnings of the build.
+func (b *CoreBuild) runChecks(originalUi packersdk.Ui, artifacts []packersdk.Artifact) error {
+	if len(b.Checks) == 0 {
+		return nil
+	}
+	ui := &TargetedUI{
+		Target: b.Name(),
+		Ui:     originalUi,
+	}
+
+	var errs []error
+	for _, check := range b.Checks {
+		log.Printf("Running check %q of build '%s'", check.Name, b.Name())
+		err := check.Check.Check(artifacts)
+		if err == nil {
+			continue
+		}
+		err = &CheckError{Name: check.Name, Err: err}
+		if check.OnFailure == "warn" {
+			ui.Error(fmt.Sprintf("Warning: %s", err))
+			b.warnings = append(b.warnings, err)
+			continue
+		}
+		errs = append(errs, err)
+	}
+
+	if len(errs) > 0 {
+		return &packersdk.MultiError{Errors: errs}
+	}
+	return nil
+}
+
+// runWithRetries runs the build until it succeeds or its retry configuration
+// tells to stop.
+func (b *CoreBuild) runWithRetries(ctx context.Context, originalUi packersdk.Ui) ([]packersdk.Artifact, error) {
+	maxAttempts := b.Retry.Attempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	backoff := retry.Backoff{
+		InitialBackoff: b.Retry.Backoff,
+		Multiplier:     2,
+	}
+	ui := &TargetedUI{
+		Target: b.Name(),
+		Ui:     originalUi,
+	}
+
+	for b.attempts = 1; ; b.attempts++ {
+		artifacts, err := b.run(ctx, originalUi)
+		if err == nil || b.attempts >= maxAttempts {
+			if err != nil && maxAttempts > 1 {
+				ui.Say(fmt.Sprintf("Build failed after %d attempts.", b.attempts))
+			}
+			return artifacts, err
+		}
+		if ctx.Err() != nil {
+			return artifacts, err
+		}
+		if b.onError == "abort" {
+			log.Printf("Build '%s' failed with on-error=abort, not retrying.", b.Name())
+			return artifacts, err
+		}
+		if !b.Retry.shouldRetry(err) {
+			log.Printf("Build '%s' failed with an error matching no retry_on pattern, not retrying.", b.Name())
+			return artifacts, err
+		}
+
+		for _, artifact := range artifacts {
+			if artifact == nil {
+				continue
+			}
+			log.Printf("Deleting artifact %q of failed attempt %d", artifact.Id(), b.attempts)
+			if err := artifact.Destroy(); err != nil {
+				log.Printf("Failed cleaning up artifact of failed attempt: %s", err)
+			}
+		}
+		b.SBOMs = nil
+
+		wait := backoff.Linear()
+		ui.Say(fmt.Sprintf("Attempt %d/%d failed with %q, retrying in %s...",
+			b.attempts, maxAttempts, err, wait))
+		ui.Machine("retry", strconv.Itoa(b.attempts), err.Error())
+
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(wait):
+		}
+	}
+}
+
+// run runs a single attempt of the build.
+func (b *CoreBuild) run(ctx context.Context, originalUi packersdk.Ui) ([]packersdk.Artifact, error) {
+	b.warnings = nil
+
 	// Copy the hooks
 	hooks := make(map[string][]packersdk.Hook)
 	for hookName, hookList := range b.hooks {
@@ -277,6 +525,9 @@ func (b *CoreBuild) Run(ctx context.Context, originalUi packersdk.Ui) ([]packers
 
 		hooks[packersdk.HookProvision] = append(hooks[packersdk.HookProvision], &ProvisionHook{
 			Provisioners: hookedProvisioners,
+			BuildName:    b.Name(),
+			DebugConsole: b.DebugConsole,
+			DebugOnError: b.onError == "ask",
 		})
 	}
 
@@ -341,88 +592,33 @@ func (b *CoreBuild) Run(ctx context.Context, originalUi packersdk.Ui) ([]packers
 	default:
 	}
 
-	// Run the post-processors
-PostProcessorRunSeqLoop:
-	for _, ppSeq := range b.PostProcessors {
-		priorArtifact := builderArtifact
-		for i, corePP := range ppSeq {
-			ppUi := &TargetedUI{
-				Target: fmt.Sprintf("%s (%s)", b.Name(), corePP.PType),
-				Ui:     originalUi,
-			}
-
-			if corePP.PName == corePP.PType {
-				builderUi.Say(fmt.Sprintf("Running post-processor: %s", corePP.PType))
-			} else {
-				builderUi.Say(fmt.Sprintf("Running post-processor: %s (type %s)", corePP.PName, corePP.PType))
-			}
-			var ts *TelemetrySpan
-			if corePP.config != nil {
-				ts = CheckpointReporter.AddSpan(corePP.PType, "post-processor", corePP.config)
-			} else {
-				ts = CheckpointReporter.AddSpan(corePP.PType, "post-processor", corePP.HCLConfig)
-			}
-			artifact, defaultKeep, forceOverride, err := corePP.PostProcessor.PostProcess(ctx, ppUi, priorArtifact)
-			ts.End(err)
-			if err != nil {
-				errors = append(errors, fmt.Errorf("Post-processor failed: %s", err))
-				continue PostProcessorRunSeqLoop
-			}
-
-			if artifact == nil {
-				log.Println("Nil artifact, halting post-processor chain.")
-				continue PostProcessorRunSeqLoop
-			}
-
-			keep := defaultKeep
-			// When user has not set keep_input_artifact
-			// corePP.keepInputArtifact is nil.
-			// In this case, use the keepDefault provided by the postprocessor.
-			// When user _has_ set keep_input_artifact, go with that instead.
-			// Exception: for postprocessors that will fail/become
-			// useless if keep isn't true, heed forceOverride and keep the
-			// input artifact regardless of user preference.
-			if corePP.KeepInputArtifact != nil {
-				if defaultKeep && *corePP.KeepInputArtifact == false && forceOverride {
-					log.Printf("The %s post-processor forces "+
-						"keep_input_artifact=true to preserve integrity of the"+
-						"build chain. User-set keep_input_artifact=false will be"+
-						"ignored.", corePP.PType)
-				} else {
-					// User overrides default.
-					keep = *corePP.KeepInputArtifact
-				}
-			}
-			if i == 0 {
-				// This is the first post-processor. We handle deleting
-				// previous artifacts a bit different because multiple
-				// post-processors may be using the original and need it.
-				if !keepOriginalArtifact && keep {
-					log.Printf(
-						"Flagging to keep original artifact from post-processor '%s'",
-						corePP.PType)
-					keepOriginalArtifact = true
-				}
-			} else {
-				// We have a prior artifact. If we want to keep it, we append
-				// it to the results list. Otherwise, we destroy it.
-				if keep {
-					artifacts = append(artifacts, priorArtifact)
-				} else {
-					log.Printf("Deleting prior artifact from post-processor '%s'", corePP.PType)
-					if err := priorArtifact.Destroy(); err != nil {
-						log.Printf("Error is %#v", err)
-						errors = append(errors, fmt.Errorf("Failed cleaning up prior artifact: %s; pp is %s", err, corePP.PType))
-					}
-				}
-			}
-
-			priorArtifact = artifact
+	// Run the post-processors. Each chain starts from the builder artifact,
+	// so chains can run concurrently when requested. Results are gathered in
+	// the order of the chains either way.
+	results := make([]postProcessorChainResult, len(b.PostProcessors))
+	if b.PostProcessorsParallel {
+		var wg sync.WaitGroup
+		for i, ppSeq := range b.PostProcessors {
+			wg.Add(1)
+			go func(i int, ppSeq []CoreBuildPostProcessor) {
+				defer wg.Done()
+				results[i] = b.runPostProcessorChain(ctx, originalUi, builderUi, ppSeq, builderArtifact)
+			}(i, ppSeq)
+		}
+		wg.Wait()
+	} else {
+		for i, ppSeq := range b.PostProcessors {
+			results[i] = b.runPostProcessorChain(ctx, originalUi, builderUi, ppSeq, builderArtifact)
 		}
+	}
 
-		// Add on the last artifact to the results
-		if priorArtifact != nil {
-			artifacts = append(artifacts, priorArtifact)
+	// The original artifact is only deleted once all chains are done with it.
+	for _, res := range results {
+		artifacts = append(artifacts, res.artifacts...)
+		errors = append(errors, res.errors...)
+		b.warnings = append(b.warnings, res.warnings...)
+		if res.keepOriginalArtifact {
+			keepOriginalArtifact = true
 		}
 	}
 
@@ -445,6 +641,117 @@ PostProcessorRunSeqLoop:
 	return artifacts, nil
 }
 
+// postProcessorChainResult is the outcome of running a chain of
+// post-processors.
+type postProcessorChainResult struct {
+	// artifacts that should be returned by the build.
+	artifacts []packersdk.Artifact
+	// keepOriginalArtifact is set when the first post-processor of the
+	// chain asks for the builder artifact to be kept.
+	keepOriginalArtifact bool
+	errors               []error
+	// warnings are the errors of post-processors allowed to fail.
+	warnings []error
+}
+
+// runPostProcessorChain runs a chain of post-processors, each post-processor
+// being given the artifact of the previous one, starting from the builder
+// artifact.
+func (b *CoreBuild) runPostProcessorChain(ctx context.Context, originalUi packersdk.Ui, builderUi packersdk.Ui, ppSeq []CoreBuildPostProcessor, builderArtifact packersdk.Artifact) postProcessorChainResult {
+	var res postProcessorChainResult
+
+	priorArtifact := builderArtifact
+	for i, corePP := range ppSeq {
+		ppUi := &TargetedUI{
+			Target: fmt.Sprintf("%s (%s)", b.Name(), corePP.PType),
+			Ui:     originalUi,
+		}
+
+		if corePP.PName == corePP.PType {
+			builderUi.Say(fmt.Sprintf("Running post-processor: %s", corePP.PType))
+		} else {
+			builderUi.Say(fmt.Sprintf("Running post-processor: %s (type %s)", corePP.PName, corePP.PType))
+		}
+		var ts *TelemetrySpan
+		if corePP.config != nil {
+			ts = CheckpointReporter.AddSpan(corePP.PType, "post-processor", corePP.config)
+		} else {
+			ts = CheckpointReporter.AddSpan(corePP.PType, "post-processor", corePP.HCLConfig)
+		}
+		artifact, defaultKeep, forceOverride, err := corePP.PostProcessor.PostProcess(ctx, ppUi, priorArtifact)
+		ts.End(err)
+		if err != nil {
+			err = fmt.Errorf("Post-processor failed: %s", err)
+			switch corePP.OnError {
+			case "warn":
+				ppUi.Error(fmt.Sprintf("Warning: %s; on_error is set to warn, skipping the rest of the chain.", err))
+				res.warnings = append(res.warnings, err)
+			case "ignore":
+				log.Printf("%s: %s; on_error is set to ignore, skipping the rest of the chain.", ppUi.Target, err)
+			default:
+				res.errors = append(res.errors, err)
+			}
+			return res
+		}
+
+		if artifact == nil {
+			log.Println("Nil artifact, halting post-processor chain.")
+			return res
+		}
+
+		keep := defaultKeep
+		// When user has not set keep_input_artifact
+		// corePP.keepInputArtifact is nil.
+		// In this case, use the keepDefault provided by the postprocessor.
+		// When user _has_ set keep_input_artifact, go with that instead.
+		// Exception: for postprocessors that will fail/become
+		// useless if keep isn't true, heed forceOverride and keep the
+		// input artifact regardless of user preference.
+		if corePP.KeepInputArtifact != nil {
+			if defaultKeep && *corePP.KeepInputArtifact == false && forceOverride {
+				log.Printf("The %s post-processor forces "+
+					"keep_input_artifact=true to preserve integrity of the"+
+					"build chain. User-set keep_input_artifact=false will be"+
+					"ignored.", corePP.PType)
+			} else {
+				// User overrides default.
+				keep = *corePP.KeepInputArtifact
+			}
+		}
+		if i == 0 {
+			// This is the first post-processor. We handle deleting
+			// previous artifacts a bit different because multiple
+			// post-processors may be using the original and need it.
+			if keep {
+				log.Printf(
+					"Flagging to keep original artifact from post-processor '%s'",
+					corePP.PType)
+				res.keepOriginalArtifact = true
+			}
+		} else {
+			// We have a prior artifact. If we want to keep it, we append
+			// it to the results list. Otherwise, we destroy it.
+			if keep {
+				res.artifacts = append(res.artifacts, priorArtifact)
+			} else {
+				log.Printf("Deleting prior artifact from post-processor '%s'", corePP.PType)
+				if err := priorArtifact.Destroy(); err != nil {
+					log.Printf("Error is %#v", err)
+					res.errors = append(res.errors, fmt.Errorf("Failed cleaning up prior artifact: %s; pp is %s", err, corePP.PType))
+				}
+			}
+		}
+
+		priorArtifact = artifact
+	}
+
+	// Add on the last artifact to the results
+	if priorArtifact != nil {
+		res.artifacts = append(res.artifacts, priorArtifact)
+	}
+	return res
+}
+
 func (b *CoreBuild) SetDebug(val bool) {
 	if b.prepareCalled {
 		panic("prepare has already been called")
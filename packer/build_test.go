@@ -5,8 +5,13 @@ package packer
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"reflect"
+	"regexp"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/packer-plugin-sdk/common"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
@@ -36,7 +41,7 @@ func testBuild() *CoreBuild {
 		},
 		PostProcessors: [][]CoreBuildPostProcessor{
 			{
-				{&MockPostProcessor{ArtifactId: "pp"}, "testPP", "testPPName", cty.Value{}, make(map[string]interface{}), boolPointer(true)},
+				{PostProcessor: &MockPostProcessor{ArtifactId: "pp"}, PType: "testPP", PName: "testPPName", HCLConfig: cty.Value{}, config: make(map[string]interface{}), KeepInputArtifact: boolPointer(true)},
 			},
 		},
 		Variables:     make(map[string]string),
@@ -308,7 +313,7 @@ func TestBuild_Run_Artifacts(t *testing.T) {
 	build = testBuild()
 	build.PostProcessors = [][]CoreBuildPostProcessor{
 		{
-			{&MockPostProcessor{ArtifactId: "pp"}, "pp", "testPPName", cty.Value{}, make(map[string]interface{}), boolPointer(false)},
+			{PostProcessor: &MockPostProcessor{ArtifactId: "pp"}, PType: "pp", PName: "testPPName", HCLConfig: cty.Value{}, config: make(map[string]interface{}), KeepInputArtifact: boolPointer(false)},
 		},
 	}
 
@@ -333,10 +338,10 @@ func TestBuild_Run_Artifacts(t *testing.T) {
 	build = testBuild()
 	build.PostProcessors = [][]CoreBuildPostProcessor{
 		{
-			{&MockPostProcessor{ArtifactId: "pp1"}, "pp", "testPPName", cty.Value{}, make(map[string]interface{}), boolPointer(false)},
+			{PostProcessor: &MockPostProcessor{ArtifactId: "pp1"}, PType: "pp", PName: "testPPName", HCLConfig: cty.Value{}, config: make(map[string]interface{}), KeepInputArtifact: boolPointer(false)},
 		},
 		{
-			{&MockPostProcessor{ArtifactId: "pp2"}, "pp", "testPPName", cty.Value{}, make(map[string]interface{}), boolPointer(true)},
+			{PostProcessor: &MockPostProcessor{ArtifactId: "pp2"}, PType: "pp", PName: "testPPName", HCLConfig: cty.Value{}, config: make(map[string]interface{}), KeepInputArtifact: boolPointer(true)},
 		},
 	}
 
@@ -361,12 +366,12 @@ func TestBuild_Run_Artifacts(t *testing.T) {
 	build = testBuild()
 	build.PostProcessors = [][]CoreBuildPostProcessor{
 		{
-			{&MockPostProcessor{ArtifactId: "pp1a"}, "pp", "testPPName", cty.Value{}, make(map[string]interface{}), boolPointer(false)},
-			{&MockPostProcessor{ArtifactId: "pp1b"}, "pp", "testPPName", cty.Value{}, make(map[string]interface{}), boolPointer(true)},
+			{PostProcessor: &MockPostProcessor{ArtifactId: "pp1a"}, PType: "pp", PName: "testPPName", HCLConfig: cty.Value{}, config: make(map[string]interface{}), KeepInputArtifact: boolPointer(false)},
+			{PostProcessor: &MockPostProcessor{ArtifactId: "pp1b"}, PType: "pp", PName: "testPPName", HCLConfig: cty.Value{}, config: make(map[string]interface{}), KeepInputArtifact: boolPointer(true)},
 		},
 		{
-			{&MockPostProcessor{ArtifactId: "pp2a"}, "pp", "testPPName", cty.Value{}, make(map[string]interface{}), boolPointer(false)},
-			{&MockPostProcessor{ArtifactId: "pp2b"}, "pp", "testPPName", cty.Value{}, make(map[string]interface{}), boolPointer(false)},
+			{PostProcessor: &MockPostProcessor{ArtifactId: "pp2a"}, PType: "pp", PName: "testPPName", HCLConfig: cty.Value{}, config: make(map[string]interface{}), KeepInputArtifact: boolPointer(false)},
+			{PostProcessor: &MockPostProcessor{ArtifactId: "pp2b"}, PType: "pp", PName: "testPPName", HCLConfig: cty.Value{}, config: make(map[string]interface{}), KeepInputArtifact: boolPointer(false)},
 		},
 	}
 
@@ -392,7 +397,7 @@ func TestBuild_Run_Artifacts(t *testing.T) {
 	build.PostProcessors = [][]CoreBuildPostProcessor{
 		{
 			{
-				&MockPostProcessor{ArtifactId: "pp", Keep: true, ForceOverride: true}, "pp", "testPPName", cty.Value{}, make(map[string]interface{}), boolPointer(false),
+				PostProcessor: &MockPostProcessor{ArtifactId: "pp", Keep: true, ForceOverride: true}, PType: "pp", PName: "testPPName", HCLConfig: cty.Value{}, config: make(map[string]interface{}), KeepInputArtifact: boolPointer(false),
 			},
 		},
 	}
@@ -420,7 +425,7 @@ func TestBuild_Run_Artifacts(t *testing.T) {
 	build.PostProcessors = [][]CoreBuildPostProcessor{
 		{
 			{
-				&MockPostProcessor{ArtifactId: "pp", Keep: true, ForceOverride: false}, "pp", "testPPName", cty.Value{}, make(map[string]interface{}), boolPointer(false),
+				PostProcessor: &MockPostProcessor{ArtifactId: "pp", Keep: true, ForceOverride: false}, PType: "pp", PName: "testPPName", HCLConfig: cty.Value{}, config: make(map[string]interface{}), KeepInputArtifact: boolPointer(false),
 			},
 		},
 	}
@@ -447,7 +452,7 @@ func TestBuild_Run_Artifacts(t *testing.T) {
 	build.PostProcessors = [][]CoreBuildPostProcessor{
 		{
 			{
-				&MockPostProcessor{ArtifactId: "pp", Keep: true, ForceOverride: false}, "pp", "testPPName", cty.Value{}, make(map[string]interface{}), nil,
+				PostProcessor: &MockPostProcessor{ArtifactId: "pp", Keep: true, ForceOverride: false}, PType: "pp", PName: "testPPName", HCLConfig: cty.Value{}, config: make(map[string]interface{}), KeepInputArtifact: nil,
 			},
 		},
 	}
@@ -502,3 +507,278 @@ func TestBuild_Cancel(t *testing.T) {
 		t.Fatal("build should err")
 	}
 }
+
+// flakyBuilder is a MockBuilder that fails the first Failures runs.
+type flakyBuilder struct {
+	packersdk.MockBuilder
+	Failures int
+	runs     int
+}
+
+func (b *flakyBuilder) Run(ctx context.Context, ui packersdk.Ui, h packersdk.Hook) (packersdk.Artifact, error) {
+	b.runs++
+	if b.runs <= b.Failures {
+		return nil, fmt.Errorf("transient error %d", b.runs)
+	}
+	return b.MockBuilder.Run(ctx, ui, h)
+}
+
+func TestBuild_Run_Retry(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     int
+		retry        BuildRetry
+		wantErr      bool
+		wantAttempts int
+	}{
+		{"no retry", 1, BuildRetry{}, true, 1},
+		{"succeeds on second attempt", 1, BuildRetry{Attempts: 3}, false, 2},
+		{"attempts exhausted", 3, BuildRetry{Attempts: 3}, true, 3},
+		{"matching retry_on", 1, BuildRetry{Attempts: 2, RetryOn: []*regexp.Regexp{regexp.MustCompile("transient")}}, false, 2},
+		{"non matching retry_on", 1, BuildRetry{Attempts: 2, RetryOn: []*regexp.Regexp{regexp.MustCompile("timeout")}}, true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			build := testBuild()
+			build.Builder = &flakyBuilder{
+				MockBuilder: packersdk.MockBuilder{ArtifactId: "b"},
+				Failures:    tt.failures,
+			}
+			build.Retry = tt.retry
+			build.Prepare()
+
+			_, err := build.Run(context.Background(), testUi())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Run() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if build.Attempts() != tt.wantAttempts {
+				t.Fatalf("Attempts() = %d, want %d", build.Attempts(), tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestBuild_Run_Timeout(t *testing.T) {
+	build := testBuild()
+	build.Timeout = 10 * time.Millisecond
+	build.Prepare()
+
+	builder := build.Builder.(*packersdk.MockBuilder)
+	builder.RunFn = func(ctx context.Context) {
+		<-ctx.Done()
+	}
+
+	_, err := build.Run(context.Background(), testUi())
+	var timeoutErr *TimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("expected a *TimeoutError, got %#v", err)
+	}
+	if err.Error() != "timed out after 10ms" {
+		t.Fatalf("bad error message: %s", err)
+	}
+}
+
+// lateBuilder is a MockBuilder that succeeds, without artifact, once its
+// context is done.
+type lateBuilder struct {
+	packersdk.MockBuilder
+}
+
+func (b *lateBuilder) Run(ctx context.Context, ui packersdk.Ui, h packersdk.Hook) (packersdk.Artifact, error) {
+	<-ctx.Done()
+	return nil, nil
+}
+
+func TestBuild_Run_TimeoutAfterSuccess(t *testing.T) {
+	build := testBuild()
+	build.Builder = new(lateBuilder)
+	build.Timeout = 10 * time.Millisecond
+	build.Prepare()
+
+	if _, err := build.Run(context.Background(), testUi()); err != nil {
+		t.Fatalf("a build that succeeded should not time out, got %#v", err)
+	}
+}
+
+// barrierPostProcessor is a MockPostProcessor that waits for all the
+// barrierPostProcessors sharing its WaitGroup to be running.
+type barrierPostProcessor struct {
+	MockPostProcessor
+	barrier *sync.WaitGroup
+}
+
+func (p *barrierPostProcessor) PostProcess(ctx context.Context, ui packersdk.Ui, a packersdk.Artifact) (packersdk.Artifact, bool, bool, error) {
+	p.barrier.Done()
+	done := make(chan struct{})
+	go func() {
+		p.barrier.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		return nil, false, false, fmt.Errorf("post-processors did not run concurrently")
+	}
+	return p.MockPostProcessor.PostProcess(ctx, ui, a)
+}
+
+func TestBuild_Run_PostProcessorsParallel(t *testing.T) {
+	barrier := &sync.WaitGroup{}
+	barrier.Add(3)
+
+	build := testBuild()
+	build.PostProcessorsParallel = true
+	build.PostProcessors = [][]CoreBuildPostProcessor{
+		{
+			{PostProcessor: &barrierPostProcessor{MockPostProcessor{ArtifactId: "pp1"}, barrier}, PType: "pp"},
+		},
+		{
+			{PostProcessor: &barrierPostProcessor{MockPostProcessor{ArtifactId: "pp2", Keep: true}, barrier}, PType: "pp"},
+		},
+		{
+			{PostProcessor: &barrierPostProcessor{MockPostProcessor{ArtifactId: "pp3"}, barrier}, PType: "pp"},
+		},
+	}
+
+	build.Prepare()
+	artifacts, err := build.Run(context.Background(), testUi())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// The builder artifact is kept as the second chain asked for it, and
+	// the artifacts are returned in the order of the chains.
+	expectedIds := []string{"b", "pp1", "pp2", "pp3"}
+	artifactIds := make([]string, len(artifacts))
+	for i, artifact := range artifacts {
+		artifactIds[i] = artifact.Id()
+	}
+
+	if !reflect.DeepEqual(artifactIds, expectedIds) {
+		t.Fatalf("unexpected ids: %#v", artifactIds)
+	}
+}
+
+func TestBuild_Run_PostProcessorOnError(t *testing.T) {
+	tests := []struct {
+		onError      string
+		wantErr      bool
+		wantWarnings int
+	}{
+		{"", true, 0},
+		{"fail", true, 0},
+		{"warn", false, 1},
+		{"ignore", false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.onError, func(t *testing.T) {
+			build := testBuild()
+			build.PostProcessors = [][]CoreBuildPostProcessor{
+				{
+					{PostProcessor: &MockPostProcessor{Error: errors.New("notification failed")}, PType: "failing", OnError: tt.onError},
+					{PostProcessor: &MockPostProcessor{ArtifactId: "skipped"}, PType: "pp"},
+				},
+				{
+					{PostProcessor: &MockPostProcessor{ArtifactId: "pp"}, PType: "pp"},
+				},
+			}
+
+			build.Prepare()
+			artifacts, err := build.Run(context.Background(), testUi())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Run() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if got := len(build.Warnings()); got != tt.wantWarnings {
+				t.Fatalf("got %d warnings, want %d: %v", got, tt.wantWarnings, build.Warnings())
+			}
+
+			// The rest of the failing chain is skipped, other chains run.
+			artifactIds := []string{}
+			for _, artifact := range artifacts {
+				artifactIds = append(artifactIds, artifact.Id())
+			}
+			if !reflect.DeepEqual(artifactIds, []string{"pp"}) {
+				t.Fatalf("unexpected ids: %#v", artifactIds)
+			}
+		})
+	}
+}
+
+// checkFunc is a BuildCheck running a function.
+type checkFunc func(artifacts []packersdk.Artifact) error
+
+func (f checkFunc) Check(artifacts []packersdk.Artifact) error {
+	return f(artifacts)
+}
+
+func TestBuild_Run_Checks(t *testing.T) {
+	failing := checkFunc(func([]packersdk.Artifact) error {
+		return errors.New("no artifact")
+	})
+
+	tests := []struct {
+		onFailure    string
+		wantErr      bool
+		wantWarnings int
+	}{
+		{"", true, 0},
+		{"error", true, 0},
+		{"warn", false, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.onFailure, func(t *testing.T) {
+			var checked []string
+			build := testBuild()
+			build.Checks = []CoreBuildCheck{
+				{Name: "ids", Check: checkFunc(func(artifacts []packersdk.Artifact) error {
+					for _, artifact := range artifacts {
+						checked = append(checked, artifact.Id())
+					}
+					return nil
+				})},
+				{Name: "size", Check: failing, OnFailure: tt.onFailure},
+			}
+
+			build.Prepare()
+			artifacts, err := build.Run(context.Background(), testUi())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Run() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				errs := err.(*packersdk.MultiError).Errors
+				if checkErr, ok := errs[0].(*CheckError); len(errs) != 1 || !ok || checkErr.Name != "size" {
+					t.Fatalf("expected the size check to fail, got %v", err)
+				}
+			}
+			if got := len(build.Warnings()); got != tt.wantWarnings {
+				t.Fatalf("got %d warnings, want %d: %v", got, tt.wantWarnings, build.Warnings())
+			}
+
+			// Artifacts are returned even when a check failed.
+			if len(artifacts) != 2 {
+				t.Fatalf("unexpected artifacts: %v", artifacts)
+			}
+			if !reflect.DeepEqual(checked, []string{"b", "pp"}) {
+				t.Fatalf("unexpected checked artifacts: %#v", checked)
+			}
+		})
+	}
+}
+
+func TestBuild_Run_ChecksNotRunOnFailure(t *testing.T) {
+	build := testBuild()
+	build.Builder = &packersdk.MockBuilder{RunErrResult: true}
+	build.Checks = []CoreBuildCheck{
+		{Name: "never", Check: checkFunc(func([]packersdk.Artifact) error {
+			t.Fatal("checks should not run when the build failed")
+			return nil
+		})},
+	}
+
+	build.Prepare()
+	if _, err := build.Run(context.Background(), testUi()); err == nil {
+		t.Fatal("should error")
+	}
+}
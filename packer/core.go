@@ -585,6 +585,11 @@ func (c *Core) InspectConfig(opts InspectConfigOptions) int {
 	// Convenience...
 	ui := opts.Ui
 	tpl := c.Template
+	if opts.VarSources || opts.JSON {
+		ui.Error("The -var-sources and -json options are only supported for HCL2 templates.")
+		return 1
+	}
+
 	ui.Say("Packer Inspect: JSON mode")
 
 	// Description
@@ -703,6 +708,11 @@ func (c *Core) InspectConfig(opts InspectConfigOptions) int {
 	return 0
 }
 
+// EvaluateOutputs returns no outputs, as JSON templates cannot declare any.
+func (c *Core) EvaluateOutputs(map[string][]packersdk.Artifact) ([]Output, hcl.Diagnostics) {
+	return nil, nil
+}
+
 func (c *Core) FixConfig(opts FixConfigOptions) hcl.Diagnostics {
 	var diags hcl.Diagnostics
 
@@ -0,0 +1,109 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package packer
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// A DebugAction tells how a build resumes once its debug console is closed.
+type DebugAction int
+
+const (
+	// DebugContinue resumes the build. After a failure, the build fails as
+	// it would have without the debug console.
+	DebugContinue DebugAction = iota
+	// DebugRetry runs the failed provisioner again.
+	DebugRetry
+	// DebugSkip ignores the failure of the provisioner and runs the next
+	// one.
+	DebugSkip
+)
+
+// A DebugConsole is started by a build running with -debug-console when it
+// reaches a breakpoint provisioner, or when a provisioner fails and the build
+// runs with -on-error=ask.
+type DebugConsole interface {
+	// Run runs the console until the user tells how to resume the build.
+	Run(ctx context.Context, session *DebugSession) DebugAction
+}
+
+// A DebugEvaluator evaluates the expressions of the debug console, in which the
+// `build` variable describes the paused build.
+type DebugEvaluator interface {
+	EvaluateDebugExpression(expr string, session *DebugSession) (output string, exit bool, diags hcl.Diagnostics)
+}
+
+// A DebugSession describes a build paused in the debug console.
+type DebugSession struct {
+	// BuildName is the name of the paused build.
+	BuildName string
+	// ProvisionerIndex is the position of the breakpoint or of the failed
+	// provisioner in the provisioners of the build, starting at zero.
+	ProvisionerIndex int
+	// ProvisionerType is the type of the breakpoint or of the failed
+	// provisioner.
+	ProvisionerType string
+	// GeneratedData is the data generated by the builder.
+	GeneratedData map[string]interface{}
+	// Err is the error of the failed provisioner, nil at breakpoints.
+	Err error
+
+	Ui   packersdk.Ui
+	Comm packersdk.Communicator
+}
+
+// BuildValue returns the `build` variable of the debug console: an object
+// with the name of the build, its generated data, the index and type of the
+// current provisioner and the error it failed with, if any.
+func (s *DebugSession) BuildValue(generatedData map[string]cty.Value) cty.Value {
+	errVal := cty.NullVal(cty.String)
+	if s.Err != nil {
+		errVal = cty.StringVal(s.Err.Error())
+	}
+	return cty.ObjectVal(map[string]cty.Value{
+		"name":              cty.StringVal(s.BuildName),
+		"generated_data":    cty.ObjectVal(generatedData),
+		"provisioner_index": cty.NumberIntVal(int64(s.ProvisionerIndex)),
+		"provisioner_type":  cty.StringVal(s.ProvisionerType),
+		"error":             errVal,
+	})
+}
+
+// Exec runs command on the machine being built, streaming its output to the
+// Ui, and returns its exit status.
+func (s *DebugSession) Exec(ctx context.Context, command string) (int, error) {
+	cmd := &packersdk.RemoteCmd{Command: command}
+	if err := cmd.RunWithUi(ctx, s.Comm, s.Ui); err != nil {
+		return 0, err
+	}
+	return cmd.ExitStatus(), nil
+}
+
+// Upload uploads the local file or directory src to dst on the machine being
+// built.
+func (s *DebugSession) Upload(src, dst string) error {
+	fi, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if fi.IsDir() {
+		return s.Comm.UploadDir(dst, src, nil)
+	}
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := s.Comm.Upload(dst, f, &fi); err != nil {
+		return fmt.Errorf("failed to upload %s: %s", src, err)
+	}
+	return nil
+}
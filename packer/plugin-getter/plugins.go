@@ -963,13 +963,41 @@ func (pr *Requirement) InstallLatest(opts InstallOptions) (*Installation, error)
 	return nil, errs
 }
 
-func GetPluginDescription(pluginPath string) (pluginsdk.SetDescription, error) {
+// PluginDescription is the output of the `describe` command of a plugin.
+type PluginDescription struct {
+	pluginsdk.SetDescription
+
+	// Functions are the HCL functions provided by the plugin, callable as
+	// `provider::<plugin>::<name>(...)` in templates.
+	Functions []FunctionDescription `json:"functions,omitempty"`
+}
+
+// FunctionDescription declares a function provided by a plugin. Types are
+// cty types in their JSON form, like "string" or ["list", "number"].
+type FunctionDescription struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+
+	Params        []FunctionParameter `json:"params,omitempty"`
+	VariadicParam *FunctionParameter  `json:"variadic_param,omitempty"`
+	ReturnType    json.RawMessage     `json:"return_type"`
+}
+
+// FunctionParameter declares a parameter of a function provided by a plugin.
+type FunctionParameter struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Type        json.RawMessage `json:"type"`
+	AllowNull   bool            `json:"allow_null,omitempty"`
+}
+
+func GetPluginDescription(pluginPath string) (PluginDescription, error) {
 	out, err := exec.Command(pluginPath, "describe").Output()
 	if err != nil {
-		return pluginsdk.SetDescription{}, err
+		return PluginDescription{}, err
 	}
 
-	desc := pluginsdk.SetDescription{}
+	desc := PluginDescription{}
 	err = json.Unmarshal(out, &desc)
 
 	return desc, err
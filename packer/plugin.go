@@ -18,6 +18,7 @@ import (
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 	pluginsdk "github.com/hashicorp/packer-plugin-sdk/plugin"
 	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
+	"github.com/zclconf/go-cty/cty/function"
 )
 
 // PluginConfig helps load and use packer plugins
@@ -29,7 +30,10 @@ type PluginConfig struct {
 	Provisioners    ProvisionerSet
 	PostProcessors  PostProcessorSet
 	DataSources     DatasourceSet
-	ReleasesOnly    bool
+	// Functions are the HCL functions provided by plugins, by their name in
+	// templates: `provider::<plugin>::<name>`.
+	Functions    map[string]function.Function
+	ReleasesOnly bool
 	// UseProtobuf is set if all the plugin candidates support protobuf, and
 	// the user has not forced usage of gob for serialisation.
 	UseProtobuf bool
@@ -61,6 +65,9 @@ func (c *PluginConfig) Discover() error {
 	if c.DataSources == nil {
 		c.DataSources = MapOfDatasource{}
 	}
+	if c.Functions == nil {
+		c.Functions = map[string]function.Function{}
+	}
 
 	// If we are already inside a plugin process we should not need to
 	// discover anything.
@@ -153,7 +160,7 @@ func (c *PluginConfig) DiscoverMultiPlugin(pluginName, pluginPath string) error
 	pluginPrefix := pluginName + "-"
 	pluginDetails := PluginDetails{
 		Name:        pluginName,
-		Description: desc,
+		Description: desc.SetDescription,
 		PluginPath:  pluginPath,
 	}
 
@@ -268,6 +275,26 @@ func (c *PluginConfig) DiscoverMultiPlugin(pluginName, pluginPath string) error
 		log.Printf("found external %v datasource from %s plugin", desc.Datasources, pluginName)
 	}
 
+	if c.Functions == nil {
+		c.Functions = map[string]function.Function{}
+	}
+	for _, fnDesc := range desc.Functions {
+		key := PluginFunctionName(pluginName, fnDesc.Name)
+		if _, found := c.Functions[key]; found {
+			continue
+		}
+
+		fn, err := c.pluginFunction(pluginPath, fnDesc)
+		if err != nil {
+			log.Printf("[WARN] ignoring function %q of %s plugin: %s", fnDesc.Name, pluginName, err)
+			continue
+		}
+		c.Functions[key] = fn
+	}
+	if len(desc.Functions) > 0 {
+		log.Printf("found external %d functions from %s plugin", len(desc.Functions), pluginName)
+	}
+
 	// Only print the log once, for the plugin that triggers that
 	// limitation in functionality. Otherwise this could be a bit
 	// verbose to print it for each non-compatible plugin.
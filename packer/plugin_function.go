@@ -0,0 +1,209 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package packer
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/rpc"
+	"sync"
+
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
+	"github.com/hashicorp/yamux"
+	"github.com/ugorji/go/codec"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// PluginFunctionCallMethod is the RPC method serving the calls of a plugin
+// function. The function is served by the plugin started with
+// `start function <name>`, on the same multiplexed connection as other
+// components.
+const PluginFunctionCallMethod = "Function.Call"
+
+// PluginFunctionCallArgs are the arguments of PluginFunctionCallMethod.
+type PluginFunctionCallArgs struct {
+	// Args are the arguments of the call, JSON encoded with their types by
+	// cty/json, as values of cty.DynamicPseudoType.
+	Args [][]byte
+}
+
+// PluginFunctionCallResponse is the response of PluginFunctionCallMethod.
+type PluginFunctionCallResponse struct {
+	// Result is the JSON encoded result, as a value of the declared return
+	// type of the function.
+	Result []byte
+	// Error is set when the call failed.
+	Error string
+	// ErrorArg is the index of the argument causing Error, if any.
+	ErrorArg *int
+}
+
+// PluginFunctionName returns the name of a plugin function in templates.
+func PluginFunctionName(pluginName, name string) string {
+	return fmt.Sprintf("provider::%s::%s", pluginName, name)
+}
+
+// pluginFunction returns a function calling the function described by desc
+// in the plugin at pluginPath.
+func (c *PluginConfig) pluginFunction(pluginPath string, desc plugingetter.FunctionDescription) (function.Function, error) {
+	if desc.Name == "" {
+		return function.Function{}, errors.New("missing name")
+	}
+
+	spec := &function.Spec{
+		Description: desc.Description,
+	}
+	for _, p := range desc.Params {
+		param, err := functionParameter(p)
+		if err != nil {
+			return function.Function{}, err
+		}
+		spec.Params = append(spec.Params, param)
+	}
+	if desc.VariadicParam != nil {
+		param, err := functionParameter(*desc.VariadicParam)
+		if err != nil {
+			return function.Function{}, err
+		}
+		spec.VarParam = &param
+	}
+
+	var returnType cty.Type
+	if err := json.Unmarshal(desc.ReturnType, &returnType); err != nil {
+		return function.Function{}, fmt.Errorf("invalid return type: %s", err)
+	}
+	spec.Type = function.StaticReturnType(returnType)
+
+	caller := &pluginFunctionCaller{
+		start: func() *PluginClient {
+			return c.Client(pluginPath, "start", "function", desc.Name)
+		},
+	}
+	spec.Impl = caller.call
+
+	return function.New(spec), nil
+}
+
+func functionParameter(p plugingetter.FunctionParameter) (function.Parameter, error) {
+	param := function.Parameter{
+		Name:        p.Name,
+		Description: p.Description,
+		AllowNull:   p.AllowNull,
+	}
+	if err := json.Unmarshal(p.Type, &param.Type); err != nil {
+		return param, fmt.Errorf("invalid type for parameter %q: %s", p.Name, err)
+	}
+	return param, nil
+}
+
+// pluginFunctionCaller calls a plugin function over RPC. The plugin is
+// started on the first call and reused by the next ones.
+type pluginFunctionCaller struct {
+	start func() *PluginClient
+
+	l      sync.Mutex
+	client *rpc.Client
+}
+
+func (f *pluginFunctionCaller) call(args []cty.Value, retType cty.Type) (cty.Value, error) {
+	f.l.Lock()
+	defer f.l.Unlock()
+
+	if f.client == nil {
+		client, err := dialPluginRPC(f.start())
+		if err != nil {
+			return cty.UnknownVal(retType), fmt.Errorf("failed to start plugin: %s", err)
+		}
+		f.client = client
+	}
+
+	result, err := callPluginFunction(f.client, args, retType)
+	if errors.Is(err, rpc.ErrShutdown) {
+		// The plugin exited, it will be restarted on the next call.
+		f.client = nil
+	}
+	return result, err
+}
+
+// callPluginFunction calls the plugin function served by client.
+func callPluginFunction(client *rpc.Client, args []cty.Value, retType cty.Type) (cty.Value, error) {
+	callArgs := &PluginFunctionCallArgs{
+		Args: make([][]byte, 0, len(args)),
+	}
+	for i, arg := range args {
+		encoded, err := ctyjson.Marshal(arg, cty.DynamicPseudoType)
+		if err != nil {
+			return cty.UnknownVal(retType), function.NewArgError(i, err)
+		}
+		callArgs.Args = append(callArgs.Args, encoded)
+	}
+
+	var resp PluginFunctionCallResponse
+	if err := client.Call(PluginFunctionCallMethod, callArgs, &resp); err != nil {
+		return cty.UnknownVal(retType), err
+	}
+	if resp.Error != "" {
+		err := errors.New(resp.Error)
+		if i := resp.ErrorArg; i != nil && *i >= 0 && *i < len(args) {
+			return cty.UnknownVal(retType), function.NewArgError(*i, err)
+		}
+		return cty.UnknownVal(retType), err
+	}
+
+	result, err := ctyjson.Unmarshal(resp.Result, retType)
+	if err != nil {
+		return cty.UnknownVal(retType), fmt.Errorf("invalid result returned by plugin: %s", err)
+	}
+	return result, nil
+}
+
+// dialPluginRPC starts the plugin and returns an RPC client to its main
+// stream. Streams are multiplexed like the SDK does: the client opens a
+// stream, writes its ID, and the plugin acknowledges it with the same ID.
+func dialPluginRPC(c *PluginClient) (*rpc.Client, error) {
+	addr, err := c.Start()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.Dial(addr.Network(), addr.String())
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := yamux.Client(conn, nil)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	stream, err := session.OpenStream()
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+
+	var id, ack uint32
+	if err := binary.Write(stream, binary.LittleEndian, id); err != nil {
+		session.Close()
+		return nil, err
+	}
+	if err := binary.Read(stream, binary.LittleEndian, &ack); err != nil {
+		session.Close()
+		return nil, err
+	}
+	if ack != id {
+		session.Close()
+		return nil, fmt.Errorf("bad ack: %d (expected %d)", ack, id)
+	}
+
+	h := &codec.MsgpackHandle{
+		WriteExt: true,
+	}
+	return rpc.NewClientWithCodec(codec.GoRpc.ClientCodec(stream, h)), nil
+}
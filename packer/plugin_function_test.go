@@ -0,0 +1,155 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package packer
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/rpc"
+	"strings"
+	"testing"
+
+	pluginsdk "github.com/hashicorp/packer-plugin-sdk/plugin"
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
+	"github.com/hashicorp/yamux"
+	"github.com/ugorji/go/codec"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// testFunction is the plugin side of a function joining its arguments with
+// dashes.
+type testFunction struct{}
+
+func (testFunction) Call(args *PluginFunctionCallArgs, resp *PluginFunctionCallResponse) error {
+	parts := []string{}
+	for i, arg := range args.Args {
+		val, err := ctyjson.Unmarshal(arg, cty.DynamicPseudoType)
+		if err != nil {
+			return err
+		}
+		if val.AsString() == "fail" {
+			resp.Error = "fail is not allowed"
+			resp.ErrorArg = &i
+			return nil
+		}
+		parts = append(parts, val.AsString())
+	}
+
+	result, err := ctyjson.Marshal(cty.StringVal(strings.Join(parts, "-")), cty.String)
+	resp.Result = result
+	return err
+}
+
+// serveTestFunction serves testFunction like a plugin started with
+// `start function`.
+func serveTestFunction() error {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s|%s|tcp|%s\n", pluginsdk.APIVersionMajor, pluginsdk.APIVersionMinor, l.Addr())
+
+	conn, err := l.Accept()
+	if err != nil {
+		return err
+	}
+	session, err := yamux.Server(conn, nil)
+	if err != nil {
+		return err
+	}
+	stream, err := session.AcceptStream()
+	if err != nil {
+		return err
+	}
+	var id uint32
+	if err := binary.Read(stream, binary.LittleEndian, &id); err != nil {
+		return err
+	}
+	if err := binary.Write(stream, binary.LittleEndian, id); err != nil {
+		return err
+	}
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("Function", testFunction{}); err != nil {
+		return err
+	}
+	server.ServeCodec(codec.GoRpc.ServerCodec(stream, &codec.MsgpackHandle{WriteExt: true}))
+	return nil
+}
+
+func TestPluginConfig_pluginFunction(t *testing.T) {
+	c := &PluginConfig{}
+	desc := plugingetter.FunctionDescription{
+		Name:        "join",
+		Description: "Joins strings with dashes.",
+		Params: []plugingetter.FunctionParameter{
+			{Name: "first", Type: json.RawMessage(`"string"`)},
+		},
+		VariadicParam: &plugingetter.FunctionParameter{Name: "others", Type: json.RawMessage(`"string"`)},
+		ReturnType:    json.RawMessage(`"string"`),
+	}
+
+	fn, err := c.pluginFunction("packer-plugin-test", desc)
+	if err != nil {
+		t.Fatalf("pluginFunction: %s", err)
+	}
+	if fn.Description() != desc.Description {
+		t.Errorf("unexpected description %q", fn.Description())
+	}
+	if len(fn.Params()) != 1 || fn.Params()[0].Type != cty.String {
+		t.Errorf("unexpected params %#v", fn.Params())
+	}
+	if fn.VarParam() == nil || fn.VarParam().Name != "others" {
+		t.Errorf("unexpected variadic param %#v", fn.VarParam())
+	}
+
+	desc.ReturnType = json.RawMessage(`"strin"`)
+	if _, err := c.pluginFunction("packer-plugin-test", desc); err == nil {
+		t.Errorf("expected an error for an invalid return type")
+	}
+}
+
+func TestPluginFunction_call(t *testing.T) {
+	var clients []*PluginClient
+	defer func() {
+		for _, c := range clients {
+			c.Kill()
+		}
+	}()
+	caller := &pluginFunctionCaller{
+		start: func() *PluginClient {
+			c := NewClient(&PluginClientConfig{Cmd: helperProcess("function")})
+			clients = append(clients, c)
+			return c
+		},
+	}
+	fn := function.New(&function.Spec{
+		VarParam: &function.Parameter{Name: "parts", Type: cty.String},
+		Type:     function.StaticReturnType(cty.String),
+		Impl:     caller.call,
+	})
+
+	for i := 0; i < 2; i++ {
+		got, err := fn.Call([]cty.Value{cty.StringVal("a"), cty.StringVal("b")})
+		if err != nil {
+			t.Fatalf("Call: %s", err)
+		}
+		if !got.RawEquals(cty.StringVal("a-b")) {
+			t.Fatalf("unexpected result %#v", got)
+		}
+	}
+	if len(clients) != 1 {
+		t.Errorf("expected the plugin to be started once, got %d", len(clients))
+	}
+
+	_, err := fn.Call([]cty.Value{cty.StringVal("a"), cty.StringVal("fail")})
+	argErr, ok := err.(function.ArgError)
+	if !ok || argErr.Index != 1 {
+		t.Fatalf("expected an error on the second argument, got %#v", err)
+	}
+}
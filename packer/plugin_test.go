@@ -123,6 +123,11 @@ func TestHelperProcess(*testing.T) {
 			os.Exit(1)
 		}
 		server.Serve()
+	case "function":
+		if err := serveTestFunction(); err != nil {
+			log.Printf("[ERR] %s", err)
+			os.Exit(1)
+		}
 	case "start-timeout":
 		time.Sleep(1 * time.Minute)
 		os.Exit(1)
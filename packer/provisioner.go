@@ -20,6 +20,7 @@ import (
 	"github.com/hashicorp/hcl/v2/hcldec"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 	"github.com/hashicorp/packer-plugin-sdk/packerbuilderdata"
+	"github.com/zclconf/go-cty/cty"
 )
 
 // A HookedProvisioner represents a provisioner and information describing it
@@ -34,6 +35,13 @@ type ProvisionHook struct {
 	// The provisioners to run as part of the hook. These should already
 	// be prepared (by calling Prepare) at some earlier stage.
 	Provisioners []*HookedProvisioner
+
+	// BuildName is the name of the build the provisioners are run for.
+	BuildName string
+	// DebugConsole, when set, is started instead of the breakpoint
+	// provisioners and, if DebugOnError is set, when a provisioner fails.
+	DebugConsole DebugConsole
+	DebugOnError bool
 }
 
 // BuilderDataCommonKeys is the list of common keys that all builder will
@@ -125,11 +133,41 @@ func (h *ProvisionHook) Run(ctx context.Context, name string, ui packersdk.Ui, c
 				"`communicator` config was set to \"none\". If you have any provisioners\n" +
 				"then a communicator is required. Please fix this to continue.")
 	}
-	for _, p := range h.Provisioners {
+	for i, p := range h.Provisioners {
 		ts := CheckpointReporter.AddSpan(p.TypeName, "provisioner", p.Config)
 
 		cast := CastDataToMap(data)
-		err := p.Provisioner.Provision(ctx, ui, comm, cast)
+		session := &DebugSession{
+			BuildName:        h.BuildName,
+			ProvisionerIndex: i,
+			ProvisionerType:  p.TypeName,
+			GeneratedData:    cast,
+			Ui:               ui,
+			Comm:             comm,
+		}
+
+		var err error
+		if h.DebugConsole != nil && p.TypeName == "breakpoint" && !breakpointDisabled(p.Config) {
+			ui.Say(fmt.Sprintf("Pausing at breakpoint provisioner %d, starting the debug console.", i))
+			h.DebugConsole.Run(ctx, session)
+		} else {
+			err = p.Provisioner.Provision(ctx, ui, comm, cast)
+		}
+
+	debug:
+		for err != nil && ctx.Err() == nil && h.DebugConsole != nil && h.DebugOnError {
+			session.Err = err
+			switch h.DebugConsole.Run(ctx, session) {
+			case DebugRetry:
+				ui.Say(fmt.Sprintf("Retrying provisioner %d...", i))
+				err = p.Provisioner.Provision(ctx, ui, comm, cast)
+			case DebugSkip:
+				ui.Say(fmt.Sprintf("Skipping failed provisioner %d.", i))
+				err = nil
+			default:
+				break debug
+			}
+		}
 
 		ts.End(err)
 		if err != nil {
@@ -140,6 +178,23 @@ func (h *ProvisionHook) Run(ctx context.Context, name string, ui packersdk.Ui, c
 	return nil
 }
 
+// breakpointDisabled tells whether the configuration of a breakpoint
+// provisioner, HCL or JSON, disables it.
+func breakpointDisabled(config interface{}) bool {
+	switch config := config.(type) {
+	case cty.Value:
+		if config.IsNull() || !config.IsKnown() || !config.Type().IsObjectType() || !config.Type().HasAttribute("disable") {
+			return false
+		}
+		disable := config.GetAttr("disable")
+		return disable.Type() == cty.Bool && disable.IsKnown() && !disable.IsNull() && disable.True()
+	case map[string]interface{}:
+		disable, _ := config["disable"].(bool)
+		return disable
+	}
+	return false
+}
+
 // PausedProvisioner is a Provisioner implementation that pauses before
 // the provisioner is actually run.
 type PausedProvisioner struct {
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/zclconf/go-cty/cty"
 )
 
 func TestProvisionHook_Impl(t *testing.T) {
@@ -91,6 +92,99 @@ func TestProvisionHook_cancel(t *testing.T) {
 	}
 }
 
+// testDebugConsole records the sessions it is started with, and returns the
+// given actions in order.
+type testDebugConsole struct {
+	actions  []DebugAction
+	sessions []DebugSession
+}
+
+func (c *testDebugConsole) Run(_ context.Context, s *DebugSession) DebugAction {
+	c.sessions = append(c.sessions, *s)
+	action := c.actions[0]
+	c.actions = c.actions[1:]
+	return action
+}
+
+// failingProvisioner fails the given number of times before succeeding.
+type failingProvisioner struct {
+	packersdk.MockProvisioner
+	failures, runs int
+}
+
+func (p *failingProvisioner) Provision(context.Context, packersdk.Ui, packersdk.Communicator, map[string]interface{}) error {
+	p.runs++
+	if p.runs <= p.failures {
+		return fmt.Errorf("failure %d", p.runs)
+	}
+	return nil
+}
+
+func TestProvisionHook_debugConsole(t *testing.T) {
+	tests := []struct {
+		name            string
+		disabled        bool
+		onError         bool
+		failures        int
+		actions         []DebugAction
+		wantErr         bool
+		wantRuns        int
+		wantBreakpoints int
+		wantFailures    int
+	}{
+		{"continue", false, true, 1, []DebugAction{DebugContinue, DebugContinue}, true, 1, 1, 1},
+		{"retry", false, true, 1, []DebugAction{DebugContinue, DebugRetry}, false, 2, 1, 1},
+		{"retry twice", false, true, 2, []DebugAction{DebugContinue, DebugRetry, DebugRetry}, false, 3, 1, 2},
+		{"skip", false, true, 5, []DebugAction{DebugContinue, DebugSkip}, false, 1, 1, 1},
+		{"no on-error=ask", false, false, 1, []DebugAction{DebugContinue}, true, 1, 1, 0},
+		{"disabled breakpoint", true, true, 0, nil, false, 1, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breakpoint := &packersdk.MockProvisioner{}
+			failing := &failingProvisioner{failures: tt.failures}
+			last := &packersdk.MockProvisioner{}
+			console := &testDebugConsole{actions: tt.actions}
+
+			hook := &ProvisionHook{
+				Provisioners: []*HookedProvisioner{
+					{breakpoint, cty.ObjectVal(map[string]cty.Value{"disable": cty.BoolVal(tt.disabled)}), "breakpoint"},
+					{failing, nil, "shell"},
+					{last, nil, "shell"},
+				},
+				BuildName:    "null.test",
+				DebugConsole: console,
+				DebugOnError: tt.onError,
+			}
+			err := hook.Run(context.Background(), "foo", testUi(), new(packersdk.MockCommunicator), nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if failing.runs != tt.wantRuns {
+				t.Errorf("the failing provisioner ran %d times, want %d", failing.runs, tt.wantRuns)
+			}
+			if last.ProvCalled == tt.wantErr {
+				t.Errorf("last provisioner called: %t", last.ProvCalled)
+			}
+			if breakpoint.ProvCalled != tt.disabled {
+				t.Errorf("breakpoint provisioner called: %t", breakpoint.ProvCalled)
+			}
+			if len(console.sessions) != tt.wantBreakpoints+tt.wantFailures {
+				t.Fatalf("the console was started %d times, want %d", len(console.sessions), tt.wantBreakpoints+tt.wantFailures)
+			}
+			for i, s := range console.sessions {
+				wantIndex := 1
+				if i < tt.wantBreakpoints {
+					wantIndex = 0
+				}
+				if s.ProvisionerIndex != wantIndex || (s.Err == nil) != (wantIndex == 0) || s.BuildName != "null.test" {
+					t.Errorf("unexpected session %d: %#v", i, s)
+				}
+			}
+		})
+	}
+}
+
 // TODO(mitchellh): Test that they're run in the proper order
 
 func TestPausedProvisioner_impl(t *testing.T) {
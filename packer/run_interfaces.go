@@ -7,6 +7,7 @@ import (
 	hcl "github.com/hashicorp/hcl/v2"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
+	"github.com/zclconf/go-cty/cty"
 )
 
 type GetBuildsOptions struct {
@@ -34,6 +35,37 @@ type Evaluator interface {
 	EvaluateExpression(expr string) (output string, exit bool, diags hcl.Diagnostics)
 }
 
+// A BuildEvaluator can evaluate the expressions of the console in the context
+// of one of its builds.
+type BuildEvaluator interface {
+	// BuildEvaluator returns an Evaluator in which expressions are evaluated
+	// like the provisioners and post-processors of the build named name see
+	// them.
+	BuildEvaluator(name string) (Evaluator, hcl.Diagnostics)
+}
+
+// A ConsoleCompleter tells what can be completed in the console.
+type ConsoleCompleter interface {
+	// CompletionCandidates returns the variables, functions and attribute
+	// paths that can be used in an expression.
+	CompletionCandidates() []string
+}
+
+// Output is a value exported by a config once its builds completed.
+type Output struct {
+	Name        string
+	Description string
+	Value       cty.Value
+	// Sensitive outputs are not displayed in the output of the commands.
+	Sensitive bool
+}
+
+type OutputEvaluator interface {
+	// EvaluateOutputs evaluates the outputs of the config once its builds
+	// completed, given the artifacts of the successful builds by name.
+	EvaluateOutputs(artifacts map[string][]packersdk.Artifact) ([]Output, hcl.Diagnostics)
+}
+
 type InitializeOptions struct {
 	// When set, the execution of datasources will be skipped and the datasource will provide
 	// an output spec that will be used for validation only.
@@ -46,6 +78,9 @@ type InitializeOptions struct {
 	//
 	// This is optional and defaults to false for now, but this may become a default later.
 	UseSequential bool
+	// AllowUnsetVariables doesn't report input variables without a value as
+	// errors, their value is then unknown. The console uses it.
+	AllowUnsetVariables bool
 }
 
 type PluginBinaryDetector interface {
@@ -65,6 +100,7 @@ type Handler interface {
 	PluginRequirements() (plugingetter.Requirements, hcl.Diagnostics)
 	Evaluator
 	BuildGetter
+	OutputEvaluator
 	ConfigFixer
 	ConfigInspector
 	PluginBinaryDetector
@@ -94,9 +130,31 @@ type ConfigFixer interface {
 
 type InspectConfigOptions struct {
 	packersdk.Ui
+
+	// VarSources lists, for each variable, the sources of its value by
+	// precedence instead of the components of the config.
+	VarSources bool
+	// JSON outputs the inspection as JSON, following a versioned schema.
+	JSON bool
 }
 
 type ConfigInspector interface {
 	// Inspect will output self inspection for a configuration
 	InspectConfig(InspectConfigOptions) (ret int)
 }
+
+type GraphConfigOptions struct {
+	packersdk.Ui
+
+	// Type selects the blocks in the graph: "prereqs" for the variables,
+	// locals and datasources, "builds" for the sources, builds,
+	// provisioners and post-processors, or "full" for all of them.
+	Type string
+	// JSON outputs the graph as JSON instead of DOT.
+	JSON bool
+}
+
+// A ConfigGrapher can output the graph of the blocks of its configuration.
+type ConfigGrapher interface {
+	GraphConfig(GraphConfigOptions) (ret int)
+}
@@ -10,6 +10,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"sync"
 	"time"
 
 	checkpoint "github.com/hashicorp/go-checkpoint"
@@ -41,6 +42,9 @@ type PackerReport struct {
 }
 
 type CheckpointTelemetry struct {
+	// spansLock guards spans, as builds and post-processor chains can add
+	// spans concurrently.
+	spansLock     sync.Mutex
 	spans         []*TelemetrySpan
 	signatureFile string
 	startTime     time.Time
@@ -117,7 +121,9 @@ func (c *CheckpointTelemetry) AddSpan(name, pluginType string, options interface
 		StartTime: time.Now().UTC(),
 		Type:      pluginType,
 	}
+	c.spansLock.Lock()
 	c.spans = append(c.spans, ts)
+	c.spansLock.Unlock()
 	return ts
 }
 
@@ -146,6 +152,9 @@ func (c *CheckpointTelemetry) Finalize(command string, errCode int, err error) e
 	params := c.baseParams(TelemetryVersion)
 	params.EndTime = time.Now().UTC()
 
+	c.spansLock.Lock()
+	defer c.spansLock.Unlock()
+
 	extra := &PackerReport{
 		Spans:    c.spans,
 		ExitCode: errCode,
@@ -21,6 +21,7 @@ import (
 	"github.com/hashicorp/packer-plugin-sdk/template/config"
 	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
 	"github.com/hashicorp/packer-plugin-sdk/tmp"
+	"github.com/hashicorp/packer/internal/gotemplate"
 )
 
 type Config struct {
@@ -67,6 +68,14 @@ type Config struct {
 	// the Packer run, but realize that there are situations where this may be
 	// unavoidable.
 	Generated bool `mapstructure:"generated" required:"false"`
+	// When set, even to an empty map, the files to upload are rendered as Go
+	// templates with these variables, like `{{ .name }}`, before being
+	// uploaded. Every file of a directory is rendered; files that are not
+	// valid UTF-8 text, like images, are uploaded as is. Rendering happens
+	// when the provisioner runs, in a temporary directory, so the sources
+	// are left untouched. See the `gotemplatefile` function for the template
+	// syntax and helpers.
+	TemplateVars map[string]string `mapstructure:"template_vars" required:"false"`
 
 	ctx interpolate.Context
 }
@@ -100,6 +109,10 @@ func (p *Provisioner) Prepare(raws ...interface{}) error {
 		errs = packersdk.MultiErrorAppend(errs,
 			errors.New("Direction must be one of: download, upload."))
 	}
+	if p.config.Direction == "download" && p.config.TemplateVars != nil {
+		errs = packersdk.MultiErrorAppend(errs,
+			errors.New("template_vars can only be used to upload files."))
+	}
 	if p.config.Source != "" {
 		p.config.Sources = append(p.config.Sources, p.config.Source)
 	}
@@ -218,6 +231,16 @@ func (p *Provisioner) ProvisionUpload(ui packersdk.Ui, comm packersdk.Communicat
 			return fmt.Errorf("Error interpolating source: %s", err)
 		}
 
+		if p.config.TemplateVars != nil {
+			rendered, err := p.renderTemplates(src)
+			if err != nil {
+				return fmt.Errorf("Error rendering templates of %s: %s", src, err)
+			}
+			defer os.RemoveAll(rendered.dir)
+			ui.Say(fmt.Sprintf("Rendered templates of %s", src))
+			src = rendered.src
+		}
+
 		ui.Say(fmt.Sprintf("Uploading %s => %s", src, dst))
 
 		info, err := os.Stat(src)
@@ -267,3 +290,49 @@ func (p *Provisioner) ProvisionUpload(ui packersdk.Ui, comm packersdk.Communicat
 	}
 	return nil
 }
+
+// renderedSource is a source whose templates were rendered in the temporary
+// directory dir.
+type renderedSource struct {
+	dir string
+	src string
+}
+
+// renderTemplates renders the file or the directory src with the template
+// variables of the config, in a temporary directory. The rendered source has
+// the same base name as src, and keeps its trailing slash, so that it is
+// uploaded to the same destination.
+func (p *Provisioner) renderTemplates(src string) (renderedSource, error) {
+	info, err := os.Stat(src)
+	if err != nil {
+		return renderedSource{}, err
+	}
+
+	dir, err := tmp.Dir("pkr-file-template")
+	if err != nil {
+		return renderedSource{}, err
+	}
+	res := renderedSource{
+		dir: dir,
+		src: filepath.Join(dir, filepath.Base(src)),
+	}
+
+	vars := make(map[string]interface{}, len(p.config.TemplateVars))
+	for k, v := range p.config.TemplateVars {
+		vars[k] = v
+	}
+
+	if info.IsDir() {
+		err = gotemplate.RenderDir(src, res.src, vars)
+		if strings.HasSuffix(src, "/") {
+			res.src += "/"
+		}
+	} else {
+		err = gotemplate.RenderFile(src, res.src, info.Mode().Perm(), vars)
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		return renderedSource{}, err
+	}
+	return res, nil
+}
@@ -192,6 +192,105 @@ func TestProvisionerProvision_SendsContent(t *testing.T) {
 
 }
 
+func TestProvisionerProvision_SendsTemplate(t *testing.T) {
+	var p Provisioner
+	src := filepath.Join(t.TempDir(), "setup.sh")
+	if err := os.WriteFile(src, []byte(`echo "{{ .name | upper }}: ${1}"`), 0755); err != nil {
+		t.Fatalf("error writing template: %s", err)
+	}
+
+	config := map[string]interface{}{
+		"source":        src,
+		"destination":   "something",
+		"template_vars": map[string]string{"name": "builder"},
+	}
+	if err := p.Prepare(config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	ui := &packersdk.BasicUi{
+		Writer: bytes.NewBuffer(nil),
+		PB:     &packersdk.NoopProgressTracker{},
+	}
+	comm := &packersdk.MockCommunicator{}
+	if err := p.Provision(context.Background(), ui, comm, make(map[string]interface{})); err != nil {
+		t.Fatalf("should successfully provision: %s", err)
+	}
+
+	if comm.UploadData != `echo "BUILDER: ${1}"` {
+		t.Fatalf("should upload the rendered template, got %q", comm.UploadData)
+	}
+	content, err := os.ReadFile(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "{{ .name | upper }}") {
+		t.Fatalf("the source should be left untouched, got %q", content)
+	}
+}
+
+func TestProvisionerProvision_SendsTemplateDir(t *testing.T) {
+	var p Provisioner
+	src := filepath.Join(t.TempDir(), "http")
+	if err := os.Mkdir(src, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "preseed.cfg"), []byte("{{ .hostname }}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := map[string]interface{}{
+		"source":        src + "/",
+		"destination":   "/srv/http",
+		"template_vars": map[string]string{},
+	}
+	if err := p.Prepare(config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	ui := &packersdk.BasicUi{
+		Writer: bytes.NewBuffer(nil),
+		PB:     &packersdk.NoopProgressTracker{},
+	}
+	comm := &packersdk.MockCommunicator{}
+	err := p.Provision(context.Background(), ui, comm, make(map[string]interface{}))
+	if err == nil || !strings.Contains(err.Error(), `map has no entry for key "hostname"`) {
+		t.Fatalf("expected an error for a missing variable, got %v", err)
+	}
+
+	config["template_vars"] = map[string]string{"hostname": "builder"}
+	p = Provisioner{}
+	if err := p.Prepare(config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := p.Provision(context.Background(), ui, comm, make(map[string]interface{})); err != nil {
+		t.Fatalf("should successfully provision: %s", err)
+	}
+	if comm.UploadDirDst != "/srv/http" {
+		t.Fatalf("should upload to configured destination, got %q", comm.UploadDirDst)
+	}
+	// The rendered directory keeps the name and the trailing slash of the
+	// source, and is removed once uploaded.
+	if filepath.Base(comm.UploadDirSrc) != "http" || !strings.HasSuffix(comm.UploadDirSrc, "/") {
+		t.Fatalf("unexpected uploaded directory %q", comm.UploadDirSrc)
+	}
+	if _, err := os.Stat(comm.UploadDirSrc); !os.IsNotExist(err) {
+		t.Fatalf("the rendered directory should be removed, got %v", err)
+	}
+}
+
+func TestProvisionerPrepare_TemplateVarsDownload(t *testing.T) {
+	var p Provisioner
+	config := testConfig()
+	config["source"] = "/tmp/remote"
+	config["direction"] = "download"
+	config["template_vars"] = map[string]string{"name": "builder"}
+
+	if err := p.Prepare(config); err == nil {
+		t.Fatal("should error when rendering templates of downloads")
+	}
+}
+
 func TestProvisionerProvision_SendsFileMultipleFiles(t *testing.T) {
 	var p Provisioner
 	tf1, err := os.CreateTemp("", "packer")